@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"iter"
+	"slices"
 
 	"google.golang.org/genai"
 
@@ -225,7 +226,11 @@ func runBeforeAgentCallbacks(ctx InvocationContext) (*session.Event, error) {
 		actions:           &session.EventActions{StateDelta: make(map[string]any)},
 	}
 
-	for _, callback := range ctx.Agent().internal().beforeAgentCallbacks {
+	// The callbacks of the runner plugins run first.
+	callbacks := pluginCallbacksFromContext(ctx).BeforeAgent
+	callbacks = append(slices.Clip(callbacks), ctx.Agent().internal().beforeAgentCallbacks...)
+
+	for _, callback := range callbacks {
 		content, err := callback(callbackCtx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to run before agent callback: %w", err)
@@ -268,7 +273,11 @@ func runAfterAgentCallbacks(ctx InvocationContext) (*session.Event, error) {
 		actions:           &session.EventActions{StateDelta: make(map[string]any)},
 	}
 
-	for _, callback := range agent.internal().afterAgentCallbacks {
+	// The callbacks of the runner plugins run first.
+	callbacks := pluginCallbacksFromContext(ctx).AfterAgent
+	callbacks = append(slices.Clip(callbacks), agent.internal().afterAgentCallbacks...)
+
+	for _, callback := range callbacks {
 		newContent, err := callback(callbackCtx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to run after agent callback: %w", err)
@@ -432,3 +441,17 @@ func (c *invocationContext) EndInvocation() {
 func (c *invocationContext) Ended() bool {
 	return c.endInvocation
 }
+
+// Value returns nil if the context isn't set.
+func (c *invocationContext) Value(key any) any {
+	if c.Context == nil {
+		return nil
+	}
+	return c.Context.Value(key)
+}
+
+// pluginCallbacksFromContext returns the agent callbacks of the runner
+// plugins.
+func pluginCallbacksFromContext(ctx context.Context) *agentinternal.PluginCallbacks[BeforeAgentCallback, AfterAgentCallback] {
+	return agentinternal.PluginCallbacksFromContext[BeforeAgentCallback, AfterAgentCallback](ctx)
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// ErrLiveRequestQueueClosed is returned when sending to a closed
+// [LiveRequestQueue].
+var ErrLiveRequestQueueClosed = errors.New("live request queue is closed")
+
+// LiveRequestQueue carries the user input to an agent running in the bidi
+// streaming mode. The application sends to the queue while the agent is
+// running, and closes it to end the live session.
+//
+// The queue is unbounded, so sending never blocks. It is safe for concurrent
+// use.
+type LiveRequestQueue struct {
+	mu     sync.Mutex
+	reqs   []*model.LiveRequest
+	closed bool
+	// ready is signaled when a request is added or the queue is closed.
+	ready chan struct{}
+}
+
+// NewLiveRequestQueue creates an empty [LiveRequestQueue].
+func NewLiveRequestQueue() *LiveRequestQueue {
+	return &LiveRequestQueue{ready: make(chan struct{}, 1)}
+}
+
+// Send adds the request to the queue.
+func (q *LiveRequestQueue) Send(req *model.LiveRequest) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrLiveRequestQueueClosed
+	}
+	q.reqs = append(q.reqs, req)
+	q.signal()
+	return nil
+}
+
+// SendContent sends the content in turn-by-turn mode, e.g. a text message.
+func (q *LiveRequestQueue) SendContent(content *genai.Content) error {
+	return q.Send(&model.LiveRequest{Content: content})
+}
+
+// SendRealtime sends the blob in realtime mode, e.g. an audio chunk.
+func (q *LiveRequestQueue) SendRealtime(blob *genai.Blob) error {
+	return q.Send(&model.LiveRequest{Blob: blob})
+}
+
+// SendActivityStart signals the start of the user activity.
+func (q *LiveRequestQueue) SendActivityStart() error {
+	return q.Send(&model.LiveRequest{ActivityStart: true})
+}
+
+// SendActivityEnd signals the end of the user activity.
+func (q *LiveRequestQueue) SendActivityEnd() error {
+	return q.Send(&model.LiveRequest{ActivityEnd: true})
+}
+
+// Close closes the queue. The requests already in the queue are still
+// delivered, after which the live session ends.
+func (q *LiveRequestQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.signal()
+}
+
+// Receive removes and returns the oldest request from the queue, waiting for
+// one if the queue is empty. It returns [io.EOF] once the queue is closed and
+// all its requests are received.
+func (q *LiveRequestQueue) Receive(ctx context.Context) (*model.LiveRequest, error) {
+	for {
+		q.mu.Lock()
+		if len(q.reqs) > 0 {
+			req := q.reqs[0]
+			q.reqs[0] = nil
+			q.reqs = q.reqs[1:]
+			if len(q.reqs) > 0 {
+				// Let the other receivers proceed.
+				q.signal()
+			}
+			q.mu.Unlock()
+			return req, nil
+		}
+		closed := q.closed
+		q.mu.Unlock()
+		if closed {
+			return nil, io.EOF
+		}
+
+		select {
+		case <-q.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// signal must be called with q.mu held.
+func (q *LiveRequestQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+func TestLiveRequestQueue(t *testing.T) {
+	ctx := t.Context()
+	q := NewLiveRequestQueue()
+
+	blob := &genai.Blob{MIMEType: "audio/pcm", Data: []byte{1, 2}}
+	for _, send := range []func() error{
+		func() error { return q.SendContent(genai.NewContentFromText("Hi", genai.RoleUser)) },
+		func() error { return q.SendActivityStart() },
+		func() error { return q.SendRealtime(blob) },
+		func() error { return q.SendActivityEnd() },
+	} {
+		if err := send(); err != nil {
+			t.Fatalf("send error = %v", err)
+		}
+	}
+	q.Close()
+	if err := q.SendContent(genai.NewContentFromText("Bye", genai.RoleUser)); !errors.Is(err, ErrLiveRequestQueueClosed) {
+		t.Errorf("SendContent() after Close() error = %v, want %v", err, ErrLiveRequestQueueClosed)
+	}
+
+	want := []*model.LiveRequest{
+		{Content: genai.NewContentFromText("Hi", genai.RoleUser)},
+		{ActivityStart: true},
+		{Blob: blob},
+		{ActivityEnd: true},
+	}
+	// The requests sent before Close are still received.
+	var got []*model.LiveRequest
+	for {
+		req, err := q.Receive(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Receive() error = %v", err)
+		}
+		got = append(got, req)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Receive() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLiveRequestQueue_ReceiveWaits(t *testing.T) {
+	q := NewLiveRequestQueue()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Receive(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Receive() on an empty queue error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.SendActivityStart()
+	}()
+	req, err := q.Receive(t.Context())
+	if err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	if !req.ActivityStart {
+		t.Errorf("Receive() = %+v, want an activity start", req)
+	}
+}
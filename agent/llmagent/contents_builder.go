@@ -0,0 +1,135 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent
+
+import (
+	"fmt"
+	"slices"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/session"
+)
+
+// ContentsBuilder selects the events of the session sent to the model as the
+// conversation history, e.g. to limit the history or to drop some events. It
+// can also add events, such as user events with retrieved memories.
+//
+// The events returned go through the same processing as the whole history
+// of the default mode: the events of other branches and of the credential
+// and confirmation requests are dropped, the replies of other agents are
+// converted to context for the agent, and the function responses are
+// paired with their function calls. The builder must keep the function
+// calls with their responses.
+//
+// The events are those of the session, which must not be modified.
+type ContentsBuilder func(ctx agent.InvocationContext, events []*session.Event) ([]*session.Event, error)
+
+// ChainContentsBuilders returns a builder applying the builders in order,
+// each to the events selected by the previous one.
+func ChainContentsBuilders(builders ...ContentsBuilder) ContentsBuilder {
+	return func(ctx agent.InvocationContext, events []*session.Event) ([]*session.Event, error) {
+		for _, builder := range builders {
+			var err error
+			if events, err = builder(ctx, events); err != nil {
+				return nil, err
+			}
+		}
+		return events, nil
+	}
+}
+
+// CurrentTurn returns a builder selecting the events of the current turn,
+// starting with the latest event of the user or of another agent, like
+// IncludeContentsNone.
+func CurrentTurn() ContentsBuilder {
+	return func(ctx agent.InvocationContext, events []*session.Event) ([]*session.Event, error) {
+		return llminternal.CurrentTurnEvents(ctx.Agent().Name(), events), nil
+	}
+}
+
+// LastInvocations returns a builder selecting the events of the last n
+// invocations, including the current one. n must be at least 1.
+func LastInvocations(n int) ContentsBuilder {
+	return func(ctx agent.InvocationContext, events []*session.Event) ([]*session.Event, error) {
+		if n < 1 {
+			return nil, fmt.Errorf("LastInvocations: n is %d, must be at least 1", n)
+		}
+		invocations := make(map[string]bool)
+		start := len(events)
+		for ; start > 0; start-- {
+			id := events[start-1].InvocationID
+			if !invocations[id] && len(invocations) == n {
+				break
+			}
+			invocations[id] = true
+		}
+		return events[start:], nil
+	}
+}
+
+// FromAuthors returns a builder selecting the events of the authors, such as
+// "user" and the names of agents. The events of the agent itself are always
+// selected.
+func FromAuthors(authors ...string) ContentsBuilder {
+	return func(ctx agent.InvocationContext, events []*session.Event) ([]*session.Event, error) {
+		return slices.DeleteFunc(slices.Clone(events), func(ev *session.Event) bool {
+			return ev.Author != ctx.Agent().Name() && !slices.Contains(authors, ev.Author)
+		}), nil
+	}
+}
+
+// WithoutPastToolCalls returns a builder dropping the function calls and
+// responses of the previous invocations, except the long-running calls
+// responded to in the current invocation. The events left without parts are
+// dropped.
+func WithoutPastToolCalls() ContentsBuilder {
+	return func(ctx agent.InvocationContext, events []*session.Event) ([]*session.Event, error) {
+		respondedNow := make(map[string]bool)
+		for _, ev := range events {
+			if ev.InvocationID != ctx.InvocationID() || ev.Content == nil {
+				continue
+			}
+			for _, p := range ev.Content.Parts {
+				if p.FunctionResponse != nil {
+					respondedNow[p.FunctionResponse.ID] = true
+				}
+			}
+		}
+
+		var res []*session.Event
+		for _, ev := range events {
+			if ev.InvocationID == ctx.InvocationID() || ev.Content == nil {
+				res = append(res, ev)
+				continue
+			}
+			parts := slices.DeleteFunc(slices.Clone(ev.Content.Parts), func(p *genai.Part) bool {
+				return p.FunctionResponse != nil || (p.FunctionCall != nil && !respondedNow[p.FunctionCall.ID])
+			})
+			switch {
+			case len(parts) == len(ev.Content.Parts):
+				res = append(res, ev)
+			case len(parts) > 0:
+				// The session events are shared, so the event is copied.
+				newEvent := *ev
+				newEvent.Content = &genai.Content{Role: ev.Content.Role, Parts: parts}
+				res = append(res, &newEvent)
+			}
+		}
+		return res, nil
+	}
+}
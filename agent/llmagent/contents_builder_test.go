@@ -0,0 +1,151 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/modeltest"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+func TestContentsBuilder(t *testing.T) {
+	// injectMemory adds the memories of the user before its message.
+	injectMemory := func(ctx agent.InvocationContext, events []*session.Event) ([]*session.Event, error) {
+		i := slices.IndexFunc(events, func(ev *session.Event) bool {
+			return ev.InvocationID == ctx.InvocationID() && ev.Author == "user"
+		})
+		if i < 0 {
+			return events, nil
+		}
+		memory := &session.Event{
+			InvocationID: ctx.InvocationID(),
+			Author:       "user",
+			LLMResponse:  model.LLMResponse{Content: genai.NewContentFromText("Memory: likes tea", genai.RoleUser)},
+		}
+		return slices.Insert(slices.Clone(events), i, memory), nil
+	}
+
+	tests := []struct {
+		name    string
+		builder llmagent.ContentsBuilder
+		want    []string
+	}{
+		{
+			name: "Default",
+			want: []string{
+				"user: Hi", "model: call ping", "user: response ping", "model: Pong.",
+				"user: Again", "model: Hello again.",
+				"user: Last",
+			},
+		},
+		{
+			name:    "CurrentTurn",
+			builder: llmagent.CurrentTurn(),
+			want:    []string{"user: Last"},
+		},
+		{
+			name:    "LastInvocations",
+			builder: llmagent.LastInvocations(2),
+			want:    []string{"user: Again", "model: Hello again.", "user: Last"},
+		},
+		{
+			name:    "FromAuthors",
+			builder: llmagent.FromAuthors(),
+			want:    []string{"model: call ping", "user: response ping", "model: Pong.", "model: Hello again."},
+		},
+		{
+			name:    "WithoutPastToolCalls",
+			builder: llmagent.WithoutPastToolCalls(),
+			want: []string{
+				"user: Hi", "model: Pong.",
+				"user: Again", "model: Hello again.",
+				"user: Last",
+			},
+		},
+		{
+			name:    "Custom",
+			builder: llmagent.ChainContentsBuilders(llmagent.LastInvocations(2), injectMemory),
+			want:    []string{"user: Again", "model: Hello again.", "user: Memory: likes tea", "user: Last"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ping, err := functiontool.New(functiontool.Config{
+				Name:        "ping",
+				Description: "pings",
+			}, func(tool.Context, struct{}) (map[string]any, error) {
+				return map[string]any{"result": "pong"}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			llm := modeltest.New("test-model",
+				modeltest.FunctionCall("ping", map[string]any{}), modeltest.Text("Pong."),
+				modeltest.Text("Hello again."),
+				modeltest.Text("Bye."))
+			a, err := llmagent.New(llmagent.Config{
+				Name:            "agent",
+				Model:           llm,
+				Tools:           []tool.Tool{ping},
+				ContentsBuilder: tc.builder,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			runner := testutil.NewTestAgentRunner(t, a)
+			for _, msg := range []string{"Hi", "Again", "Last"} {
+				if _, err := testutil.CollectEvents(runner.Run(t, "session", msg)); err != nil {
+					t.Fatalf("Run(%q) error = %v", msg, err)
+				}
+			}
+			if diff := cmp.Diff(tc.want, modeltest.Contents(llm.LastRequest())); diff != "" {
+				t.Errorf("model request contents mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLastInvocations_Invalid(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		events := []*session.Event{{InvocationID: "e-1", Author: "user"}}
+		if _, err := llmagent.LastInvocations(n)(nil, events); err == nil {
+			t.Errorf("LastInvocations(%d) error = nil, want error", n)
+		}
+	}
+}
+
+func TestContentsBuilder_WithIncludeContents(t *testing.T) {
+	_, err := llmagent.New(llmagent.Config{
+		Name:            "agent",
+		IncludeContents: llmagent.IncludeContentsNone,
+		ContentsBuilder: llmagent.CurrentTurn(),
+	})
+	if err == nil || !strings.Contains(err.Error(), "can't be used with a ContentsBuilder") {
+		t.Errorf("New() error = %v, want IncludeContents error", err)
+	}
+}
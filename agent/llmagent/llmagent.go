@@ -18,20 +18,27 @@ import (
 	"fmt"
 	"iter"
 	"strings"
+	"time"
 
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/codeexecutor"
 	agentinternal "google.golang.org/adk/internal/agent"
 	icontext "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/internal/llminternal"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/planner"
 	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 )
 
 // New is a constructor for LLMAgent.
 func New(cfg Config) (agent.Agent, error) {
+	if cfg.ContentsBuilder != nil && cfg.IncludeContents != "" && cfg.IncludeContents != IncludeContentsDefault {
+		return nil, fmt.Errorf("IncludeContents %q can't be used with a ContentsBuilder", cfg.IncludeContents)
+	}
+
 	beforeModelCallbacks := make([]llminternal.BeforeModelCallback, 0, len(cfg.BeforeModelCallbacks))
 	for _, c := range cfg.BeforeModelCallbacks {
 		beforeModelCallbacks = append(beforeModelCallbacks, llminternal.BeforeModelCallback(c))
@@ -53,14 +60,20 @@ func New(cfg Config) (agent.Agent, error) {
 	}
 
 	a := &llmAgent{
-		beforeModelCallbacks: beforeModelCallbacks,
-		model:                cfg.Model,
-		afterModelCallbacks:  afterModelCallbacks,
-		beforeToolCallbacks:  beforeToolCallbacks,
-		afterToolCallbacks:   afterToolCallbacks,
-		instruction:          cfg.Instruction,
-		inputSchema:          cfg.InputSchema,
-		outputSchema:         cfg.OutputSchema,
+		beforeModelCallbacks:   beforeModelCallbacks,
+		model:                  cfg.Model,
+		afterModelCallbacks:    afterModelCallbacks,
+		beforeToolCallbacks:    beforeToolCallbacks,
+		afterToolCallbacks:     afterToolCallbacks,
+		instruction:            cfg.Instruction,
+		inputSchema:            cfg.InputSchema,
+		outputSchema:           cfg.OutputSchema,
+		maxContinuations:       cfg.MaxContinuations,
+		maxOutputRepairs:       cfg.MaxOutputRepairs,
+		maxConcurrentToolCalls: cfg.MaxConcurrentToolCalls,
+		toolTimeout:            cfg.ToolTimeout,
+		maxToolErrorRetries:    cfg.MaxToolErrorRetries,
+		toolConfirmationPolicy: cfg.ToolConfirmationPolicy,
 
 		State: llminternal.State{
 			Model:                    cfg.Model,
@@ -73,11 +86,14 @@ func New(cfg Config) (agent.Agent, error) {
 			OutputSchema:             cfg.OutputSchema,
 			// TODO: internal type for includeContents
 			IncludeContents:           string(cfg.IncludeContents),
+			ContentsBuilder:           llminternal.ContentsBuilder(cfg.ContentsBuilder),
 			Instruction:               cfg.Instruction,
 			InstructionProvider:       llminternal.InstructionProvider(cfg.InstructionProvider),
 			GlobalInstruction:         cfg.GlobalInstruction,
 			GlobalInstructionProvider: llminternal.InstructionProvider(cfg.GlobalInstructionProvider),
 			OutputKey:                 cfg.OutputKey,
+			Planner:                   cfg.Planner,
+			CodeExecutor:              cfg.CodeExecutor,
 		},
 	}
 
@@ -157,6 +173,14 @@ type Config struct {
 	// This is the ideal place to log model responses, collect metrics on token
 	// usage, or perform post-processing on the raw `LLMResponse`.
 	AfterModelCallbacks []AfterModelCallback
+	// MaxContinuations is the maximum number of times the model is asked to
+	// continue a response that was cut off because it reached the output
+	// token limit (finish reason MAX_TOKENS). The pieces are stitched into a
+	// single response event.
+	//
+	// Zero, the default, disables the continuation: the truncated response is
+	// returned as is.
+	MaxContinuations int
 
 	// Instruction is set for the LLM model guiding the agent's behavior.
 	//
@@ -213,6 +237,9 @@ type Config struct {
 
 	// Whether to include contents (conversation history) in the model request.
 	IncludeContents IncludeContents
+	// ContentsBuilder selects the events of the conversation history sent to
+	// the model, instead of IncludeContents, which must be left unset.
+	ContentsBuilder ContentsBuilder
 
 	// TODO(ngeorgy): consider to switch to jsonschema for input and output schema.
 	// The input schema when agent is used as a tool.
@@ -221,7 +248,16 @@ type Config struct {
 	//
 	// NOTE: when this is set, agent can only reply and cannot use any tools,
 	// such as function tools, RAGs, agent transfer, etc.
+	//
+	// The final response of the agent is validated against the schema. If it
+	// doesn't match, the agent run fails with an [OutputValidationError].
 	OutputSchema *genai.Schema
+	// MaxOutputRepairs is the maximum number of times the model is asked to
+	// fix a response that doesn't match OutputSchema. The model is given its
+	// response and the validation error.
+	//
+	// Zero, the default, disables the repair.
+	MaxOutputRepairs int
 
 	// Callbacks are executed in the order they are provided.
 	// If a callback returns result/error, then the execution of the callback
@@ -236,6 +272,46 @@ type Config struct {
 	// Toolsets will be used by llmagent to extract tools and pass to the
 	// underlying LLM.
 	Toolsets []tool.Toolset
+	// MaxConcurrentToolCalls is the maximum number of function calls of a
+	// model response that run concurrently. The responses are sent back to
+	// the model in the order of the calls.
+	//
+	// Zero, the default, runs the calls sequentially, as does 1. A negative
+	// value runs all the calls concurrently. Tools and tool callbacks must be
+	// safe for concurrent use to set it above 1 or below 0. When concurrent
+	// calls set the same state key or transfer to different agents, the
+	// latest call in the order of the response wins.
+	MaxConcurrentToolCalls int
+	// ToolTimeout limits the duration of the tool calls. At the deadline the
+	// context of the tool is canceled, and the model gets an error function
+	// response to recover from the timeout. The tools with their own
+	// timeout, e.g. set with functiontool.Config.Timeout, use it instead.
+	//
+	// The tools must honour the cancellation of their context and return
+	// once ctx.Done() is closed. A tool ignoring it keeps running in the
+	// background after the timeout, but its result and its state and
+	// artifact changes are discarded.
+	//
+	// Long-running tools aren't limited by ToolTimeout, only by their own
+	// timeout. Zero, the default, means no timeout.
+	ToolTimeout time.Duration
+	// MaxToolErrorRetries enables the recovery from the function calls the
+	// model gets wrong. Instead of failing the run, the calls of unknown
+	// tools get an error function response listing the available tools, and
+	// the calls with arguments not matching the parameters schema of the tool
+	// get the validation error, without calling the tool. The model can then
+	// correct its calls, in at most MaxToolErrorRetries responses per run of
+	// the agent; past that, the run fails.
+	//
+	// Zero, the default, disables the recovery: the calls of unknown tools
+	// fail the run, and the arguments are validated by the tools.
+	MaxToolErrorRetries int
+	// ToolConfirmationPolicy, if set, decides which tool calls must be
+	// confirmed by the user before running, in addition to the tools
+	// requiring it themselves, e.g. with functiontool.Config.RequireConfirmation.
+	// The agent run pauses with a confirmation request, see package
+	// [google.golang.org/adk/tool/toolconfirmation].
+	ToolConfirmationPolicy tool.ConfirmationPolicy
 
 	// OutputKey is an optional parameter to specify the key in session state for the agent output.
 	//
@@ -243,8 +319,24 @@ type Config struct {
 	// - Extracts agent reply for later use, such as in tools, callbacks, etc.
 	// - Connects agents to coordinate with each other.
 	OutputKey string
+
+	// Planner makes the agent plan before acting, e.g. [planner.NewBuiltIn]
+	// to use the thinking of the model or [planner.NewPlanReAct] to instruct
+	// the model to write a plan. The planning is marked as thoughts, which
+	// aren't part of the agent output and are removed from the history.
+	Planner planner.Planner
+
+	// CodeExecutor lets the agent run the code written by the model, e.g.
+	// [codeexecutor.NewBuiltIn] to use the code execution of the model or
+	// [codeexecutor.NewLocal] to run the code blocks of the responses in a
+	// subprocess. The model is called again with the result of the code.
+	CodeExecutor codeexecutor.CodeExecutor
 }
 
+// OutputValidationError is returned when the final response of an agent
+// doesn't match its [Config.OutputSchema], after all the repair attempts.
+type OutputValidationError = llminternal.OutputValidationError
+
 // BeforeModelCallback that is called before sending a request to the model.
 //
 // If it returns non-nil LLMResponse or error, the actual model call is skipped
@@ -301,34 +393,55 @@ type llmAgent struct {
 
 	inputSchema  *genai.Schema
 	outputSchema *genai.Schema
+
+	maxContinuations       int
+	maxOutputRepairs       int
+	maxConcurrentToolCalls int
+	toolTimeout            time.Duration
+	maxToolErrorRetries    int
+	toolConfirmationPolicy tool.ConfirmationPolicy
 }
 
 type agentState = agentinternal.State
 
 func (a *llmAgent) run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 	// TODO: branch context?
+	// The invocation ID is kept: all the events of an invocation share it,
+	// whichever agent authors them.
 	ctx = icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{
-		Artifacts:   ctx.Artifacts(),
-		Memory:      ctx.Memory(),
-		Session:     ctx.Session(),
-		Branch:      ctx.Branch(),
-		Agent:       a,
-		UserContent: ctx.UserContent(),
-		RunConfig:   ctx.RunConfig(),
+		Artifacts:    ctx.Artifacts(),
+		Memory:       ctx.Memory(),
+		Session:      ctx.Session(),
+		Branch:       ctx.Branch(),
+		Agent:        a,
+		InvocationID: ctx.InvocationID(),
+		UserContent:  ctx.UserContent(),
+		RunConfig:    ctx.RunConfig(),
 	})
 
 	f := &llminternal.Flow{
-		Model:                a.model,
-		RequestProcessors:    llminternal.DefaultRequestProcessors,
-		ResponseProcessors:   llminternal.DefaultResponseProcessors,
-		BeforeModelCallbacks: a.beforeModelCallbacks,
-		AfterModelCallbacks:  a.afterModelCallbacks,
-		BeforeToolCallbacks:  a.beforeToolCallbacks,
-		AfterToolCallbacks:   a.afterToolCallbacks,
+		Model:                  a.model,
+		RequestProcessors:      llminternal.DefaultRequestProcessors,
+		ResponseProcessors:     llminternal.DefaultResponseProcessors,
+		BeforeModelCallbacks:   a.beforeModelCallbacks,
+		AfterModelCallbacks:    a.afterModelCallbacks,
+		BeforeToolCallbacks:    a.beforeToolCallbacks,
+		AfterToolCallbacks:     a.afterToolCallbacks,
+		MaxContinuations:       a.maxContinuations,
+		MaxOutputRepairs:       a.maxOutputRepairs,
+		MaxConcurrentToolCalls: a.maxConcurrentToolCalls,
+		ToolTimeout:            a.toolTimeout,
+		MaxToolErrorRetries:    a.maxToolErrorRetries,
+		ToolConfirmationPolicy: a.toolConfirmationPolicy,
+	}
+
+	run := f.Run
+	if cfg := ctx.RunConfig(); cfg != nil && cfg.StreamingMode == agent.StreamingModeBidi {
+		run = f.RunLive
 	}
 
 	return func(yield func(*session.Event, error) bool) {
-		for ev, err := range f.Run(ctx) {
+		for ev, err := range run(ctx) {
 			a.maybeSaveOutputToState(ev)
 			if !yield(ev, err) {
 				return
@@ -356,7 +469,7 @@ func (a *llmAgent) maybeSaveOutputToState(event *session.Event) {
 		}
 		result := sb.String()
 
-		// TODO: add output schema validation and unmarshalling
+		// The output was validated against the schema by the flow.
 		if a.OutputSchema != nil {
 			// If the result from the final chunk is just whitespace or empty,
 			// it means this is an empty final chunk of a stream.
@@ -15,26 +15,39 @@
 package llmagent_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"iter"
 	"net/http"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/codeexecutor"
 	"google.golang.org/adk/internal/httprr"
 	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/model/gemini"
+	"google.golang.org/adk/model/modeltest"
+	"google.golang.org/adk/planner"
+	"google.golang.org/adk/runner"
 	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/adk/tool/toolconfirmation"
 )
 
 const modelName = "gemini-2.0-flash"
@@ -936,6 +949,483 @@ func TestAgentTransfer(t *testing.T) {
 	//   - test_auto_to_loop
 }
 
+func TestMaxContinuations(t *testing.T) {
+	truncated := func(text string) modeltest.Turn {
+		return modeltest.Turn{Responses: []*model.LLMResponse{{
+			Content:       genai.NewContentFromText(text, genai.RoleModel),
+			FinishReason:  genai.FinishReasonMaxTokens,
+			UsageMetadata: &genai.GenerateContentResponseUsageMetadata{CandidatesTokenCount: 2},
+		}}}
+	}
+	stop := func(content *genai.Content) modeltest.Turn {
+		return modeltest.Turn{Responses: []*model.LLMResponse{{
+			Content:       content,
+			FinishReason:  genai.FinishReasonStop,
+			UsageMetadata: &genai.GenerateContentResponseUsageMetadata{CandidatesTokenCount: 1},
+		}}}
+	}
+
+	for _, tc := range []struct {
+		name             string
+		maxContinuations int
+		turns            []modeltest.Turn
+		wantParts        []*genai.Part
+		wantFinishReason genai.FinishReason
+		wantTokens       int32
+		wantCalls        int
+	}{
+		{
+			name:             "disabled",
+			turns:            []modeltest.Turn{truncated("Hel")},
+			wantParts:        []*genai.Part{genai.NewPartFromText("Hel")},
+			wantFinishReason: genai.FinishReasonMaxTokens,
+			wantTokens:       2,
+			wantCalls:        1,
+		},
+		{
+			name:             "stitched",
+			maxContinuations: 3,
+			turns:            []modeltest.Turn{truncated("Hel"), truncated("lo, wor"), stop(genai.NewContentFromText("ld!", genai.RoleModel))},
+			wantParts:        []*genai.Part{genai.NewPartFromText("Hello, world!")},
+			wantFinishReason: genai.FinishReasonStop,
+			wantTokens:       5,
+			wantCalls:        3,
+		},
+		{
+			name:             "max continuations reached",
+			maxContinuations: 1,
+			turns:            []modeltest.Turn{truncated("Hel"), truncated("lo, wor"), stop(genai.NewContentFromText("ld!", genai.RoleModel))},
+			wantParts:        []*genai.Part{genai.NewPartFromText("Hello, wor")},
+			wantFinishReason: genai.FinishReasonMaxTokens,
+			wantTokens:       4,
+			wantCalls:        2,
+		},
+		{
+			name:             "truncated function call",
+			maxContinuations: 1,
+			turns: []modeltest.Turn{
+				{Responses: []*model.LLMResponse{{
+					Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+						genai.NewPartFromText("Bye."),
+						genai.NewPartFromFunctionCall("exit", map[string]any{"co": "incomplete"}),
+					}},
+					FinishReason: genai.FinishReasonMaxTokens,
+				}}},
+				stop(&genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+					genai.NewPartFromText(" Exiting."),
+					genai.NewPartFromFunctionCall("exit", map[string]any{"code": 0.0}),
+				}}),
+			},
+			wantParts: []*genai.Part{
+				genai.NewPartFromText("Bye. Exiting."),
+				genai.NewPartFromFunctionCall("exit", map[string]any{"code": 0.0}),
+			},
+			wantFinishReason: genai.FinishReasonStop,
+			wantTokens:       1,
+			wantCalls:        2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			llm := modeltest.New("test-model", tc.turns...)
+			a, err := llmagent.New(llmagent.Config{
+				Name:             "agent",
+				Model:            llm,
+				MaxContinuations: tc.maxContinuations,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Only consume the model response: the stitched function call is
+			// not meant to be run.
+			var got *session.Event
+			for ev, err := range testutil.NewTestAgentRunner(t, a).Run(t, "session", "hi") {
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+				got = ev
+				break
+			}
+
+			if diff := cmp.Diff(tc.wantParts, got.Content.Parts, cmpopts.IgnoreFields(genai.FunctionCall{}, "ID")); diff != "" {
+				t.Errorf("response parts mismatch (-want +got):\n%s", diff)
+			}
+			if got.FinishReason != tc.wantFinishReason {
+				t.Errorf("FinishReason = %v, want %v", got.FinishReason, tc.wantFinishReason)
+			}
+			if got.UsageMetadata.CandidatesTokenCount != tc.wantTokens {
+				t.Errorf("CandidatesTokenCount = %d, want %d", got.UsageMetadata.CandidatesTokenCount, tc.wantTokens)
+			}
+			requests := llm.Requests()
+			if len(requests) != tc.wantCalls {
+				t.Fatalf("model called %d times, want %d", len(requests), tc.wantCalls)
+			}
+			if tc.wantCalls > 1 {
+				// The continuation request ends with the output so far and the
+				// continuation prompt.
+				contents := requests[1].Contents
+				if n := len(contents); n < 2 || contents[n-2].Role != genai.RoleModel || contents[n-1].Role != genai.RoleUser {
+					t.Errorf("continuation request contents = %v, want the model output followed by a user prompt", contents)
+				}
+			}
+		})
+	}
+}
+
+func TestOutputSchemaValidation(t *testing.T) {
+	schema := &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"city": {Type: genai.TypeString},
+		},
+		Required: []string{"city"},
+	}
+	valid, missingCity, notJSON := modeltest.Text(`{"city": "Paris"}`), modeltest.Text(`{"town": "Paris"}`), modeltest.Text("Paris")
+
+	for _, tc := range []struct {
+		name             string
+		maxOutputRepairs int
+		turns            []modeltest.Turn
+		wantOutput       string
+		wantAttempts     int // of the OutputValidationError, if any
+		wantCalls        int
+	}{
+		{
+			name:       "valid",
+			turns:      []modeltest.Turn{valid},
+			wantOutput: `{"city": "Paris"}`,
+			wantCalls:  1,
+		},
+		{
+			name:         "invalid without repair",
+			turns:        []modeltest.Turn{notJSON},
+			wantAttempts: 1,
+			wantCalls:    1,
+		},
+		{
+			name:             "repaired",
+			maxOutputRepairs: 2,
+			turns:            []modeltest.Turn{notJSON, valid},
+			wantOutput:       `{"city": "Paris"}`,
+			wantCalls:        2,
+		},
+		{
+			name:             "repair failed",
+			maxOutputRepairs: 1,
+			turns:            []modeltest.Turn{notJSON, missingCity, valid},
+			wantAttempts:     2,
+			wantCalls:        2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			llm := modeltest.New("test-model", tc.turns...)
+			a, err := llmagent.New(llmagent.Config{
+				Name:             "agent",
+				Model:            llm,
+				OutputSchema:     schema,
+				OutputKey:        "result",
+				MaxOutputRepairs: tc.maxOutputRepairs,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var events []*session.Event
+			var runErr error
+			for ev, err := range testutil.NewTestAgentRunner(t, a).Run(t, "session", "Where is the Eiffel Tower?") {
+				if err != nil {
+					runErr = err
+					break
+				}
+				events = append(events, ev)
+			}
+
+			if tc.wantAttempts > 0 {
+				var validationErr *llmagent.OutputValidationError
+				if !errors.As(runErr, &validationErr) {
+					t.Fatalf("Run() error = %v, want an OutputValidationError", runErr)
+				}
+				if validationErr.Attempts != tc.wantAttempts {
+					t.Errorf("OutputValidationError.Attempts = %d, want %d", validationErr.Attempts, tc.wantAttempts)
+				}
+				if len(events) != 0 {
+					t.Errorf("Run() yielded %d events, want none", len(events))
+				}
+			} else {
+				if runErr != nil {
+					t.Fatalf("Run() error = %v", runErr)
+				}
+				if len(events) != 1 {
+					t.Fatalf("Run() yielded %d events, want 1", len(events))
+				}
+				if got := events[0].Actions.StateDelta["result"]; got != tc.wantOutput {
+					t.Errorf("state[result] = %v, want %v", got, tc.wantOutput)
+				}
+			}
+
+			requests := llm.Requests()
+			if len(requests) != tc.wantCalls {
+				t.Fatalf("model called %d times, want %d", len(requests), tc.wantCalls)
+			}
+			if tc.wantCalls > 1 {
+				// The repair request shows the invalid output and the validation error.
+				contents := requests[1].Contents
+				last := contents[len(contents)-1]
+				if want := tc.turns[0].Responses[0].Content; contents[len(contents)-2] != want {
+					t.Errorf("repair request output = %v, want %v", contents[len(contents)-2], want)
+				}
+				if last.Role != genai.RoleUser || !strings.Contains(last.Parts[0].Text, "failed to parse output JSON") {
+					t.Errorf("repair request prompt = %v, want the validation error", last.Parts[0].Text)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanner(t *testing.T) {
+	t.Run("PlanReAct", func(t *testing.T) {
+		llm := modeltest.New("test-model",
+			modeltest.Text("/*PLANNING*/ Answer directly. /*FINAL_ANSWER*/ Paris."),
+			modeltest.Text("/*FINAL_ANSWER*/ Berlin."),
+		)
+		a, err := llmagent.New(llmagent.Config{
+			Name:    "agent",
+			Model:   llm,
+			Planner: planner.NewPlanReAct(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		runner := testutil.NewTestAgentRunner(t, a)
+		for _, prompt := range []string{"Capital of France?", "Capital of Germany?"} {
+			for _, err := range runner.Run(t, "session", prompt) {
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+			}
+		}
+		if err := llm.Done(); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := modeltest.SystemInstruction(llm.LastRequest()); !strings.Contains(got, planner.PlanningTag) {
+			t.Errorf("system instruction = %q, want the planning instruction", got)
+		}
+		// The planning and reasoning of the first answer are left out.
+		want := []string{"user: Capital of France?", "model:  Paris.", "user: Capital of Germany?"}
+		if diff := cmp.Diff(want, modeltest.Contents(llm.LastRequest())); diff != "" {
+			t.Errorf("request contents mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("BuiltIn", func(t *testing.T) {
+		thinkingConfig := &genai.ThinkingConfig{IncludeThoughts: true}
+		llm := modeltest.New("test-model", modeltest.Text("Paris."))
+		a, err := llmagent.New(llmagent.Config{
+			Name:    "agent",
+			Model:   llm,
+			Planner: planner.NewBuiltIn(thinkingConfig),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, err := range testutil.NewTestAgentRunner(t, a).Run(t, "session", "Capital of France?") {
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+		}
+		if got := llm.LastRequest().Config.ThinkingConfig; got != thinkingConfig {
+			t.Errorf("ThinkingConfig = %v, want %v", got, thinkingConfig)
+		}
+	})
+}
+
+func TestCodeExecutor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test runs a shell script")
+	}
+	executor, err := codeexecutor.NewLocal(codeexecutor.LocalConfig{Command: []string{"sh"}, FileName: "main.sh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	llm := modeltest.New("test-model",
+		modeltest.Text("Let me compute it.\n```tool_code\necho $((6 * 7)) | tee answer.txt\n```"),
+		modeltest.Text("The answer is 42."),
+	)
+	a, err := llmagent.New(llmagent.Config{
+		Name:         "agent",
+		Model:        llm,
+		CodeExecutor: executor,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := t.Context()
+	sessionService, artifactService := session.InMemoryService(), artifact.InMemoryService()
+	r, err := runner.New(runner.Config{
+		AppName:         "app",
+		Agent:           a,
+		SessionService:  sessionService,
+		ArtifactService: artifactService,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	var events []*session.Event
+	for ev, err := range r.Run(ctx, "user", "session", genai.NewContentFromText("What is 6 times 7?", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		events = append(events, ev)
+	}
+	if err := llm.Done(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Run() yielded %d events, want 3", len(events))
+	}
+	wantCode := genai.NewPartFromExecutableCode("echo $((6 * 7)) | tee answer.txt", genai.LanguagePython)
+	if diff := cmp.Diff(wantCode, events[0].Content.Parts[len(events[0].Content.Parts)-1]); diff != "" {
+		t.Errorf("code event mismatch (-want +got):\n%s", diff)
+	}
+	wantResult := genai.NewPartFromCodeExecutionResult(genai.OutcomeOK, "Code execution result:\n42\n\n\n\nSaved artifacts:\n`answer.txt`")
+	if diff := cmp.Diff(wantResult, events[1].Content.Parts[0]); diff != "" {
+		t.Errorf("result event mismatch (-want +got):\n%s", diff)
+	}
+	if got, want := events[1].Actions.ArtifactDelta, map[string]int64{"answer.txt": 1}; !cmp.Equal(got, want) {
+		t.Errorf("result event ArtifactDelta = %v, want %v", got, want)
+	}
+
+	// The code and its result are sent back to the model as text.
+	want := []string{
+		"user: What is 6 times 7?",
+		"model: Let me compute it.\n, ```tool_code\necho $((6 * 7)) | tee answer.txt\n```",
+		"user: ```tool_output\nCode execution result:\n42\n\n\n\nSaved artifacts:\n`answer.txt`\n```",
+	}
+	if diff := cmp.Diff(want, modeltest.Contents(llm.LastRequest())); diff != "" {
+		t.Errorf("request contents mismatch (-want +got):\n%s", diff)
+	}
+
+	resp, err := artifactService.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "answer.txt"})
+	if err != nil {
+		t.Fatalf("failed to load the artifact: %v", err)
+	}
+	if got := string(resp.Part.InlineData.Data); got != "42\n" {
+		t.Errorf("artifact = %q, want %q", got, "42\n")
+	}
+}
+
+func TestToolAuth(t *testing.T) {
+	authConfig := &auth.Config{
+		Scheme: &auth.Scheme{Type: auth.SchemeTypeAPIKey, In: "header", Name: "X-Key"},
+	}
+	type Args struct{}
+	profileTool, err := functiontool.New(functiontool.Config{
+		Name:        "get_profile",
+		Description: "returns the profile of the user",
+	}, func(ctx tool.Context, args Args) (map[string]any, error) {
+		cred, err := tool.Credential(ctx, authConfig)
+		if err != nil {
+			return nil, err
+		}
+		if cred == nil {
+			if err := tool.RequestCredential(ctx, authConfig); err != nil {
+				return nil, err
+			}
+			return map[string]any{"status": "pending"}, nil
+		}
+		return map[string]any{"profile": "profile of " + cred.APIKey}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	llm := modeltest.New("test-model",
+		modeltest.FunctionCall("get_profile", nil),
+		modeltest.Text("You are Alice."),
+	)
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "agent",
+		Model: llm,
+		Tools: []tool.Tool{profileTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+
+	// The tool requests the credential, which pauses the run.
+	events, err := testutil.CollectEvents(runner.Run(t, "session", "Who am I?"))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Run() yielded %d events, want 3", len(events))
+	}
+	callID := events[0].Content.Parts[0].FunctionCall.ID
+	authEvent := events[2]
+	if !authEvent.IsFinalResponse() {
+		t.Errorf("credential request event isn't final")
+	}
+	request := authEvent.Content.Parts[0].FunctionCall
+	if request.Name != "adk_request_credential" || !slices.Equal(authEvent.LongRunningToolIDs, []string{request.ID}) {
+		t.Fatalf("credential request = %+v, want a long running adk_request_credential call", request)
+	}
+	if got := request.Args["functionCallId"]; got != callID {
+		t.Errorf("credential request functionCallId = %v, want %v", got, callID)
+	}
+
+	// The client provides the credential, and the tool is called again.
+	var requested auth.Config
+	data, err := json.Marshal(request.Args["authConfig"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &requested); err != nil {
+		t.Fatal(err)
+	}
+	requested.ExchangedCredential = &auth.Credential{Type: auth.CredentialTypeAPIKey, APIKey: "alice-key"}
+	data, err = json.Marshal(requested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response map[string]any
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatal(err)
+	}
+	answer := &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{
+		ID:       request.ID,
+		Name:     "adk_request_credential",
+		Response: response,
+	}}}}
+	texts, err := testutil.CollectTextParts(runner.RunContent(t, "session", answer))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if diff := cmp.Diff([]string{"You are Alice."}, texts); diff != "" {
+		t.Errorf("Run() texts mismatch (-want +got):\n%s", diff)
+	}
+	if err := llm.Done(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The model only sees the response of the tool with the credential.
+	req := llm.LastRequest()
+	want := []string{"user: Who am I?", "model: call get_profile", "user: response get_profile"}
+	if diff := cmp.Diff(want, modeltest.Contents(req)); diff != "" {
+		t.Errorf("request contents mismatch (-want +got):\n%s", diff)
+	}
+	if got := req.Contents[2].Parts[0].FunctionResponse.Response["profile"]; got != "profile of alice-key" {
+		t.Errorf("function response profile = %v, want %q", got, "profile of alice-key")
+	}
+}
+
 func newGeminiModel(t *testing.T, modelName string, transport http.RoundTripper) model.LLM {
 	apiKey := "fakeKey"
 	if transport == nil { // use httprr
@@ -972,3 +1462,497 @@ type roundTripperFunc func(*http.Request) (*http.Response, error)
 func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return fn(req)
 }
+
+func TestParallelFunctionCalls(t *testing.T) {
+	cities := []string{"Paris", "Tokyo", "Lima"}
+	var calls []*genai.Part
+	for _, city := range cities {
+		calls = append(calls, genai.NewPartFromFunctionCall("get_weather", map[string]any{"city": city}))
+	}
+	parallelCalls := modeltest.Turn{Responses: []*model.LLMResponse{{
+		Content:      genai.NewContentFromParts(calls, genai.RoleModel),
+		TurnComplete: true,
+	}}}
+
+	for _, tc := range []struct {
+		name          string
+		maxConcurrent int
+		wantInFlight  int32
+	}{
+		{name: "Default", maxConcurrent: 0, wantInFlight: 1},
+		{name: "Sequential", maxConcurrent: 1, wantInFlight: 1},
+		{name: "Unlimited", maxConcurrent: -1, wantInFlight: int32(len(cities))},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var inFlight, maxInFlight atomic.Int32
+			// allStarted is closed when wantInFlight calls run at the same time.
+			allStarted := make(chan struct{})
+			type Args struct {
+				City string `json:"city"`
+			}
+			weatherTool, err := functiontool.New(functiontool.Config{
+				Name:        "get_weather",
+				Description: "returns the weather in a city",
+			}, func(ctx tool.Context, args Args) (map[string]any, error) {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for m := maxInFlight.Load(); n > m && !maxInFlight.CompareAndSwap(m, n); m = maxInFlight.Load() {
+				}
+				if n == tc.wantInFlight && tc.wantInFlight > 1 {
+					close(allStarted)
+				}
+				if tc.wantInFlight > 1 {
+					select {
+					case <-allStarted:
+					case <-time.After(5 * time.Second):
+						return nil, errors.New("calls didn't run concurrently")
+					}
+				}
+				if err := ctx.State().Set("weather:"+args.City, "sunny"); err != nil {
+					return nil, err
+				}
+				return map[string]any{"city": args.City}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			llm := modeltest.New("test-model", parallelCalls, modeltest.Text("It's sunny everywhere."))
+			a, err := llmagent.New(llmagent.Config{
+				Name:                   "agent",
+				Model:                  llm,
+				Tools:                  []tool.Tool{weatherTool},
+				MaxConcurrentToolCalls: tc.maxConcurrent,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "What's the weather?"))
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if err := llm.Done(); err != nil {
+				t.Error(err)
+			}
+			if got := maxInFlight.Load(); got != tc.wantInFlight {
+				t.Errorf("max concurrent calls = %d, want %d", got, tc.wantInFlight)
+			}
+			if len(events) != 3 {
+				t.Fatalf("Run() yielded %d events, want 3", len(events))
+			}
+			var gotCities []any
+			for _, p := range events[1].Content.Parts {
+				gotCities = append(gotCities, p.FunctionResponse.Response["city"])
+			}
+			if diff := cmp.Diff([]any{"Paris", "Tokyo", "Lima"}, gotCities); diff != "" {
+				t.Errorf("function responses mismatch (-want +got):\n%s", diff)
+			}
+			wantDelta := map[string]any{"weather:Paris": "sunny", "weather:Tokyo": "sunny", "weather:Lima": "sunny"}
+			if diff := cmp.Diff(wantDelta, events[1].Actions.StateDelta); diff != "" {
+				t.Errorf("StateDelta mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestToolTimeout(t *testing.T) {
+	type Args struct{}
+	newTool := func(cfg functiontool.Config, handler functiontool.Func[Args, map[string]any]) tool.Tool {
+		t.Helper()
+		tool, err := functiontool.New(cfg, handler)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tool
+	}
+	waitForCancellation := func(ctx tool.Context, args Args) (map[string]any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	// released unblocks the tools ignoring the cancellation at the end of the test.
+	released := make(chan struct{})
+	defer close(released)
+
+	for _, tc := range []struct {
+		name         string
+		tool         tool.Tool
+		toolTimeout  time.Duration
+		wantResponse map[string]any
+		wantDelta    map[string]any
+	}{
+		{
+			name:         "AgentTimeout",
+			tool:         newTool(functiontool.Config{Name: "slow_tool"}, waitForCancellation),
+			toolTimeout:  10 * time.Millisecond,
+			wantResponse: map[string]any{"error": `tool "slow_tool" timed out after 10ms`},
+		},
+		{
+			name:         "ToolTimeout",
+			tool:         newTool(functiontool.Config{Name: "slow_tool", Timeout: 10 * time.Millisecond}, waitForCancellation),
+			toolTimeout:  time.Hour,
+			wantResponse: map[string]any{"error": `tool "slow_tool" timed out after 10ms`},
+		},
+		{
+			name: "IgnoredCancellation",
+			tool: newTool(functiontool.Config{Name: "hung_tool"}, func(ctx tool.Context, args Args) (map[string]any, error) {
+				if err := ctx.State().Set("started", true); err != nil {
+					return nil, err
+				}
+				<-released
+				return map[string]any{"result": "done"}, nil
+			}),
+			toolTimeout:  10 * time.Millisecond,
+			wantResponse: map[string]any{"error": `tool "hung_tool" timed out after 10ms`},
+		},
+		{
+			name: "InTime",
+			tool: newTool(functiontool.Config{Name: "fast_tool"}, func(ctx tool.Context, args Args) (map[string]any, error) {
+				if err := ctx.State().Set("started", true); err != nil {
+					return nil, err
+				}
+				return map[string]any{"result": "done"}, nil
+			}),
+			toolTimeout:  time.Hour,
+			wantResponse: map[string]any{"result": "done"},
+			wantDelta:    map[string]any{"started": true},
+		},
+		{
+			name: "LongRunningToolExempt",
+			tool: newTool(functiontool.Config{Name: "long_running_tool", IsLongRunning: true}, func(ctx tool.Context, args Args) (map[string]any, error) {
+				_, hasDeadline := ctx.Deadline()
+				return map[string]any{"hasDeadline": hasDeadline}, nil
+			}),
+			toolTimeout:  time.Millisecond,
+			wantResponse: map[string]any{"hasDeadline": false},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			llm := modeltest.New("test-model",
+				modeltest.FunctionCall(tc.tool.Name(), nil),
+				modeltest.Text("Done."),
+			)
+			a, err := llmagent.New(llmagent.Config{
+				Name:        "agent",
+				Model:       llm,
+				Tools:       []tool.Tool{tc.tool},
+				ToolTimeout: tc.toolTimeout,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "Go!"))
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if len(events) < 2 {
+				t.Fatalf("Run() yielded %d events, want at least 2", len(events))
+			}
+			fnResponseEvent := events[1]
+			if diff := cmp.Diff(tc.wantResponse, fnResponseEvent.Content.Parts[0].FunctionResponse.Response); diff != "" {
+				t.Errorf("function response mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantDelta, fnResponseEvent.Actions.StateDelta, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("StateDelta mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestToolTimeout_LateWrites checks, with -race, that a tool changing its
+// state after its deadline doesn't touch the events of the invocation.
+func TestToolTimeout_LateWrites(t *testing.T) {
+	type Args struct{}
+	for _, tc := range []struct {
+		name string
+		// untilStop makes the tool write until the end of the invocation.
+		untilStop bool
+	}{
+		{name: "WriteAndReturn"},
+		{name: "WriteUntilEnd", untilStop: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stop, stopped := make(chan struct{}), make(chan struct{})
+			lateTool, err := functiontool.New(functiontool.Config{Name: "late_tool"}, func(ctx tool.Context, args Args) (map[string]any, error) {
+				defer close(stopped)
+				<-ctx.Done()
+				for i := 0; ; i++ {
+					if err := ctx.State().Set(fmt.Sprintf("late_%d", i%10), i); err != nil {
+						return nil, err
+					}
+					if !tc.untilStop {
+						return map[string]any{"result": "late"}, nil
+					}
+					select {
+					case <-stop:
+						return map[string]any{"result": "late"}, nil
+					default:
+					}
+				}
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := llmagent.New(llmagent.Config{
+				Name:        "agent",
+				Model:       modeltest.New("test-model", modeltest.FunctionCall("late_tool", nil), modeltest.Text("Done.")),
+				Tools:       []tool.Tool{lateTool},
+				ToolTimeout: 10 * time.Millisecond,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "Go!"))
+			close(stop)
+			<-stopped
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			for _, ev := range events {
+				if len(ev.Actions.StateDelta) > 0 {
+					t.Errorf("event of %q has StateDelta %v, want none", ev.Author, ev.Actions.StateDelta)
+				}
+			}
+		})
+	}
+}
+
+func TestToolErrorRecovery(t *testing.T) {
+	type Args struct {
+		City string `json:"city"`
+	}
+	weatherTool, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather in a city",
+	}, func(ctx tool.Context, args Args) (map[string]any, error) {
+		return map[string]any{"weather": "sunny in " + args.City}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknownToolCall := modeltest.FunctionCall("get_wether", map[string]any{"city": "Paris"})
+	invalidArgsCall := modeltest.FunctionCall("get_weather", map[string]any{"town": "Paris"})
+	validCall := modeltest.FunctionCall("get_weather", map[string]any{"city": "Paris"})
+
+	for _, tc := range []struct {
+		name          string
+		maxRetries    int
+		turns         []modeltest.Turn
+		wantResponses []map[string]any
+		wantErr       string
+	}{
+		{
+			name:    "Disabled",
+			turns:   []modeltest.Turn{unknownToolCall},
+			wantErr: `unknown tool: "get_wether"`,
+		},
+		{
+			name:       "Recovered",
+			maxRetries: 2,
+			turns:      []modeltest.Turn{unknownToolCall, invalidArgsCall, validCall, modeltest.Text("It's sunny.")},
+			wantResponses: []map[string]any{
+				{"error": `tool "get_wether" not found`, "availableTools": []string{"get_weather"}},
+				{"error": `invalid arguments for tool "get_weather": validating root: unexpected additional properties ["town"]`},
+				{"weather": "sunny in Paris"},
+			},
+		},
+		{
+			name:       "TooManyRetries",
+			maxRetries: 1,
+			turns:      []modeltest.Turn{invalidArgsCall, unknownToolCall},
+			wantResponses: []map[string]any{
+				{"error": `invalid arguments for tool "get_weather": validating root: unexpected additional properties ["town"]`},
+			},
+			wantErr: `unknown tool: "get_wether"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			llm := modeltest.New("test-model", tc.turns...)
+			a, err := llmagent.New(llmagent.Config{
+				Name:                "agent",
+				Model:               llm,
+				Tools:               []tool.Tool{weatherTool},
+				MaxToolErrorRetries: tc.maxRetries,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotResponses []map[string]any
+			var gotErr string
+			for ev, err := range testutil.NewTestAgentRunner(t, a).Run(t, "session", "What's the weather in Paris?") {
+				if err != nil {
+					gotErr = err.Error()
+					break
+				}
+				for _, p := range ev.Content.Parts {
+					if p.FunctionResponse != nil {
+						gotResponses = append(gotResponses, p.FunctionResponse.Response)
+					}
+				}
+			}
+			if !strings.Contains(gotErr, tc.wantErr) || (gotErr == "") != (tc.wantErr == "") {
+				t.Errorf("Run() error = %q, want %q", gotErr, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.wantResponses, gotResponses); diff != "" {
+				t.Errorf("function responses mismatch (-want +got):\n%s", diff)
+			}
+			if err := llm.Done(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestToolConfirmation(t *testing.T) {
+	type Args struct {
+		Amount int `json:"amount"`
+	}
+	for _, tc := range []struct {
+		name         string
+		toolConfig   functiontool.Config
+		policy       tool.ConfirmationPolicy
+		amount       int
+		confirmation *toolconfirmation.Confirmation
+		wantResponse map[string]any
+	}{
+		{
+			name:         "Approved",
+			toolConfig:   functiontool.Config{Name: "transfer", RequireConfirmation: true},
+			amount:       200,
+			confirmation: &toolconfirmation.Confirmation{Confirmed: true},
+			wantResponse: map[string]any{"transferred": 200.0},
+		},
+		{
+			name:         "Rejected",
+			toolConfig:   functiontool.Config{Name: "transfer", RequireConfirmation: true},
+			amount:       200,
+			confirmation: &toolconfirmation.Confirmation{Confirmed: false},
+			wantResponse: map[string]any{"error": "This tool call is rejected."},
+		},
+		{
+			name:         "EditedArgs",
+			toolConfig:   functiontool.Config{Name: "transfer", RequireConfirmation: true},
+			amount:       200,
+			confirmation: &toolconfirmation.Confirmation{Confirmed: true, Args: map[string]any{"amount": 150}},
+			wantResponse: map[string]any{"transferred": 150.0},
+		},
+		{
+			name:         "InvalidEditedArgs",
+			toolConfig:   functiontool.Config{Name: "transfer", RequireConfirmation: true},
+			amount:       200,
+			confirmation: &toolconfirmation.Confirmation{Confirmed: true, Args: map[string]any{"amount": "all"}},
+			wantResponse: map[string]any{"error": `invalid arguments for tool "transfer": validating root: validating /properties/amount: type: all has type "string", want "integer"`},
+		},
+		{
+			name:       "PolicyNotRequired",
+			toolConfig: functiontool.Config{Name: "transfer"},
+			policy: func(ctx agent.ReadonlyContext, t tool.Tool, args map[string]any) bool {
+				return t.Name() == "transfer" && args["amount"].(float64) > 100
+			},
+			amount:       50,
+			wantResponse: map[string]any{"transferred": 50.0},
+		},
+		{
+			name:       "PolicyApproved",
+			toolConfig: functiontool.Config{Name: "transfer"},
+			policy: func(ctx agent.ReadonlyContext, t tool.Tool, args map[string]any) bool {
+				return t.Name() == "transfer" && args["amount"].(float64) > 100
+			},
+			amount:       200,
+			confirmation: &toolconfirmation.Confirmation{Confirmed: true},
+			wantResponse: map[string]any{"transferred": 200.0},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			transferTool, err := functiontool.New(tc.toolConfig, func(ctx tool.Context, args Args) (map[string]any, error) {
+				return map[string]any{"transferred": args.Amount}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			llm := modeltest.New("test-model",
+				modeltest.FunctionCall("transfer", map[string]any{"amount": float64(tc.amount)}),
+				modeltest.Text("Done."),
+			)
+			a, err := llmagent.New(llmagent.Config{
+				Name:                   "agent",
+				Model:                  llm,
+				Tools:                  []tool.Tool{transferTool},
+				ToolConfirmationPolicy: tc.policy,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner := testutil.NewTestAgentRunner(t, a)
+
+			events, err := testutil.CollectEvents(runner.Run(t, "session", "Transfer the money."))
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if tc.confirmation != nil {
+				// The call waits for the confirmation of the user.
+				if len(events) != 3 {
+					t.Fatalf("Run() yielded %d events, want 3", len(events))
+				}
+				callID := events[0].Content.Parts[0].FunctionCall.ID
+				requestEvent := events[2]
+				call := requestEvent.Content.Parts[0].FunctionCall
+				if call.Name != toolconfirmation.FunctionCallName || !slices.Equal(requestEvent.LongRunningToolIDs, []string{call.ID}) {
+					t.Fatalf("confirmation request = %+v, want a long running %s call", call, toolconfirmation.FunctionCallName)
+				}
+				var req toolconfirmation.Request
+				data, err := json.Marshal(call.Args)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := json.Unmarshal(data, &req); err != nil {
+					t.Fatal(err)
+				}
+				wantCall := &genai.FunctionCall{ID: callID, Name: "transfer", Args: map[string]any{"amount": float64(tc.amount)}}
+				if diff := cmp.Diff(wantCall, req.OriginalFunctionCall); diff != "" {
+					t.Errorf("original function call mismatch (-want +got):\n%s", diff)
+				}
+
+				data, err = json.Marshal(tc.confirmation)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var response map[string]any
+				if err := json.Unmarshal(data, &response); err != nil {
+					t.Fatal(err)
+				}
+				answer := &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{
+					ID:       call.ID,
+					Name:     toolconfirmation.FunctionCallName,
+					Response: response,
+				}}}}
+				events, err = testutil.CollectEvents(runner.RunContent(t, "session", answer))
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+			}
+
+			var fnResponse *genai.FunctionResponse
+			for _, ev := range events {
+				for _, p := range ev.Content.Parts {
+					if p.FunctionResponse != nil {
+						fnResponse = p.FunctionResponse
+					}
+				}
+			}
+			if fnResponse == nil {
+				t.Fatal("no function response")
+			}
+			if diff := cmp.Diff(tc.wantResponse, fnResponse.Response); diff != "" {
+				t.Errorf("function response mismatch (-want +got):\n%s", diff)
+			}
+			if err := llm.Done(); err != nil {
+				t.Error(err)
+			}
+			wantContents := []string{"user: Transfer the money.", "model: call transfer", "user: response transfer"}
+			if diff := cmp.Diff(wantContents, modeltest.Contents(llm.LastRequest())); diff != "" {
+				t.Errorf("model request contents mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
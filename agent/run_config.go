@@ -14,6 +14,13 @@
 
 package agent
 
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/genai"
+)
+
 // StreamingMode defines the streaming mode for agent execution.
 type StreamingMode string
 
@@ -23,6 +30,10 @@ const (
 	// StreamingModeSSE enables server-sent events streaming, one-way, where
 	// LLM response parts are streamed immediately as they are generated.
 	StreamingModeSSE StreamingMode = "sse"
+	// StreamingModeBidi enables bidirectional streaming, where the user input
+	// (text, audio, video) is sent through a [LiveRequestQueue] while the
+	// model is generating. It requires a model implementing [model.LiveLLM].
+	StreamingModeBidi StreamingMode = "bidi"
 )
 
 // RunConfig controls runtime behavior of an agent.
@@ -32,4 +43,70 @@ type RunConfig struct {
 	// If true, ADK runner will save each part of the user input that is a blob
 	// (e.g., images, files) as an artifact.
 	SaveInputBlobsAsArtifacts bool
+
+	// The following fields limit the invocation, including the agents it
+	// transfers to and the agents called as tools. An invocation exceeding a
+	// limit ends with an event whose ErrorCode is the [Limit]. Zero means no
+	// limit.
+
+	// MaxLLMCalls is the maximum number of calls to the models.
+	MaxLLMCalls int
+	// MaxToolCalls is the maximum number of function calls.
+	MaxToolCalls int
+	// MaxAgentTransfers is the maximum number of transfers to other agents.
+	MaxAgentTransfers int
+	// Timeout is the maximum duration of the invocation.
+	Timeout time.Duration
+
+	// The following fields are only used in the bidi streaming mode.
+
+	// ResponseModalities are the output modalities of the model,
+	// e.g. [genai.ModalityAudio].
+	ResponseModalities []genai.Modality
+	// SpeechConfig configures the speech generation of the model.
+	SpeechConfig *genai.SpeechConfig
+	// InputAudioTranscription enables the transcription of the user audio.
+	InputAudioTranscription *genai.AudioTranscriptionConfig
+	// OutputAudioTranscription enables the transcription of the model audio.
+	OutputAudioTranscription *genai.AudioTranscriptionConfig
+	// RealtimeInputConfig configures the handling of the realtime input,
+	// e.g. the automatic activity detection.
+	RealtimeInputConfig *genai.RealtimeInputConfig
+}
+
+// Limit is a limit of the invocations set in the [RunConfig]. Its value is
+// the error code of the event ending an invocation which exceeded it.
+type Limit string
+
+const (
+	LimitLLMCalls       Limit = "MAX_LLM_CALLS_EXCEEDED"
+	LimitToolCalls      Limit = "MAX_TOOL_CALLS_EXCEEDED"
+	LimitAgentTransfers Limit = "MAX_AGENT_TRANSFERS_EXCEEDED"
+	LimitTimeout        Limit = "TIMEOUT_EXCEEDED"
+)
+
+// LimitExceededError is the error of an invocation which exceeded a limit of
+// its [RunConfig].
+type LimitExceededError struct {
+	Limit Limit
+	// Max is the maximum number of calls or transfers, for the limits other
+	// than LimitTimeout.
+	Max int
+	// Timeout is the timeout of the invocation, for LimitTimeout.
+	Timeout time.Duration
+}
+
+func (e *LimitExceededError) Error() string {
+	switch e.Limit {
+	case LimitLLMCalls:
+		return fmt.Sprintf("invocation exceeded the maximum of %d LLM calls", e.Max)
+	case LimitToolCalls:
+		return fmt.Sprintf("invocation exceeded the maximum of %d tool calls", e.Max)
+	case LimitAgentTransfers:
+		return fmt.Sprintf("invocation exceeded the maximum of %d agent transfers", e.Max)
+	case LimitTimeout:
+		return fmt.Sprintf("invocation exceeded the timeout of %v", e.Timeout)
+	default:
+		return fmt.Sprintf("invocation exceeded the limit %s", e.Limit)
+	}
 }
@@ -77,11 +77,17 @@ func (a *loopAgent) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, e
 
 	return func(yield func(*session.Event, error) bool) {
 		for {
+			// The loop ends when the invocation is canceled or timed out,
+			// even if the sub-agents don't check the context.
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
 			shouldExit := false
 			for _, subAgent := range ctx.Agent().SubAgents() {
 				for event, err := range subAgent.Run(ctx) {
 					// TODO: ensure consistency -- if there's an error, return and close iterator, verify everywhere in ADK.
-					if !yield(event, err) {
+					if !yield(event, err) || err != nil {
 						return
 					}
 
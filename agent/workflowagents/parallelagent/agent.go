@@ -80,14 +80,16 @@ func run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 		}
 		subAgent := sa
 		errGroup.Go(func() error {
+			// The sub-agents run in their own branch of the same invocation.
 			subCtx := icontext.NewInvocationContext(errGroupCtx, icontext.InvocationContextParams{
-				Artifacts:   ctx.Artifacts(),
-				Memory:      ctx.Memory(),
-				Session:     ctx.Session(),
-				Branch:      branch,
-				Agent:       subAgent,
-				UserContent: ctx.UserContent(),
-				RunConfig:   ctx.RunConfig(),
+				Artifacts:    ctx.Artifacts(),
+				Memory:       ctx.Memory(),
+				Session:      ctx.Session(),
+				Branch:       branch,
+				Agent:        subAgent,
+				InvocationID: ctx.InvocationID(),
+				UserContent:  ctx.UserContent(),
+				RunConfig:    ctx.RunConfig(),
 			})
 
 			if err := runSubAgent(subCtx, subAgent, resultsChan, doneChan); err != nil {
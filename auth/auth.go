@@ -0,0 +1,193 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package auth lets tools call APIs protected by authentication on behalf of
+// the users.
+//
+// A tool declares how an API is protected with a [Config]: the [Scheme] of
+// the API and the raw [Credential] of the application, e.g. an API key or the
+// OAuth2 client of the application. With tool.Credential, the tool gets the
+// credential to call the API. When the user must authorize the access, e.g.
+// with OAuth2, the tool calls tool.RequestCredential instead:
+// the agent run pauses with a [FunctionCallName] function call for the
+// client, which answers with the function response carrying the
+// authorization of the user. The tool is then called again, and the
+// credential is stored in the [CredentialService] for the next calls.
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// FunctionCallName is the name of the function calls requesting credentials
+// from the client.
+const FunctionCallName = "adk_request_credential"
+
+// SchemeType is the type of a [Scheme], as defined by OpenAPI.
+type SchemeType string
+
+const (
+	SchemeTypeAPIKey SchemeType = "apiKey"
+	SchemeTypeHTTP   SchemeType = "http"
+	SchemeTypeOAuth2 SchemeType = "oauth2"
+)
+
+// Scheme describes how an API is protected. It follows the security scheme
+// object of OpenAPI.
+type Scheme struct {
+	Type SchemeType `json:"type"`
+	// In is where the API key is sent: "header", "query" or "cookie".
+	In string `json:"in,omitempty"`
+	// Name of the header, query parameter or cookie of the API key.
+	Name string `json:"name,omitempty"`
+	// HTTPScheme is the HTTP authentication scheme, "bearer" or "basic".
+	HTTPScheme string `json:"scheme,omitempty"`
+	// Flows are the OAuth2 flows supported by the API.
+	Flows *OAuth2Flows `json:"flows,omitempty"`
+}
+
+// OAuth2Flows are the OAuth2 flows supported by an API.
+type OAuth2Flows struct {
+	// AuthorizationCode is the flow where the user authorizes the access.
+	AuthorizationCode *OAuth2Flow `json:"authorizationCode,omitempty"`
+	// ClientCredentials is the flow where the application accesses the API
+	// on its own behalf.
+	ClientCredentials *OAuth2Flow `json:"clientCredentials,omitempty"`
+}
+
+// OAuth2Flow is the configuration of an OAuth2 flow.
+type OAuth2Flow struct {
+	AuthorizationURL string `json:"authorizationUrl,omitempty"`
+	TokenURL         string `json:"tokenUrl,omitempty"`
+	RefreshURL       string `json:"refreshUrl,omitempty"`
+	// Scopes maps the scopes to request to their description.
+	Scopes map[string]string `json:"scopes,omitempty"`
+}
+
+// CredentialType is the type of a [Credential].
+type CredentialType string
+
+const (
+	CredentialTypeAPIKey         CredentialType = "apiKey"
+	CredentialTypeHTTP           CredentialType = "http"
+	CredentialTypeOAuth2         CredentialType = "oauth2"
+	CredentialTypeServiceAccount CredentialType = "serviceAccount"
+)
+
+// Credential is a credential to call an API. Only the field of its type is
+// set.
+type Credential struct {
+	Type           CredentialType            `json:"authType"`
+	APIKey         string                    `json:"apiKey,omitempty"`
+	HTTP           *HTTPCredential           `json:"http,omitempty"`
+	OAuth2         *OAuth2Credential         `json:"oauth2,omitempty"`
+	ServiceAccount *ServiceAccountCredential `json:"serviceAccount,omitempty"`
+}
+
+// HTTPCredential is a credential of an HTTP authentication scheme.
+type HTTPCredential struct {
+	// Scheme is "bearer" or "basic".
+	Scheme   string `json:"scheme"`
+	Token    string `json:"token,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// OAuth2Credential is an OAuth2 client, with the state of the authorization
+// of the user and the obtained tokens.
+type OAuth2Credential struct {
+	ClientID     string `json:"clientId,omitempty"`
+	ClientSecret string `json:"clientSecret,omitempty"`
+	// RedirectURI is where the user is redirected after the authorization.
+	RedirectURI string `json:"redirectUri,omitempty"`
+
+	// AuthURI is the URL where the user authorizes the access, set in the
+	// credential requests.
+	AuthURI string `json:"authUri,omitempty"`
+	// State is the state of the authorization request.
+	State string `json:"state,omitempty"`
+	// AuthResponseURI is the URL the user was redirected to after the
+	// authorization, set by the client. Alternatively, the client can set
+	// AuthCode.
+	AuthResponseURI string `json:"authResponseUri,omitempty"`
+	// AuthCode is the authorization code.
+	AuthCode string `json:"authCode,omitempty"`
+
+	AccessToken  string `json:"accessToken,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	// ExpiresAt is the expiry time of the access token, in seconds since the
+	// Unix epoch, or 0 if unknown.
+	ExpiresAt int64 `json:"expiresAt,omitempty"`
+}
+
+// ServiceAccountCredential is a Google Cloud service account.
+type ServiceAccountCredential struct {
+	// JSONKey is the content of the JSON key file of the service account.
+	JSONKey string `json:"jsonKey,omitempty"`
+	// UseDefaultCredential uses the Application Default Credentials instead
+	// of a key.
+	UseDefaultCredential bool `json:"useDefaultCredential,omitempty"`
+	// Scopes of the access tokens.
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// expired reports whether the credential has an access token which expired,
+// or is about to.
+func (c *Credential) expired() bool {
+	if c == nil || c.OAuth2 == nil || c.OAuth2.ExpiresAt == 0 {
+		return false
+	}
+	return time.Now().Add(time.Minute).Unix() >= c.OAuth2.ExpiresAt
+}
+
+// Config is the authentication of an API for a tool.
+type Config struct {
+	// Scheme of the API.
+	Scheme *Scheme `json:"authScheme"`
+	// RawCredential is the credential of the application, e.g. an API key or
+	// the OAuth2 client.
+	RawCredential *Credential `json:"rawAuthCredential,omitempty"`
+	// ExchangedCredential is the credential used to call the API, e.g. an
+	// access token. In the credential requests, it carries the
+	// authorization request, and the client sets the authorization of the
+	// user in it.
+	ExchangedCredential *Credential `json:"exchangedAuthCredential,omitempty"`
+	// CredentialKey identifies the credential in the [CredentialService].
+	// If empty, it's derived from the scheme and the raw credential.
+	CredentialKey string `json:"credentialKey,omitempty"`
+}
+
+// Key returns the key of the credential in the [CredentialService].
+func (c *Config) Key() string {
+	if c.CredentialKey != "" {
+		return c.CredentialKey
+	}
+	data, _ := json.Marshal(struct {
+		Scheme        *Scheme
+		RawCredential *Credential
+	}{c.Scheme, c.RawCredential})
+	sum := sha256.Sum256(data)
+	return "adk_" + hex.EncodeToString(sum[:16])
+}
+
+// clone returns a deep copy of the config.
+func (c *Config) clone() *Config {
+	var clone Config
+	data, _ := json.Marshal(c)
+	_ = json.Unmarshal(data, &clone)
+	return &clone
+}
@@ -0,0 +1,364 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/adk/auth"
+)
+
+// newTokenServer returns an OAuth2 token endpoint issuing tokens named after
+// the grant, e.g. "authorization_code:code" or "refresh_token:refresh".
+func newTokenServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if id, secret, _ := r.BasicAuth(); id != "client" || secret != "secret" {
+			http.Error(w, "invalid client", http.StatusUnauthorized)
+			return
+		}
+		grant := r.Form.Get("grant_type")
+		var token string
+		switch grant {
+		case "authorization_code":
+			token = grant + ":" + r.Form.Get("code")
+		case "refresh_token":
+			token = grant + ":" + r.Form.Get("refresh_token")
+		default:
+			token = grant
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": %q, "token_type": "Bearer", "refresh_token": "refresh", "expires_in": 3600}`, token)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func oauth2Config(tokenURL string) *auth.Config {
+	return &auth.Config{
+		Scheme: &auth.Scheme{
+			Type: auth.SchemeTypeOAuth2,
+			Flows: &auth.OAuth2Flows{
+				AuthorizationCode: &auth.OAuth2Flow{
+					AuthorizationURL: "https://example.com/authorize",
+					TokenURL:         tokenURL,
+					Scopes:           map[string]string{"read": "Read access"},
+				},
+			},
+		},
+		RawCredential: &auth.Credential{
+			Type: auth.CredentialTypeOAuth2,
+			OAuth2: &auth.OAuth2Credential{
+				ClientID:     "client",
+				ClientSecret: "secret",
+				RedirectURI:  "https://example.com/callback",
+			},
+		},
+	}
+}
+
+func TestGenerateAuthRequest(t *testing.T) {
+	cfg := oauth2Config("https://example.com/token")
+	req, err := auth.GenerateAuthRequest(cfg)
+	if err != nil {
+		t.Fatalf("GenerateAuthRequest() error = %v", err)
+	}
+
+	if req.CredentialKey != cfg.Key() {
+		t.Errorf("CredentialKey = %q, want %q", req.CredentialKey, cfg.Key())
+	}
+	if req.RawCredential.OAuth2.ClientSecret != "" {
+		t.Errorf("the client secret is sent to the client")
+	}
+	if cfg.RawCredential.OAuth2.ClientSecret != "secret" {
+		t.Errorf("GenerateAuthRequest() modified the config")
+	}
+	exchanged := req.ExchangedCredential.OAuth2
+	authURI, err := url.Parse(exchanged.AuthURI)
+	if err != nil {
+		t.Fatalf("invalid AuthURI: %v", err)
+	}
+	q := authURI.Query()
+	want := url.Values{
+		"access_type":   {"offline"},
+		"client_id":     {"client"},
+		"prompt":        {"consent"},
+		"redirect_uri":  {"https://example.com/callback"},
+		"response_type": {"code"},
+		"scope":         {"read"},
+		"state":         {exchanged.State},
+	}
+	if diff := cmp.Diff(want, q); diff != "" {
+		t.Errorf("AuthURI query mismatch (-want +got):\n%s", diff)
+	}
+	if exchanged.State == "" {
+		t.Errorf("State is empty")
+	}
+}
+
+func TestConfig_Key(t *testing.T) {
+	a, b := oauth2Config("https://example.com/token"), oauth2Config("https://example.com/token")
+	if a.Key() != b.Key() {
+		t.Errorf("Key() = %q and %q for the same configs", a.Key(), b.Key())
+	}
+	b.Scheme.Flows.AuthorizationCode.Scopes["write"] = "Write access"
+	if a.Key() == b.Key() {
+		t.Errorf("Key() = %q for different configs", a.Key())
+	}
+	b.CredentialKey = "custom"
+	if got := b.Key(); got != "custom" {
+		t.Errorf("Key() = %q, want %q", got, "custom")
+	}
+}
+
+func TestGetCredential(t *testing.T) {
+	ctx := t.Context()
+	tokenServer := newTokenServer(t)
+	apiKey := &auth.Credential{Type: auth.CredentialTypeAPIKey, APIKey: "key"}
+	token := func(accessToken string) *auth.Credential {
+		return &auth.Credential{Type: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2Credential{AccessToken: accessToken, RefreshToken: "refresh"}}
+	}
+
+	tests := []struct {
+		name   string
+		cfg    *auth.Config
+		stored *auth.Credential
+		want   *auth.Credential
+	}{
+		{
+			name: "raw API key",
+			cfg: &auth.Config{
+				Scheme:        &auth.Scheme{Type: auth.SchemeTypeAPIKey, In: "header", Name: "X-Key"},
+				RawCredential: apiKey,
+			},
+			want: apiKey,
+		},
+		{
+			name:   "API key provided by the user",
+			cfg:    &auth.Config{Scheme: &auth.Scheme{Type: auth.SchemeTypeAPIKey, In: "header", Name: "X-Key"}},
+			stored: apiKey,
+			want:   apiKey,
+		},
+		{
+			name: "authorization required",
+			cfg:  oauth2Config(tokenServer.URL),
+			want: nil,
+		},
+		{
+			name: "authorization code",
+			cfg:  oauth2Config(tokenServer.URL),
+			stored: &auth.Credential{Type: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2Credential{
+				State:           "state",
+				AuthResponseURI: "https://example.com/callback?code=code&state=state",
+			}},
+			want: token("authorization_code:code"),
+		},
+		{
+			name:   "valid token",
+			cfg:    oauth2Config(tokenServer.URL),
+			stored: token("stored"),
+			want:   token("stored"),
+		},
+		{
+			name: "expired token",
+			cfg:  oauth2Config(tokenServer.URL),
+			stored: &auth.Credential{Type: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2Credential{
+				AccessToken:  "expired",
+				RefreshToken: "refresh",
+				ExpiresAt:    time.Now().Add(-time.Hour).Unix(),
+			}},
+			want: token("refresh_token:refresh"),
+		},
+		{
+			name: "client credentials",
+			cfg: &auth.Config{
+				Scheme: &auth.Scheme{
+					Type:  auth.SchemeTypeOAuth2,
+					Flows: &auth.OAuth2Flows{ClientCredentials: &auth.OAuth2Flow{TokenURL: tokenServer.URL}},
+				},
+				RawCredential: &auth.Credential{
+					Type:   auth.CredentialTypeOAuth2,
+					OAuth2: &auth.OAuth2Credential{ClientID: "client", ClientSecret: "secret"},
+				},
+			},
+			want: token("client_credentials"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := auth.InMemoryCredentialService()
+			if tt.stored != nil {
+				err := service.Save(ctx, &auth.SaveRequest{AppName: "app", UserID: "user", Key: tt.cfg.Key(), Credential: tt.stored})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := auth.GetCredential(ctx, service, "app", "user", tt.cfg)
+			if err != nil {
+				t.Fatalf("GetCredential() error = %v", err)
+			}
+			ignoreExpiry := cmp.FilterPath(func(p cmp.Path) bool { return p.Last().String() == ".ExpiresAt" }, cmp.Ignore())
+			if diff := cmp.Diff(tt.want, got, ignoreExpiry); diff != "" {
+				t.Errorf("GetCredential() mismatch (-want +got):\n%s", diff)
+			}
+			if got == nil {
+				return
+			}
+			// The credential is stored for the next calls.
+			resp, err := service.Load(ctx, &auth.LoadRequest{AppName: "app", UserID: "user", Key: tt.cfg.Key()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.cfg.RawCredential != got && resp.Credential != got {
+				t.Errorf("stored credential = %+v, want %+v", resp.Credential, got)
+			}
+		})
+	}
+}
+
+func TestGetCredential_StateMismatch(t *testing.T) {
+	ctx := t.Context()
+	cfg := oauth2Config(newTokenServer(t).URL)
+	service := auth.InMemoryCredentialService()
+	err := auth.StoreAuthResponse(ctx, service, "app", "user", &auth.Config{
+		CredentialKey: cfg.Key(),
+		ExchangedCredential: &auth.Credential{Type: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2Credential{
+			State:           "state",
+			AuthResponseURI: "https://example.com/callback?code=code&state=other",
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.GetCredential(ctx, service, "app", "user", cfg); err == nil || !strings.Contains(err.Error(), "state") {
+		t.Errorf("GetCredential() error = %v, want a state mismatch", err)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		scheme     *auth.Scheme
+		cred       *auth.Credential
+		wantHeader http.Header
+		wantQuery  string
+	}{
+		{
+			name:       "API key in header",
+			scheme:     &auth.Scheme{Type: auth.SchemeTypeAPIKey, In: "header", Name: "X-Key"},
+			cred:       &auth.Credential{Type: auth.CredentialTypeAPIKey, APIKey: "key"},
+			wantHeader: http.Header{"X-Key": {"key"}},
+		},
+		{
+			name:      "API key in query",
+			scheme:    &auth.Scheme{Type: auth.SchemeTypeAPIKey, In: "query", Name: "key"},
+			cred:      &auth.Credential{Type: auth.CredentialTypeAPIKey, APIKey: "secret"},
+			wantQuery: "a=b&key=secret",
+		},
+		{
+			name:       "API key in cookie",
+			scheme:     &auth.Scheme{Type: auth.SchemeTypeAPIKey, In: "cookie", Name: "key"},
+			cred:       &auth.Credential{Type: auth.CredentialTypeAPIKey, APIKey: "secret"},
+			wantHeader: http.Header{"Cookie": {"key=secret"}},
+		},
+		{
+			name:       "bearer",
+			scheme:     &auth.Scheme{Type: auth.SchemeTypeHTTP, HTTPScheme: "bearer"},
+			cred:       &auth.Credential{Type: auth.CredentialTypeHTTP, HTTP: &auth.HTTPCredential{Scheme: "bearer", Token: "token"}},
+			wantHeader: http.Header{"Authorization": {"Bearer token"}},
+		},
+		{
+			name:       "basic",
+			scheme:     &auth.Scheme{Type: auth.SchemeTypeHTTP, HTTPScheme: "basic"},
+			cred:       &auth.Credential{Type: auth.CredentialTypeHTTP, HTTP: &auth.HTTPCredential{Scheme: "basic", Username: "user", Password: "pass"}},
+			wantHeader: http.Header{"Authorization": {"Basic dXNlcjpwYXNz"}},
+		},
+		{
+			name:       "OAuth2",
+			scheme:     &auth.Scheme{Type: auth.SchemeTypeOAuth2},
+			cred:       &auth.Credential{Type: auth.CredentialTypeOAuth2, OAuth2: &auth.OAuth2Credential{AccessToken: "token"}},
+			wantHeader: http.Header{"Authorization": {"Bearer token"}},
+		},
+		{
+			name:      "no credential",
+			wantQuery: "a=b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotHeader http.Header
+			var gotQuery string
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotHeader, gotQuery = r.Header, r.URL.RawQuery
+			}))
+			defer s.Close()
+
+			ctx := t.Context()
+			if tt.cred != nil {
+				ctx = auth.NewContext(ctx, tt.scheme, tt.cred)
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL+"?a=b", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &http.Client{Transport: &auth.Transport{}}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			for k := range tt.wantHeader {
+				if diff := cmp.Diff(tt.wantHeader[k], gotHeader[k]); diff != "" {
+					t.Errorf("header %s mismatch (-want +got):\n%s", k, diff)
+				}
+			}
+			if tt.wantQuery != "" && gotQuery != tt.wantQuery {
+				t.Errorf("query = %q, want %q", gotQuery, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestConfig_JSON(t *testing.T) {
+	// The configs are sent to the clients in the format of the other ADKs.
+	cfg := &auth.Config{
+		Scheme:        &auth.Scheme{Type: auth.SchemeTypeAPIKey, In: "header", Name: "X-Key"},
+		RawCredential: &auth.Credential{Type: auth.CredentialTypeAPIKey, APIKey: "key"},
+		CredentialKey: "key",
+	}
+	got, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"authScheme":{"type":"apiKey","in":"header","name":"X-Key"},"rawAuthCredential":{"authType":"apiKey","apiKey":"key"},"credentialKey":"key"}`
+	if string(got) != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+}
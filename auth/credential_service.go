@@ -0,0 +1,104 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// CredentialService stores the credentials of the users.
+type CredentialService interface {
+	// Load returns the credential, or a nil Credential if there's none.
+	Load(ctx context.Context, req *LoadRequest) (*LoadResponse, error)
+	Save(ctx context.Context, req *SaveRequest) error
+	Delete(ctx context.Context, req *DeleteRequest) error
+}
+
+// LoadRequest represents a request to load a credential.
+type LoadRequest struct {
+	AppName, UserID, Key string
+}
+
+// LoadResponse represents the response of loading a credential.
+type LoadResponse struct {
+	Credential *Credential
+}
+
+// SaveRequest represents a request to save a credential.
+type SaveRequest struct {
+	AppName, UserID, Key string
+	Credential           *Credential
+}
+
+// DeleteRequest represents a request to delete a credential.
+type DeleteRequest struct {
+	AppName, UserID, Key string
+}
+
+// InMemoryCredentialService returns a [CredentialService] keeping the
+// credentials in memory, e.g. for tests or local development.
+func InMemoryCredentialService() CredentialService {
+	return &inMemoryCredentialService{credentials: make(map[credentialKey]*Credential)}
+}
+
+type credentialKey struct {
+	appName, userID, key string
+}
+
+type inMemoryCredentialService struct {
+	mu          sync.RWMutex
+	credentials map[credentialKey]*Credential
+}
+
+func (s *inMemoryCredentialService) Load(ctx context.Context, req *LoadRequest) (*LoadResponse, error) {
+	if err := validate(req.AppName, req.UserID, req.Key); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &LoadResponse{Credential: s.credentials[credentialKey{req.AppName, req.UserID, req.Key}]}, nil
+}
+
+func (s *inMemoryCredentialService) Save(ctx context.Context, req *SaveRequest) error {
+	if err := validate(req.AppName, req.UserID, req.Key); err != nil {
+		return err
+	}
+	if req.Credential == nil {
+		return errors.New("credential is nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.credentials[credentialKey{req.AppName, req.UserID, req.Key}] = req.Credential
+	return nil
+}
+
+func (s *inMemoryCredentialService) Delete(ctx context.Context, req *DeleteRequest) error {
+	if err := validate(req.AppName, req.UserID, req.Key); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.credentials, credentialKey{req.AppName, req.UserID, req.Key})
+	return nil
+}
+
+func validate(appName, userID, key string) error {
+	if appName == "" || userID == "" || key == "" {
+		return errors.New("app name, user ID and key are required")
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/oauth2/google"
+)
+
+// GenerateAuthRequest returns the config sent to the client to request the
+// credential. For the OAuth2 authorization code flow, its exchanged
+// credential holds the URL where the user authorizes the access. The secrets
+// of the raw credential aren't sent to the client.
+func GenerateAuthRequest(cfg *Config) (*Config, error) {
+	req := cfg.clone()
+	req.CredentialKey = cfg.Key()
+	if raw := req.RawCredential; raw != nil {
+		raw.APIKey = ""
+		raw.HTTP = nil
+		raw.ServiceAccount = nil
+		if raw.OAuth2 != nil {
+			raw.OAuth2.ClientSecret = ""
+		}
+	}
+	if req.ExchangedCredential != nil {
+		return req, nil
+	}
+
+	flow := authorizationCodeFlow(cfg.Scheme)
+	if flow == nil || cfg.RawCredential == nil || cfg.RawCredential.OAuth2 == nil {
+		return req, nil
+	}
+	client := cfg.RawCredential.OAuth2
+	if client.ClientID == "" {
+		return nil, errors.New("OAuth2 client ID is required")
+	}
+	state := rand.Text()
+	authURI := oauth2Config(client, flow).AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.SetAuthURLParam("prompt", "consent"))
+	req.ExchangedCredential = &Credential{
+		Type: CredentialTypeOAuth2,
+		OAuth2: &OAuth2Credential{
+			ClientID:    client.ClientID,
+			RedirectURI: client.RedirectURI,
+			AuthURI:     authURI,
+			State:       state,
+		},
+	}
+	return req, nil
+}
+
+// StoreAuthResponse stores the exchanged credential of the config provided
+// by the client in response to a credential request. The authorization code
+// it may hold is exchanged when the credential is next requested, with the
+// raw credential of the tool.
+func StoreAuthResponse(ctx context.Context, service CredentialService, appName, userID string, cfg *Config) error {
+	if cfg.ExchangedCredential == nil {
+		return errors.New("auth response has no exchanged credential")
+	}
+	return service.Save(ctx, &SaveRequest{AppName: appName, UserID: userID, Key: cfg.Key(), Credential: cfg.ExchangedCredential})
+}
+
+// GetCredential returns the credential of the user for the config, without
+// interaction with the user. It's, in order:
+//   - the exchanged or raw credential of the config, if it can be used as
+//     is, e.g. an API key;
+//   - the credential stored in the service, whose authorization code is
+//     exchanged, or access token refreshed if it expired;
+//   - an access token obtained with a service account or the OAuth2 client
+//     credentials flow.
+//
+// The credentials obtained are stored in the service. It returns nil if the
+// user must authorize the access, see [GenerateAuthRequest].
+func GetCredential(ctx context.Context, service CredentialService, appName, userID string, cfg *Config) (*Credential, error) {
+	if cfg.Scheme == nil {
+		return nil, errors.New("auth scheme is required")
+	}
+	if usable(cfg.ExchangedCredential) {
+		return cfg.ExchangedCredential, nil
+	}
+	if usable(cfg.RawCredential) {
+		return cfg.RawCredential, nil
+	}
+
+	key := cfg.Key()
+	resp, err := service.Load(ctx, &LoadRequest{AppName: appName, UserID: userID, Key: key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credential: %w", err)
+	}
+	cred, err := refresh(ctx, cfg, resp.Credential)
+	if err != nil {
+		return nil, err
+	}
+	if cred == resp.Credential && cred != nil {
+		return cred, nil
+	}
+	if cred == nil {
+		if cred, err = exchange(ctx, cfg); err != nil || cred == nil {
+			return nil, err
+		}
+	}
+	if err := service.Save(ctx, &SaveRequest{AppName: appName, UserID: userID, Key: key, Credential: cred}); err != nil {
+		return nil, fmt.Errorf("failed to save credential: %w", err)
+	}
+	return cred, nil
+}
+
+// usable reports whether the credential can be used without exchange.
+func usable(c *Credential) bool {
+	if c == nil {
+		return false
+	}
+	switch c.Type {
+	case CredentialTypeAPIKey:
+		return c.APIKey != ""
+	case CredentialTypeHTTP:
+		return c.HTTP != nil
+	case CredentialTypeOAuth2:
+		return c.OAuth2 != nil && c.OAuth2.AccessToken != "" && !c.expired()
+	}
+	return false
+}
+
+// refresh returns the stored credential, with its authorization code
+// exchanged or its access token refreshed if needed. It returns nil if the
+// credential can't be used anymore.
+func refresh(ctx context.Context, cfg *Config, stored *Credential) (*Credential, error) {
+	if stored == nil {
+		return nil, nil
+	}
+	if stored.Type != CredentialTypeOAuth2 || stored.OAuth2 == nil {
+		return stored, nil
+	}
+	if stored.OAuth2.AccessToken == "" {
+		return exchangeAuthCode(ctx, cfg, stored.OAuth2)
+	}
+	if !stored.expired() {
+		return stored, nil
+	}
+	if stored.OAuth2.RefreshToken == "" {
+		return nil, nil
+	}
+	conf, err := clientConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if refreshURL := cfg.Scheme.Flows.AuthorizationCode.RefreshURL; refreshURL != "" {
+		conf.Endpoint.TokenURL = refreshURL
+	}
+	tok, err := conf.TokenSource(ctx, &oauth2.Token{
+		RefreshToken: stored.OAuth2.RefreshToken,
+		Expiry:       time.Unix(stored.OAuth2.ExpiresAt, 0),
+	}).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh OAuth2 token: %w", err)
+	}
+	return tokenCredential(tok), nil
+}
+
+// exchangeAuthCode exchanges the authorization code of the user for an
+// access token.
+func exchangeAuthCode(ctx context.Context, cfg *Config, resp *OAuth2Credential) (*Credential, error) {
+	code := resp.AuthCode
+	if code == "" && resp.AuthResponseURI != "" {
+		u, err := url.Parse(resp.AuthResponseURI)
+		if err != nil {
+			return nil, fmt.Errorf("invalid auth response URI: %w", err)
+		}
+		if state := u.Query().Get("state"); resp.State != "" && state != resp.State {
+			return nil, errors.New("auth response state doesn't match the auth request")
+		}
+		code = u.Query().Get("code")
+	}
+	if code == "" {
+		return nil, nil
+	}
+	conf, err := clientConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if resp.RedirectURI != "" {
+		conf.RedirectURL = resp.RedirectURI
+	}
+	tok, err := conf.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange OAuth2 authorization code: %w", err)
+	}
+	return tokenCredential(tok), nil
+}
+
+// exchange obtains a credential without interaction with the user, or
+// returns nil.
+func exchange(ctx context.Context, cfg *Config) (*Credential, error) {
+	raw := cfg.RawCredential
+	if raw == nil {
+		return nil, nil
+	}
+	switch {
+	case raw.Type == CredentialTypeServiceAccount && raw.ServiceAccount != nil:
+		tok, err := serviceAccountToken(ctx, raw.ServiceAccount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service account token: %w", err)
+		}
+		return tokenCredential(tok), nil
+	case raw.Type == CredentialTypeOAuth2 && raw.OAuth2 != nil && cfg.Scheme.Flows != nil &&
+		cfg.Scheme.Flows.ClientCredentials != nil && cfg.Scheme.Flows.AuthorizationCode == nil:
+		flow := cfg.Scheme.Flows.ClientCredentials
+		conf := &clientcredentials.Config{
+			ClientID:     raw.OAuth2.ClientID,
+			ClientSecret: raw.OAuth2.ClientSecret,
+			TokenURL:     flow.TokenURL,
+			Scopes:       scopes(flow),
+		}
+		tok, err := conf.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get OAuth2 client credentials token: %w", err)
+		}
+		return tokenCredential(tok), nil
+	}
+	return nil, nil
+}
+
+func serviceAccountToken(ctx context.Context, sa *ServiceAccountCredential) (*oauth2.Token, error) {
+	if sa.UseDefaultCredential {
+		creds, err := google.FindDefaultCredentials(ctx, sa.Scopes...)
+		if err != nil {
+			return nil, err
+		}
+		return creds.TokenSource.Token()
+	}
+	conf, err := google.JWTConfigFromJSON([]byte(sa.JSONKey), sa.Scopes...)
+	if err != nil {
+		return nil, err
+	}
+	return conf.TokenSource(ctx).Token()
+}
+
+// clientConfig returns the OAuth2 config of the authorization code flow of
+// the config.
+func clientConfig(cfg *Config) (*oauth2.Config, error) {
+	flow := authorizationCodeFlow(cfg.Scheme)
+	if flow == nil {
+		return nil, errors.New("auth scheme has no OAuth2 authorization code flow")
+	}
+	if cfg.RawCredential == nil || cfg.RawCredential.OAuth2 == nil {
+		return nil, errors.New("raw credential has no OAuth2 client")
+	}
+	return oauth2Config(cfg.RawCredential.OAuth2, flow), nil
+}
+
+func authorizationCodeFlow(s *Scheme) *OAuth2Flow {
+	if s == nil || s.Type != SchemeTypeOAuth2 || s.Flows == nil {
+		return nil
+	}
+	return s.Flows.AuthorizationCode
+}
+
+func oauth2Config(client *OAuth2Credential, flow *OAuth2Flow) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     client.ClientID,
+		ClientSecret: client.ClientSecret,
+		RedirectURL:  client.RedirectURI,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  flow.AuthorizationURL,
+			TokenURL: flow.TokenURL,
+		},
+		Scopes: scopes(flow),
+	}
+}
+
+func scopes(flow *OAuth2Flow) []string {
+	var s []string
+	for scope := range flow.Scopes {
+		s = append(s, scope)
+	}
+	slices.Sort(s)
+	return s
+}
+
+func tokenCredential(tok *oauth2.Token) *Credential {
+	c := &OAuth2Credential{AccessToken: tok.AccessToken, RefreshToken: tok.RefreshToken}
+	if !tok.Expiry.IsZero() {
+		c.ExpiresAt = tok.Expiry.Unix()
+	}
+	return &Credential{Type: CredentialTypeOAuth2, OAuth2: c}
+}
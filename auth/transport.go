@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Apply sets the credential on the HTTP request, as defined by the scheme.
+func (c *Credential) Apply(req *http.Request, scheme *Scheme) error {
+	switch c.Type {
+	case CredentialTypeAPIKey:
+		if scheme == nil || scheme.Type != SchemeTypeAPIKey || scheme.Name == "" {
+			return errors.New("API key credential requires an API key scheme with a name")
+		}
+		switch scheme.In {
+		case "header":
+			req.Header.Set(scheme.Name, c.APIKey)
+		case "query":
+			q := req.URL.Query()
+			q.Set(scheme.Name, c.APIKey)
+			req.URL.RawQuery = q.Encode()
+		case "cookie":
+			req.AddCookie(&http.Cookie{Name: scheme.Name, Value: c.APIKey})
+		default:
+			return fmt.Errorf("unsupported API key location %q", scheme.In)
+		}
+	case CredentialTypeHTTP:
+		if c.HTTP == nil {
+			return errors.New("HTTP credential is empty")
+		}
+		switch strings.ToLower(c.HTTP.Scheme) {
+		case "bearer":
+			req.Header.Set("Authorization", "Bearer "+c.HTTP.Token)
+		case "basic":
+			req.SetBasicAuth(c.HTTP.Username, c.HTTP.Password)
+		default:
+			return fmt.Errorf("unsupported HTTP authentication scheme %q", c.HTTP.Scheme)
+		}
+	case CredentialTypeOAuth2:
+		if c.OAuth2 == nil || c.OAuth2.AccessToken == "" {
+			return errors.New("OAuth2 credential has no access token")
+		}
+		req.Header.Set("Authorization", "Bearer "+c.OAuth2.AccessToken)
+	default:
+		return fmt.Errorf("credential of type %q can't be applied to a request", c.Type)
+	}
+	return nil
+}
+
+type contextKey struct{}
+
+type contextCredential struct {
+	scheme *Scheme
+	cred   *Credential
+}
+
+// NewContext returns a context carrying the credential, which a [Transport]
+// sets on the requests sent with this context.
+func NewContext(ctx context.Context, scheme *Scheme, cred *Credential) context.Context {
+	return context.WithValue(ctx, contextKey{}, &contextCredential{scheme: scheme, cred: cred})
+}
+
+// Transport is an [http.RoundTripper] setting the credential of the request
+// context, if any, on the requests. It lets HTTP clients shared by the users,
+// such as the ones of MCP toolsets, call APIs on behalf of each user.
+type Transport struct {
+	// Base sends the requests. Defaults to [http.DefaultTransport].
+	Base http.RoundTripper
+}
+
+// RoundTrip implements [http.RoundTripper].
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c, ok := req.Context().Value(contextKey{}).(*contextCredential)
+	if !ok {
+		return base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	if err := c.cred.Apply(req, c.scheme); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("failed to apply credential: %w", err)
+	}
+	return base.RoundTrip(req)
+}
@@ -18,10 +18,12 @@ package console
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"google.golang.org/genai"
 
@@ -29,8 +31,10 @@ import (
 	"google.golang.org/adk/cmd/launcher"
 	"google.golang.org/adk/cmd/launcher/universal"
 	"google.golang.org/adk/internal/cli/util"
+	"google.golang.org/adk/internal/converters"
 	"google.golang.org/adk/runner"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool/toolconfirmation"
 )
 
 // consoleConfig contains command-line params for console launcher
@@ -79,10 +83,13 @@ func (l *consoleLauncher) Run(ctx context.Context, config *launcher.Config) erro
 	session := resp.Session
 
 	r, err := runner.New(runner.Config{
-		AppName:         appName,
-		Agent:           rootAgent,
-		SessionService:  sessionService,
-		ArtifactService: config.ArtifactService,
+		AppName:           appName,
+		Agent:             rootAgent,
+		SessionService:    sessionService,
+		ArtifactService:   config.ArtifactService,
+		Usage:             config.Usage,
+		CredentialService: config.CredentialService,
+		Plugins:           config.Plugins,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create runner: %v", err)
@@ -90,15 +97,18 @@ func (l *consoleLauncher) Run(ctx context.Context, config *launcher.Config) erro
 
 	reader := bufio.NewReader(os.Stdin)
 
+	var userMsg *genai.Content
 	for {
-		fmt.Print("\nUser -> ")
+		if userMsg == nil {
+			fmt.Print("\nUser -> ")
 
-		userInput, err := reader.ReadString('\n')
-		if err != nil {
-			log.Fatal(err)
-		}
+			userInput, err := reader.ReadString('\n')
+			if err != nil {
+				log.Fatal(err)
+			}
 
-		userMsg := genai.NewContentFromText(userInput, genai.RoleUser)
+			userMsg = genai.NewContentFromText(userInput, genai.RoleUser)
+		}
 
 		streamingMode := l.config.streamingMode
 		if streamingMode == "" {
@@ -106,6 +116,7 @@ func (l *consoleLauncher) Run(ctx context.Context, config *launcher.Config) erro
 		}
 		fmt.Print("\nAgent -> ")
 		prevText := ""
+		var confirmationRequests []*genai.FunctionCall
 		for event, err := range r.Run(ctx, userID, session.ID(), userMsg, agent.RunConfig{
 			StreamingMode: streamingMode,
 		}) {
@@ -116,6 +127,12 @@ func (l *consoleLauncher) Run(ctx context.Context, config *launcher.Config) erro
 					continue
 				}
 
+				for _, p := range event.LLMResponse.Content.Parts {
+					if p.FunctionCall != nil && p.FunctionCall.Name == toolconfirmation.FunctionCallName {
+						confirmationRequests = append(confirmationRequests, p.FunctionCall)
+					}
+				}
+
 				text := ""
 				for _, p := range event.LLMResponse.Content.Parts {
 					text += p.Text
@@ -141,7 +158,55 @@ func (l *consoleLauncher) Run(ctx context.Context, config *launcher.Config) erro
 				prevText = ""
 			}
 		}
+
+		// The agent waits for the confirmation of tool calls, the answers of
+		// the user are sent as the next message.
+		userMsg = nil
+		if len(confirmationRequests) > 0 {
+			userMsg, err = confirmToolCalls(reader, confirmationRequests)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+}
+
+// confirmToolCalls asks the user to approve or reject the tool calls of the
+// confirmation requests, and returns the responses to the requests.
+func confirmToolCalls(reader *bufio.Reader, requests []*genai.FunctionCall) (*genai.Content, error) {
+	var parts []*genai.Part
+	for _, fc := range requests {
+		req, err := converters.FromMapStructure[toolconfirmation.Request](fc.Args)
+		if err != nil {
+			return nil, fmt.Errorf("invalid confirmation request: %w", err)
+		}
+		if req.OriginalFunctionCall == nil {
+			return nil, fmt.Errorf("invalid confirmation request: missing the original function call")
+		}
+		if req.ToolConfirmation != nil && req.ToolConfirmation.Hint != "" {
+			fmt.Printf("\n%s", req.ToolConfirmation.Hint)
+		}
+		args, err := json.Marshal(req.OriginalFunctionCall.Args)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("\nCall %s(%s)? [y/N] ", req.OriginalFunctionCall.Name, args)
+		answer, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		confirmed := strings.EqualFold(strings.TrimSpace(answer), "y")
+		response, err := converters.ToMapStructure(toolconfirmation.Confirmation{Confirmed: confirmed})
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, &genai.Part{FunctionResponse: &genai.FunctionResponse{
+			ID:       fc.ID,
+			Name:     fc.Name,
+			Response: response,
+		}})
 	}
+	return &genai.Content{Role: genai.RoleUser, Parts: parts}, nil
 }
 
 // Parse implements launcher.SubLauncher. After parsing console-specific
@@ -22,8 +22,11 @@ import (
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/memory"
+	"google.golang.org/adk/plugin"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/usage"
 )
 
 // Launcher is the main interface for running an ADK application.
@@ -59,4 +62,12 @@ type Config struct {
 	MemoryService   memory.Service
 	AgentLoader     agent.Loader
 	A2AOptions      []a2asrv.RequestHandlerOption
+	// Usage enables the token usage and cost accounting. Optional.
+	Usage *usage.Config
+	// Plugins hook into the runs of all the agents. Optional.
+	Plugins []plugin.Plugin
+	// CredentialService stores the credentials the tools use on behalf of
+	// the users. It's shared by all the requests. Optional, defaults to an
+	// in-memory service.
+	CredentialService auth.CredentialService
 }
@@ -0,0 +1,130 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package codeexecutor provides code executors that let LLM agents write and
+// run code.
+//
+// A code executor is set on an agent with llmagent.Config.CodeExecutor.
+// [BuiltIn] uses the code execution tool of Gemini models, which run the code
+// themselves. Other executors, such as [Local], run the code blocks found in
+// the model responses, delimited by their [CodeExecutor.CodeBlockDelimiters].
+// The results are returned to the model, which continues its response, and
+// the files created by the code are saved as artifacts.
+package codeexecutor
+
+import (
+	"errors"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+)
+
+// CodeExecutor runs the code written by the model.
+type CodeExecutor interface {
+	// ExecuteCode runs the code. A failure of the code itself is reported
+	// in the Stderr of the result, so that the model can fix it, while an
+	// error fails the agent run.
+	ExecuteCode(ctx agent.InvocationContext, input *Input) (*Result, error)
+	// CodeBlockDelimiters are the delimiters of the code blocks to run in
+	// the model responses. The first ones are used to format the code in the
+	// requests.
+	CodeBlockDelimiters() []Delimiter
+	// ExecutionResultDelimiter is used to format the results of the code in
+	// the requests.
+	ExecutionResultDelimiter() Delimiter
+}
+
+// Delimiter surrounds a block in the text of the contents.
+type Delimiter struct {
+	Start, End string
+}
+
+var (
+	// DefaultCodeBlockDelimiters are the default delimiters of the code
+	// blocks.
+	DefaultCodeBlockDelimiters = []Delimiter{
+		{Start: "```tool_code\n", End: "\n```"},
+		{Start: "```python\n", End: "\n```"},
+	}
+	// DefaultExecutionResultDelimiter is the default delimiter of the
+	// results of the code.
+	DefaultExecutionResultDelimiter = Delimiter{Start: "```tool_output\n", End: "\n```"}
+)
+
+// Input is the code to execute.
+type Input struct {
+	// Code to execute.
+	Code string
+	// ExecutionID identifies the execution. Executions of the same
+	// invocation share the same ID.
+	ExecutionID string
+}
+
+// Result is the result of an execution.
+type Result struct {
+	// Stdout is the standard output of the code.
+	Stdout string
+	// Stderr is the standard error of the code. A non-empty Stderr means the
+	// execution failed.
+	Stderr string
+	// OutputFiles are the files created by the code.
+	OutputFiles []File
+}
+
+// File is a file created by the code.
+type File struct {
+	// Name of the file, relative to the working directory of the code.
+	Name string
+	// MIMEType of the content.
+	MIMEType string
+	// Content of the file.
+	Content []byte
+}
+
+// BuiltIn is a [CodeExecutor] using the code execution tool of Gemini 2 and
+// later models. The code is run by the model, so ExecuteCode isn't called.
+type BuiltIn struct{}
+
+// NewBuiltIn returns a [BuiltIn] code executor.
+func NewBuiltIn() *BuiltIn {
+	return &BuiltIn{}
+}
+
+// ApplyCodeExecutionTool adds the code execution tool to the request.
+func (e *BuiltIn) ApplyCodeExecutionTool(req *model.LLMRequest) {
+	if req.Config == nil {
+		req.Config = &genai.GenerateContentConfig{}
+	}
+	req.Config.Tools = append(req.Config.Tools, &genai.Tool{CodeExecution: &genai.ToolCodeExecution{}})
+}
+
+// ExecuteCode implements [CodeExecutor]. It always fails, since the model
+// runs the code itself.
+func (e *BuiltIn) ExecuteCode(ctx agent.InvocationContext, input *Input) (*Result, error) {
+	return nil, errors.New("code is executed by the model")
+}
+
+// CodeBlockDelimiters implements [CodeExecutor].
+func (e *BuiltIn) CodeBlockDelimiters() []Delimiter {
+	return DefaultCodeBlockDelimiters
+}
+
+// ExecutionResultDelimiter implements [CodeExecutor].
+func (e *BuiltIn) ExecutionResultDelimiter() Delimiter {
+	return DefaultExecutionResultDelimiter
+}
+
+var _ CodeExecutor = (*BuiltIn)(nil)
@@ -0,0 +1,333 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeexecutor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/agent"
+)
+
+// LocalConfig is the configuration of a [Local] code executor.
+type LocalConfig struct {
+	// Command runs the code. The name of the file containing the code is
+	// appended to its arguments. Defaults to {"python3"}.
+	Command []string
+	// FileName is the name of the file the code is written to, in the
+	// working directory. Defaults to "main.py".
+	FileName string
+	// WorkDir is the directory the code runs in. The files it creates or
+	// modifies there are returned as output files. The executions in the
+	// same directory, by any Local executor, run one at a time, so that they
+	// don't overwrite the code of each other or get the output files of each
+	// other. If empty, each execution runs in a new temporary directory,
+	// removed afterwards.
+	WorkDir string
+	// Env is the environment of the code, in the form "key=value". If nil,
+	// only PATH is passed, and HOME is set to the working directory.
+	Env []string
+	// Timeout of an execution. Defaults to 30 seconds.
+	Timeout time.Duration
+	// Limits are the resource limits of the code.
+	Limits ResourceLimits
+	// MaxOutputBytes is the maximum size of the captured stdout and stderr,
+	// each. Defaults to 1 MiB.
+	MaxOutputBytes int
+
+	// CodeBlockDelimiters default to [DefaultCodeBlockDelimiters].
+	CodeBlockDelimiters []Delimiter
+	// ExecutionResultDelimiter defaults to
+	// [DefaultExecutionResultDelimiter].
+	ExecutionResultDelimiter Delimiter
+}
+
+// ResourceLimits are limits on the resources used by the code. Zero values
+// mean no limit. The limits are only supported on Unix systems, where they
+// are enforced with ulimit.
+type ResourceLimits struct {
+	// MaxMemoryBytes is the maximum size of the virtual memory.
+	MaxMemoryBytes int64
+	// MaxCPUTime is the maximum CPU time, rounded up to the second.
+	MaxCPUTime time.Duration
+	// MaxFileSize is the maximum size of the files written, rounded up to
+	// 512 bytes.
+	MaxFileSize int64
+	// MaxOpenFiles is the maximum number of open file descriptors.
+	MaxOpenFiles int
+}
+
+func (l ResourceLimits) isZero() bool {
+	return l == ResourceLimits{}
+}
+
+// Local is a [CodeExecutor] running the code in a subprocess on the local
+// machine.
+//
+// The subprocess is only isolated by the working directory, environment and
+// resource limits, so Local shouldn't run untrusted code outside of a
+// sandboxed environment, such as a container.
+type Local struct {
+	cfg LocalConfig
+	// workDirLock is held by the execution running in the working directory.
+	workDirLock chan struct{}
+}
+
+// workDirLocks are the locks of the working directories, by absolute path.
+var workDirLocks sync.Map
+
+// NewLocal returns a [Local] code executor.
+func NewLocal(cfg LocalConfig) (*Local, error) {
+	if len(cfg.Command) == 0 {
+		cfg.Command = []string{"python3"}
+	}
+	if cfg.Command[0] == "" {
+		return nil, errors.New("command is empty")
+	}
+	if cfg.FileName == "" {
+		cfg.FileName = "main.py"
+	}
+	if cfg.FileName != filepath.Base(cfg.FileName) {
+		return nil, fmt.Errorf("file name %q must not contain a directory", cfg.FileName)
+	}
+	var workDirLock chan struct{}
+	if cfg.WorkDir != "" {
+		fi, err := os.Stat(cfg.WorkDir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid working directory: %w", err)
+		}
+		if !fi.IsDir() {
+			return nil, fmt.Errorf("working directory %q is not a directory", cfg.WorkDir)
+		}
+		abs, err := filepath.Abs(cfg.WorkDir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid working directory: %w", err)
+		}
+		lock, _ := workDirLocks.LoadOrStore(abs, make(chan struct{}, 1))
+		workDirLock = lock.(chan struct{})
+	}
+	if !cfg.Limits.isZero() && !resourceLimitsSupported {
+		return nil, errors.New("resource limits are not supported on this system")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.MaxOutputBytes <= 0 {
+		cfg.MaxOutputBytes = 1 << 20
+	}
+	if len(cfg.CodeBlockDelimiters) == 0 {
+		cfg.CodeBlockDelimiters = DefaultCodeBlockDelimiters
+	}
+	if cfg.ExecutionResultDelimiter == (Delimiter{}) {
+		cfg.ExecutionResultDelimiter = DefaultExecutionResultDelimiter
+	}
+	return &Local{cfg: cfg, workDirLock: workDirLock}, nil
+}
+
+// ExecuteCode implements [CodeExecutor]. The code is written to a file in the
+// working directory and run by the command of the executor. A code which
+// exits with a non-zero status or times out is reported in the Stderr of the
+// result.
+func (e *Local) ExecuteCode(ctx agent.InvocationContext, input *Input) (*Result, error) {
+	dir := e.cfg.WorkDir
+	if e.workDirLock != nil {
+		select {
+		case e.workDirLock <- struct{}{}:
+			defer func() { <-e.workDirLock }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "adk-code-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create working directory: %w", err)
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	}
+
+	before, err := listFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	codePath := filepath.Join(dir, e.cfg.FileName)
+	if err := os.WriteFile(codePath, []byte(input.Code), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write code: %w", err)
+	}
+	defer os.Remove(codePath)
+
+	runCtx, cancel := context.WithTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+	args := append(slices.Clone(e.cfg.Command[1:]), e.cfg.FileName)
+	cmd := command(runCtx, e.cfg.Command[0], args, e.cfg.Limits)
+	cmd.Dir = dir
+	cmd.Env = e.cfg.Env
+	if cmd.Env == nil {
+		cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + dir}
+	}
+	stdout := &limitedBuffer{max: e.cfg.MaxOutputBytes}
+	stderr := &limitedBuffer{max: e.cfg.MaxOutputBytes}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	// Don't wait for the subprocesses keeping the outputs open after a kill.
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	result := &Result{Stdout: stdout.String(), Stderr: stderr.String()}
+	var exitErr *exec.ExitError
+	switch {
+	case runCtx.Err() != nil:
+		result.Stderr = joinLines(result.Stderr, fmt.Sprintf("execution timed out after %v", e.cfg.Timeout))
+	case errors.As(err, &exitErr):
+		if result.Stderr == "" {
+			result.Stderr = exitErr.Error()
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to run code: %w", err)
+	}
+
+	result.OutputFiles, err = outputFiles(dir, before, e.cfg.FileName)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CodeBlockDelimiters implements [CodeExecutor].
+func (e *Local) CodeBlockDelimiters() []Delimiter {
+	return e.cfg.CodeBlockDelimiters
+}
+
+// ExecutionResultDelimiter implements [CodeExecutor].
+func (e *Local) ExecutionResultDelimiter() Delimiter {
+	return e.cfg.ExecutionResultDelimiter
+}
+
+var _ CodeExecutor = (*Local)(nil)
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// listFiles returns the state of the regular files in dir, by their slash
+// separated path relative to dir.
+func listFiles(dir string) (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = fileState{size: fi.Size(), modTime: fi.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of %q: %w", dir, err)
+	}
+	return files, nil
+}
+
+// outputFiles returns the files of dir created or modified since before,
+// except the code file.
+func outputFiles(dir string, before map[string]fileState, codeFile string) ([]File, error) {
+	after, err := listFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []File
+	for name, state := range after {
+		if name == codeFile {
+			continue
+		}
+		if prev, ok := before[name]; ok && prev == state {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read output file: %w", err)
+		}
+		files = append(files, File{Name: name, MIMEType: mimeType(name, content), Content: content})
+	}
+	slices.SortFunc(files, func(a, b File) int { return strings.Compare(a.Name, b.Name) })
+	return files, nil
+}
+
+func mimeType(name string, content []byte) string {
+	t := mime.TypeByExtension(filepath.Ext(name))
+	if t == "" {
+		t = http.DetectContentType(content)
+	}
+	if mediaType, _, err := mime.ParseMediaType(t); err == nil {
+		return mediaType
+	}
+	return t
+}
+
+func joinLines(a, b string) string {
+	if a == "" || strings.HasSuffix(a, "\n") {
+		return a + b
+	}
+	return a + "\n" + b
+}
+
+// limitedBuffer keeps the first max bytes written to it.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if n := b.max - b.buf.Len(); n < len(p) {
+		b.truncated = true
+		b.buf.Write(p[:max(n, 0)])
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return joinLines(b.buf.String(), "... (output truncated)")
+	}
+	return b.buf.String()
+}
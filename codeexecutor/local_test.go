@@ -0,0 +1,255 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeexecutor_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/codeexecutor"
+	icontext "google.golang.org/adk/internal/context"
+)
+
+func newShellExecutor(t *testing.T, cfg codeexecutor.LocalConfig) *codeexecutor.Local {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the tests run shell scripts")
+	}
+	cfg.Command = []string{"sh"}
+	cfg.FileName = "main.sh"
+	e, err := codeexecutor.NewLocal(cfg)
+	if err != nil {
+		t.Fatalf("NewLocal() error = %v", err)
+	}
+	return e
+}
+
+func invocationContext(t *testing.T) agent.InvocationContext {
+	return icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{})
+}
+
+func TestLocal_ExecuteCode(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  codeexecutor.LocalConfig
+		code string
+		want *codeexecutor.Result
+	}{
+		{
+			name: "stdout",
+			code: "echo hello",
+			want: &codeexecutor.Result{Stdout: "hello\n"},
+		},
+		{
+			name: "stderr",
+			code: "echo hello; echo oops >&2",
+			want: &codeexecutor.Result{Stdout: "hello\n", Stderr: "oops\n"},
+		},
+		{
+			name: "exit status",
+			code: "exit 3",
+			want: &codeexecutor.Result{Stderr: "exit status 3"},
+		},
+		{
+			name: "timeout",
+			cfg:  codeexecutor.LocalConfig{Timeout: 100 * time.Millisecond},
+			code: "echo started; sleep 10",
+			want: &codeexecutor.Result{Stdout: "started\n", Stderr: "execution timed out after 100ms"},
+		},
+		{
+			name: "truncated output",
+			cfg:  codeexecutor.LocalConfig{MaxOutputBytes: 4},
+			code: "echo hello",
+			want: &codeexecutor.Result{Stdout: "hell\n... (output truncated)"},
+		},
+		{
+			name: "environment",
+			cfg:  codeexecutor.LocalConfig{Env: []string{"GREETING=hi"}},
+			code: "echo $GREETING",
+			want: &codeexecutor.Result{Stdout: "hi\n"},
+		},
+		{
+			name: "output files",
+			code: "mkdir out; echo a,b > out/data.csv; echo done > result.txt",
+			want: &codeexecutor.Result{OutputFiles: []codeexecutor.File{
+				{Name: "out/data.csv", MIMEType: "text/csv", Content: []byte("a,b\n")},
+				{Name: "result.txt", MIMEType: "text/plain", Content: []byte("done\n")},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newShellExecutor(t, tt.cfg)
+			got, err := e.ExecuteCode(invocationContext(t), &codeexecutor.Input{Code: tt.code})
+			if err != nil {
+				t.Fatalf("ExecuteCode() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ExecuteCode() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLocal_ExecuteCode_WorkDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "input.txt"), []byte("input\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e := newShellExecutor(t, codeexecutor.LocalConfig{WorkDir: dir})
+
+	// Only the files created or modified by an execution are output files.
+	got, err := e.ExecuteCode(invocationContext(t), &codeexecutor.Input{Code: "cat input.txt > copy.txt"})
+	if err != nil {
+		t.Fatalf("ExecuteCode() error = %v", err)
+	}
+	want := &codeexecutor.Result{OutputFiles: []codeexecutor.File{
+		{Name: "copy.txt", MIMEType: "text/plain", Content: []byte("input\n")},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ExecuteCode() mismatch (-want +got):\n%s", diff)
+	}
+
+	// The files are kept after the execution, but not the code file.
+	if _, err := os.Stat(filepath.Join(dir, "copy.txt")); err != nil {
+		t.Errorf("output file: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.sh")); !os.IsNotExist(err) {
+		t.Errorf("code file wasn't removed: %v", err)
+	}
+}
+
+func TestLocal_ExecuteCode_ConcurrentWorkDir(t *testing.T) {
+	dir := t.TempDir()
+	executors := []*codeexecutor.Local{
+		newShellExecutor(t, codeexecutor.LocalConfig{WorkDir: dir}),
+		newShellExecutor(t, codeexecutor.LocalConfig{WorkDir: dir}),
+	}
+
+	// Each execution runs its own code and only gets its own output file.
+	const n = 6
+	results := make([]*codeexecutor.Result, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code := fmt.Sprintf("echo %d; echo %d > out_%d.txt; sleep 0.05", i, i, i)
+			results[i], errs[i] = executors[i%2].ExecuteCode(invocationContext(t), &codeexecutor.Input{Code: code})
+		}()
+	}
+	wg.Wait()
+	for i := range n {
+		if errs[i] != nil {
+			t.Fatalf("ExecuteCode(%d) error = %v", i, errs[i])
+		}
+		want := &codeexecutor.Result{
+			Stdout: fmt.Sprintf("%d\n", i),
+			OutputFiles: []codeexecutor.File{
+				{Name: fmt.Sprintf("out_%d.txt", i), MIMEType: "text/plain", Content: fmt.Appendf(nil, "%d\n", i)},
+			},
+		}
+		if diff := cmp.Diff(want, results[i]); diff != "" {
+			t.Errorf("ExecuteCode(%d) mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestLocal_ExecuteCode_Limits(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("resource limits aren't supported")
+	}
+	e := newShellExecutor(t, codeexecutor.LocalConfig{
+		Limits: codeexecutor.ResourceLimits{MaxFileSize: 1024},
+	})
+	got, err := e.ExecuteCode(invocationContext(t), &codeexecutor.Input{Code: "head -c 4096 /dev/zero > big.bin"})
+	if err != nil {
+		t.Fatalf("ExecuteCode() error = %v", err)
+	}
+	if got.Stderr == "" {
+		t.Errorf("ExecuteCode() stderr is empty, want the file size limit to fail the code")
+	}
+	for _, f := range got.OutputFiles {
+		if len(f.Content) > 1024 {
+			t.Errorf("ExecuteCode() wrote %d bytes to %s, want at most 1024", len(f.Content), f.Name)
+		}
+	}
+}
+
+func TestNewLocal(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		cfg     codeexecutor.LocalConfig
+		wantErr string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:    "empty command",
+			cfg:     codeexecutor.LocalConfig{Command: []string{""}},
+			wantErr: "command is empty",
+		},
+		{
+			name:    "file name with directory",
+			cfg:     codeexecutor.LocalConfig{FileName: "dir/main.py"},
+			wantErr: "must not contain a directory",
+		},
+		{
+			name:    "missing working directory",
+			cfg:     codeexecutor.LocalConfig{WorkDir: filepath.Join(file, "missing")},
+			wantErr: "invalid working directory",
+		},
+		{
+			name:    "working directory is a file",
+			cfg:     codeexecutor.LocalConfig{WorkDir: file},
+			wantErr: "is not a directory",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := codeexecutor.NewLocal(tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewLocal() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewLocal() error = %v", err)
+			}
+			if diff := cmp.Diff(codeexecutor.DefaultCodeBlockDelimiters, e.CodeBlockDelimiters()); diff != "" {
+				t.Errorf("CodeBlockDelimiters() mismatch (-want +got):\n%s", diff)
+			}
+			if got := e.ExecutionResultDelimiter(); got != codeexecutor.DefaultExecutionResultDelimiter {
+				t.Errorf("ExecutionResultDelimiter() = %v, want %v", got, codeexecutor.DefaultExecutionResultDelimiter)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package codeexecutor
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+const resourceLimitsSupported = true
+
+// command returns the command running the code in its own process group, so
+// that its subprocesses are killed with it.
+func command(ctx context.Context, name string, args []string, limits ResourceLimits) *exec.Cmd {
+	if script := ulimitScript(limits); script != "" {
+		args = append([]string{"-c", script + `exec "$@"`, "sh", name}, args...)
+		name = "/bin/sh"
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return cmd
+}
+
+// ulimitScript returns the shell commands setting the limits.
+func ulimitScript(l ResourceLimits) string {
+	var b strings.Builder
+	if l.MaxMemoryBytes > 0 {
+		fmt.Fprintf(&b, "ulimit -v %d || exit 1; ", ceilDiv(l.MaxMemoryBytes, 1024))
+	}
+	if l.MaxCPUTime > 0 {
+		fmt.Fprintf(&b, "ulimit -t %d || exit 1; ", ceilDiv(l.MaxCPUTime.Nanoseconds(), 1e9))
+	}
+	if l.MaxFileSize > 0 {
+		fmt.Fprintf(&b, "ulimit -f %d || exit 1; ", ceilDiv(l.MaxFileSize, 512))
+	}
+	if l.MaxOpenFiles > 0 {
+		fmt.Fprintf(&b, "ulimit -n %d || exit 1; ", l.MaxOpenFiles)
+	}
+	return b.String()
+}
+
+func ceilDiv(a, b int64) int64 {
+	return (a + b - 1) / b
+}
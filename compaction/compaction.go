@@ -0,0 +1,252 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compaction compacts the history of the sessions, so that long
+// sessions fit in the context window of the models.
+//
+// Once the history reaches a threshold of tokens or turns, the runner
+// summarizes the older events with a model into a compaction event, which
+// it appends to the session. The agents then send the summary to the model
+// instead of the compacted events, and the later compactions summarize the
+// previous summary along with the newer events.
+package compaction
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+// DefaultInstruction is the default instruction of the model summarizing
+// the events.
+const DefaultInstruction = `Summarize the conversation between a user and AI agents below, so that the conversation can continue from the summary alone. Keep the facts, the decisions, the open tasks and the results of the tool calls that may matter later. Reply with the summary only.`
+
+// Config configures the compaction of the sessions by a runner.
+type Config struct {
+	// Model summarizes the compacted events. Required.
+	Model model.LLM
+	// Instruction of the model. Defaults to DefaultInstruction.
+	Instruction string
+
+	// TokenThreshold triggers the compaction once the prompt of the last
+	// model call of the session has at least this number of tokens. Zero
+	// disables it.
+	TokenThreshold int
+	// TurnThreshold triggers the compaction once the session has at least
+	// this number of turns since the last compaction. A turn starts with a
+	// message of the user. Zero disables it.
+	TurnThreshold int
+	// KeepTurns is the number of the last turns kept verbatim.
+	KeepTurns int
+}
+
+// Validate checks the configuration.
+func (c *Config) Validate() error {
+	if c.Model == nil {
+		return fmt.Errorf("compaction model is required")
+	}
+	if c.TokenThreshold <= 0 && c.TurnThreshold <= 0 {
+		return fmt.Errorf("compaction requires a token or a turn threshold")
+	}
+	if c.KeepTurns < 0 {
+		return fmt.Errorf("compaction can't keep %d turns", c.KeepTurns)
+	}
+	return nil
+}
+
+// Compact returns the compaction event of the events, or nil if the events
+// don't reach a threshold or there is nothing to compact. The function calls
+// and their responses are compacted together.
+func Compact(ctx context.Context, cfg *Config, invocationID string, events session.Events) (*session.Event, error) {
+	var previous *session.EventCompaction
+	for ev := range events.All() {
+		if ev.Actions.Compaction != nil {
+			previous = ev.Actions.Compaction
+		}
+	}
+	// The events compacted by the previous compaction are summarized by its
+	// summary.
+	var history []*session.Event
+	for ev := range events.All() {
+		if ev.Actions.Compaction != nil || ev.Content == nil || len(ev.Content.Parts) == 0 {
+			continue
+		}
+		if previous == nil || ev.Timestamp.After(previous.EndTime) {
+			history = append(history, ev)
+		}
+	}
+
+	var turns []int
+	for i, ev := range history {
+		if isTurnStart(ev) {
+			turns = append(turns, i)
+		}
+	}
+	if !reachesThreshold(cfg, history, len(turns)) || len(turns) <= cfg.KeepTurns {
+		return nil, nil
+	}
+
+	end := len(history)
+	if cfg.KeepTurns > 0 {
+		end = turns[len(turns)-cfg.KeepTurns]
+	}
+	end = pairedEnd(history, end)
+	if end == 0 {
+		return nil, nil
+	}
+
+	summary, err := summarize(ctx, cfg, previous, history[:end])
+	if err != nil {
+		return nil, err
+	}
+	start := history[0].Timestamp
+	if previous != nil {
+		start = previous.StartTime
+	}
+	event := session.NewEvent(invocationID)
+	event.Author = "user"
+	event.Actions.Compaction = &session.EventCompaction{
+		StartTime: start,
+		EndTime:   history[end-1].Timestamp,
+		Content:   genai.NewContentFromText(summary, genai.RoleModel),
+	}
+	return event, nil
+}
+
+// isTurnStart reports whether the event is a message of the user, rather
+// than function responses sent by the client.
+func isTurnStart(ev *session.Event) bool {
+	if ev.Author != "user" {
+		return false
+	}
+	for _, p := range ev.Content.Parts {
+		if p.FunctionResponse == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func reachesThreshold(cfg *Config, history []*session.Event, turns int) bool {
+	if cfg.TurnThreshold > 0 && turns >= cfg.TurnThreshold {
+		return true
+	}
+	if cfg.TokenThreshold <= 0 {
+		return false
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if usage := history[i].UsageMetadata; usage != nil {
+			return int(usage.PromptTokenCount) >= cfg.TokenThreshold
+		}
+	}
+	return false
+}
+
+// pairedEnd returns the end of the compacted events, at most end, such that
+// the function calls of the compacted events are responded to by compacted
+// events. The calls are kept with their responses, or with the responses
+// still to come.
+func pairedEnd(history []*session.Event, end int) int {
+	responses := make(map[string]int)
+	for i, ev := range history {
+		for _, p := range ev.Content.Parts {
+			if p.FunctionResponse != nil {
+				responses[p.FunctionResponse.ID] = i
+			}
+		}
+	}
+	splitsCall := func(end int) bool {
+		for _, ev := range history[:end] {
+			for _, p := range ev.Content.Parts {
+				if p.FunctionCall == nil {
+					continue
+				}
+				if i, ok := responses[p.FunctionCall.ID]; !ok || i >= end {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for end > 0 && splitsCall(end) {
+		end--
+	}
+	return end
+}
+
+func summarize(ctx context.Context, cfg *Config, previous *session.EventCompaction, events []*session.Event) (string, error) {
+	var transcript strings.Builder
+	if previous != nil {
+		fmt.Fprintf(&transcript, "Summary of the earlier conversation: %s\n", text(previous.Content))
+	}
+	for _, ev := range events {
+		for _, p := range ev.Content.Parts {
+			switch {
+			case p.Thought:
+			case p.Text != "":
+				fmt.Fprintf(&transcript, "[%s] said: %s\n", ev.Author, p.Text)
+			case p.FunctionCall != nil:
+				fmt.Fprintf(&transcript, "[%s] called tool %q with parameters: %s\n", ev.Author, p.FunctionCall.Name, stringify(p.FunctionCall.Args))
+			case p.FunctionResponse != nil:
+				fmt.Fprintf(&transcript, "[%s] %q tool returned result: %s\n", ev.Author, p.FunctionResponse.Name, stringify(p.FunctionResponse.Response))
+			}
+		}
+	}
+
+	instruction := cfg.Instruction
+	if instruction == "" {
+		instruction = DefaultInstruction
+	}
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText(transcript.String(), genai.RoleUser)},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText(instruction, genai.RoleUser),
+		},
+	}
+	var summary strings.Builder
+	for resp, err := range cfg.Model.GenerateContent(ctx, req, false) {
+		if err != nil {
+			return "", fmt.Errorf("failed to summarize the events: %w", err)
+		}
+		summary.WriteString(text(resp.Content))
+	}
+	if summary.Len() == 0 {
+		return "", fmt.Errorf("failed to summarize the events: empty summary")
+	}
+	return summary.String(), nil
+}
+
+func text(c *genai.Content) string {
+	if c == nil {
+		return ""
+	}
+	var s strings.Builder
+	for _, p := range c.Parts {
+		if !p.Thought {
+			s.WriteString(p.Text)
+		}
+	}
+	return s.String()
+}
+
+func stringify(v any) string {
+	s, _ := json.Marshal(v)
+	return string(s)
+}
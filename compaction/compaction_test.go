@@ -0,0 +1,354 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compaction_test
+
+import (
+	"errors"
+	"iter"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/compaction"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/modeltest"
+	"google.golang.org/adk/plugin"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+)
+
+func TestCompact(t *testing.T) {
+	start := time.Now()
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Second) }
+	text := func(i int, author, text string) *session.Event {
+		var role genai.Role = genai.RoleModel
+		if author == "user" {
+			role = genai.RoleUser
+		}
+		return &session.Event{
+			Timestamp:   at(i),
+			Author:      author,
+			LLMResponse: model.LLMResponse{Content: genai.NewContentFromText(text, role)},
+		}
+	}
+	call := func(i int, id string) *session.Event {
+		return &session.Event{
+			Timestamp: at(i),
+			Author:    "agent",
+			LLMResponse: model.LLMResponse{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+				{FunctionCall: &genai.FunctionCall{ID: id, Name: "tool"}},
+			}}},
+		}
+	}
+	response := func(i int, author, id string) *session.Event {
+		return &session.Event{
+			Timestamp: at(i),
+			Author:    author,
+			LLMResponse: model.LLMResponse{Content: &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+				{FunctionResponse: &genai.FunctionResponse{ID: id, Name: "tool", Response: map[string]any{"result": "ok"}}},
+			}}},
+		}
+	}
+	withUsage := func(ev *session.Event, promptTokens int32) *session.Event {
+		ev.UsageMetadata = &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: promptTokens}
+		return ev
+	}
+	previous := &session.Event{
+		Timestamp: at(3),
+		Author:    "user",
+		Actions: session.EventActions{Compaction: &session.EventCompaction{
+			StartTime: at(0),
+			EndTime:   at(2),
+			Content:   genai.NewContentFromText("earlier summary", genai.RoleModel),
+		}},
+	}
+
+	tests := []struct {
+		name   string
+		cfg    compaction.Config
+		events []*session.Event
+		// want is the compaction, nil if none, without its content.
+		want *session.EventCompaction
+		// wantTranscript is the transcript summarized by the model.
+		wantTranscript string
+	}{
+		{
+			name: "BelowTurnThreshold",
+			cfg:  compaction.Config{TurnThreshold: 3, KeepTurns: 1},
+			events: []*session.Event{
+				text(1, "user", "a"), text(2, "agent", "b"),
+				text(3, "user", "c"), text(4, "agent", "d"),
+			},
+		},
+		{
+			name: "TurnThreshold",
+			cfg:  compaction.Config{TurnThreshold: 2, KeepTurns: 1},
+			events: []*session.Event{
+				text(1, "user", "a"), text(2, "agent", "b"),
+				text(3, "user", "c"), text(4, "agent", "d"),
+			},
+			want:           &session.EventCompaction{StartTime: at(1), EndTime: at(2)},
+			wantTranscript: "[user] said: a\n[agent] said: b\n",
+		},
+		{
+			name: "NothingToCompact",
+			cfg:  compaction.Config{TurnThreshold: 2, KeepTurns: 2},
+			events: []*session.Event{
+				text(1, "user", "a"), text(2, "agent", "b"),
+				text(3, "user", "c"), text(4, "agent", "d"),
+			},
+		},
+		{
+			name: "TokenThreshold",
+			cfg:  compaction.Config{TokenThreshold: 1000},
+			events: []*session.Event{
+				text(1, "user", "a"), withUsage(text(2, "agent", "b"), 1200),
+			},
+			want:           &session.EventCompaction{StartTime: at(1), EndTime: at(2)},
+			wantTranscript: "[user] said: a\n[agent] said: b\n",
+		},
+		{
+			name: "BelowTokenThreshold",
+			cfg:  compaction.Config{TokenThreshold: 1000},
+			events: []*session.Event{
+				text(1, "user", "a"), withUsage(text(2, "agent", "b"), 1200),
+				text(3, "user", "c"), withUsage(text(4, "agent", "d"), 800),
+			},
+		},
+		{
+			name: "FunctionCallsNotSplit",
+			cfg:  compaction.Config{TurnThreshold: 2, KeepTurns: 1},
+			events: []*session.Event{
+				text(1, "user", "a"), text(2, "agent", "b"), call(3, "c1"), response(4, "agent", "c1"), call(5, "c2"),
+				// The response of the long-running call c2 comes in the
+				// second turn.
+				text(6, "user", "c"), text(7, "agent", "d"), response(8, "user", "c2"),
+			},
+			want:           &session.EventCompaction{StartTime: at(1), EndTime: at(4)},
+			wantTranscript: "[user] said: a\n[agent] said: b\n[agent] called tool \"tool\" with parameters: null\n[agent] \"tool\" tool returned result: {\"result\":\"ok\"}\n",
+		},
+		{
+			name: "PreviousCompaction",
+			cfg:  compaction.Config{TurnThreshold: 2, KeepTurns: 1},
+			events: []*session.Event{
+				text(1, "user", "a"), text(2, "agent", "b"), previous,
+				text(4, "user", "c"), text(5, "agent", "d"),
+				text(6, "user", "e"), text(7, "agent", "f"),
+			},
+			want:           &session.EventCompaction{StartTime: at(0), EndTime: at(5)},
+			wantTranscript: "Summary of the earlier conversation: earlier summary\n[user] said: c\n[agent] said: d\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			llm := modeltest.New("summarizer", modeltest.Text("summary"))
+			tc.cfg.Model = llm
+			got, err := compaction.Compact(t.Context(), &tc.cfg, "invocation", events(tc.events))
+			if err != nil {
+				t.Fatalf("Compact() error = %v", err)
+			}
+			if tc.want == nil {
+				if got != nil {
+					t.Errorf("Compact() = %+v, want nil", got.Actions.Compaction)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("Compact() = nil, want compaction event")
+			}
+			if got.Author != "user" || got.InvocationID != "invocation" {
+				t.Errorf("Compact() author = %q, invocation = %q, want user, invocation", got.Author, got.InvocationID)
+			}
+			tc.want.Content = genai.NewContentFromText("summary", genai.RoleModel)
+			if diff := cmp.Diff(tc.want, got.Actions.Compaction); diff != "" {
+				t.Errorf("Compact() compaction mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff([]string{"user: " + tc.wantTranscript}, modeltest.Contents(llm.LastRequest())); diff != "" {
+				t.Errorf("Compact() transcript mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCompact_ModelError(t *testing.T) {
+	modelErr := errors.New("model failed")
+	cfg := &compaction.Config{Model: modeltest.New("summarizer", modeltest.Error(modelErr)), TurnThreshold: 1}
+	evs := events{{
+		Timestamp:   time.Now(),
+		Author:      "user",
+		LLMResponse: model.LLMResponse{Content: genai.NewContentFromText("a", genai.RoleUser)},
+	}}
+	if _, err := compaction.Compact(t.Context(), cfg, "invocation", evs); !errors.Is(err, modelErr) {
+		t.Errorf("Compact() error = %v, want %v", err, modelErr)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	llm := modeltest.New("summarizer")
+	for _, tc := range []struct {
+		name    string
+		cfg     compaction.Config
+		wantErr string
+	}{
+		{name: "Valid", cfg: compaction.Config{Model: llm, TurnThreshold: 1}},
+		{name: "NoModel", cfg: compaction.Config{TurnThreshold: 1}, wantErr: "model is required"},
+		{name: "NoThreshold", cfg: compaction.Config{Model: llm}, wantErr: "requires a token or a turn threshold"},
+		{name: "NegativeKeepTurns", cfg: compaction.Config{Model: llm, TurnThreshold: 1, KeepTurns: -1}, wantErr: "can't keep -1 turns"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunnerCompaction(t *testing.T) {
+	ctx := t.Context()
+	llm := modeltest.New("test-model", modeltest.Text("Hello!"), modeltest.Text("Sunny."), modeltest.Text("Bye!"))
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: llm})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	summarizer := modeltest.New("summarizer", modeltest.Text("The user greeted the agent."), modeltest.Text("The user asked about the weather."))
+	r, err := runner.New(runner.Config{
+		AppName:        "app",
+		Agent:          a,
+		SessionService: sessionService,
+		Compaction:     &compaction.Config{Model: summarizer, TurnThreshold: 2, KeepTurns: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The session is compacted after the second and the third turns.
+	for _, msg := range []string{"Hi", "How's the weather?", "Bye"} {
+		for _, err := range r.Run(ctx, "user", "session", genai.NewContentFromText(msg, genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				t.Fatalf("Run(%q) error = %v", msg, err)
+			}
+		}
+	}
+
+	want := []string{
+		"model: The user greeted the agent.",
+		"user: How's the weather?",
+		"model: Sunny.",
+		"user: Bye",
+	}
+	if diff := cmp.Diff(want, modeltest.Contents(llm.LastRequest())); diff != "" {
+		t.Errorf("model request contents mismatch (-want +got):\n%s", diff)
+	}
+	if err := summarizer.Done(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunnerCompaction_SummarizerError(t *testing.T) {
+	ctx := t.Context()
+	llm := modeltest.New("test-model", modeltest.Text("Hello!"))
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: llm})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	summarizerErr := errors.New("summarizer failed")
+	errPlugin := &errorPlugin{}
+	r, err := runner.New(runner.Config{
+		AppName:        "app",
+		Agent:          a,
+		SessionService: sessionService,
+		Plugins:        []plugin.Plugin{errPlugin},
+		Compaction:     &compaction.Config{Model: modeltest.New("summarizer", modeltest.Error(summarizerErr)), TurnThreshold: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The run succeeds, and the failure goes to the plugins.
+	var texts []string
+	for ev, err := range r.Run(ctx, "user", "session", genai.NewContentFromText("Hi", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		texts = append(texts, ev.Content.Parts[0].Text)
+	}
+	if diff := cmp.Diff([]string{"Hello!"}, texts); diff != "" {
+		t.Errorf("Run() texts mismatch (-want +got):\n%s", diff)
+	}
+	if len(errPlugin.errs) != 1 || !errors.Is(errPlugin.errs[0], summarizerErr) {
+		t.Errorf("OnError() errors = %v, want %v", errPlugin.errs, summarizerErr)
+	}
+
+	// The history isn't compacted.
+	resp, err := sessionService.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "session"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ev := range resp.Session.Events().All() {
+		if ev.Actions.Compaction != nil {
+			t.Errorf("session has compaction event %+v", ev)
+		}
+	}
+}
+
+// errorPlugin records the errors passed to its OnError hook.
+type errorPlugin struct {
+	plugin.Base
+	errs []error
+}
+
+func (p *errorPlugin) Name() string {
+	return "error_plugin"
+}
+
+func (p *errorPlugin) OnError(_ agent.InvocationContext, err error) {
+	p.errs = append(p.errs, err)
+}
+
+// events implements session.Events.
+type events []*session.Event
+
+func (e events) All() iter.Seq[*session.Event] {
+	return slices.Values(e)
+}
+
+func (e events) Len() int {
+	return len(e)
+}
+
+func (e events) At(i int) *session.Event {
+	return e[i]
+}
@@ -0,0 +1,506 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package guardrail checks the user messages, the model responses and the
+// tool results against content policies, such as deny lists, personal
+// information detectors, length limits and LLM judges.
+//
+// A [Guardrail] is a [plugin.Plugin] guarding all the agents of a runner:
+//
+//	g := guardrail.New(guardrail.Config{
+//		Rules: []guardrail.Rule{
+//			{Policy: guardrail.PII(), Action: guardrail.ActionRedact},
+//			{Policy: guardrail.Keywords("banned", "secret")},
+//		},
+//	})
+//	r, err := runner.New(runner.Config{
+//		...
+//		Plugins: []plugin.Plugin{g},
+//	})
+//
+// It can also hook into the callbacks of a single agent:
+//
+//	a, err := llmagent.New(llmagent.Config{
+//		...
+//		BeforeModelCallbacks: []llmagent.BeforeModelCallback{g.BeforeModel},
+//		AfterModelCallbacks:  []llmagent.AfterModelCallback{g.AfterModel},
+//		AfterToolCallbacks:   []llmagent.AfterToolCallback{g.AfterTool},
+//		AfterAgentCallbacks:  []agent.AfterAgentCallback{g.AfterAgent},
+//	})
+//
+// As a plugin, the guardrail checks each user message once, when it's added
+// to the session, and the session keeps the redacted message. As callbacks,
+// it checks the user message of the current turn on the model requests, and
+// only the requests are redacted.
+//
+// The content violating a policy is either blocked, replaced with a canned
+// response, or redacted. The violations are recorded in the custom metadata
+// of the model response events under [MetadataKey].
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/plugin"
+	"google.golang.org/adk/tool"
+)
+
+// MetadataKey is the key of the violations in the custom metadata of the
+// events. The value is a []Violation.
+const MetadataKey = "guardrail_violations"
+
+// DefaultBlockedResponse is the default text of the responses replacing the
+// blocked content.
+const DefaultBlockedResponse = "Sorry, I can't help with that."
+
+// blockedMessage replaces the blocked user messages in the session.
+const blockedMessage = "[BLOCKED]"
+
+// Stage is a stage of the runs whose content is checked.
+type Stage string
+
+const (
+	// StageInput checks the text of the user messages.
+	StageInput Stage = "input"
+	// StageOutput checks the text of the model responses.
+	StageOutput Stage = "output"
+	// StageToolResult checks the string values of the tool results.
+	StageToolResult Stage = "tool_result"
+)
+
+// Action is what a guardrail does with the content violating a policy.
+type Action string
+
+const (
+	// ActionBlock replaces the content with the blocked response.
+	ActionBlock Action = "block"
+	// ActionRedact redacts the violating parts of the content. The content
+	// is blocked if the policy can't redact it.
+	ActionRedact Action = "redact"
+)
+
+// Policy checks texts against a content policy.
+type Policy interface {
+	// Name identifies the policy in the violations.
+	Name() string
+	// Check returns the finding of the policy in the text, or nil if the
+	// text complies with the policy.
+	Check(ctx context.Context, text string) (*Finding, error)
+}
+
+// Finding is a violation of a policy found in a text.
+type Finding struct {
+	// Reason describes the violation.
+	Reason string
+	// Redacted is the text with the violating parts redacted. It's empty if
+	// the policy can't redact the text.
+	Redacted string
+}
+
+// Violation is a violation of a policy recorded in the event metadata.
+type Violation struct {
+	Policy string `json:"policy"`
+	Stage  Stage  `json:"stage"`
+	// Action is the action taken.
+	Action Action `json:"action"`
+	Reason string `json:"reason"`
+	// Tool is the name of the tool whose result violated the policy.
+	Tool string `json:"tool,omitempty"`
+}
+
+// Rule applies a policy to stages of the runs.
+type Rule struct {
+	Policy Policy
+	// Stages checked by the policy. Empty means all the stages.
+	Stages []Stage
+	// Action taken on violations. Defaults to ActionBlock.
+	Action Action
+}
+
+// Config is used to create a [Guardrail].
+type Config struct {
+	// Name of the guardrail when used as a plugin. Defaults to "guardrail".
+	Name string
+	// Rules are checked in order. The redacted text is checked by the rules
+	// following the redacting rule.
+	Rules []Rule
+	// BlockedResponse is the text of the responses replacing the blocked
+	// content. Defaults to DefaultBlockedResponse.
+	BlockedResponse string
+}
+
+// Guardrail checks the content of the runs against the rules. It's safe for
+// concurrent use.
+type Guardrail struct {
+	plugin.Base
+
+	name            string
+	rules           []Rule
+	blockedResponse string
+
+	mu sync.Mutex
+	// pending holds the violations found in the model requests and the tool
+	// results, until they are recorded in the next model response.
+	pending map[pendingKey][]Violation
+	// inputs holds the checks of the user messages, by invocation ID.
+	inputs map[string]*inputCheck
+}
+
+type pendingKey struct {
+	invocationID, branch, agentName string
+}
+
+// inputCheck is the check of the user message of an invocation.
+type inputCheck struct {
+	violations []Violation
+	blocked    bool
+}
+
+// New creates a new [Guardrail].
+func New(cfg Config) *Guardrail {
+	name := cfg.Name
+	if name == "" {
+		name = "guardrail"
+	}
+	blockedResponse := cfg.BlockedResponse
+	if blockedResponse == "" {
+		blockedResponse = DefaultBlockedResponse
+	}
+	return &Guardrail{
+		name:            name,
+		rules:           cfg.Rules,
+		blockedResponse: blockedResponse,
+		pending:         make(map[pendingKey][]Violation),
+		inputs:          make(map[string]*inputCheck),
+	}
+}
+
+// Name implements [plugin.Plugin].
+func (g *Guardrail) Name() string {
+	return g.name
+}
+
+// OnUserMessage checks the text of the user message. The session keeps the
+// redacted message, or a placeholder if the message is blocked. The
+// violations are recorded in the next model response, and the model calls
+// of the invocation get the blocked response if the message is blocked.
+func (g *Guardrail) OnUserMessage(ctx agent.InvocationContext, msg *genai.Content) (*genai.Content, error) {
+	parts, violations, blocked, err := g.checkParts(ctx, StageInput, msg.Parts)
+	if err != nil {
+		return nil, err
+	}
+	g.mu.Lock()
+	g.inputs[ctx.InvocationID()] = &inputCheck{violations: violations, blocked: blocked}
+	g.mu.Unlock()
+	switch {
+	case blocked:
+		return genai.NewContentFromText(blockedMessage, genai.Role(msg.Role)), nil
+	case parts != nil:
+		return &genai.Content{Role: msg.Role, Parts: parts}, nil
+	}
+	return nil, nil
+}
+
+// BeforeModel returns the blocked response if the user message of the
+// invocation is blocked.
+//
+// If the message wasn't checked by OnUserMessage, i.e. the guardrail isn't a
+// plugin of the runner, it checks the text of the user contents following
+// the last model content, which are the message of the current turn, and
+// redacts the request.
+func (g *Guardrail) BeforeModel(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+	if input, ok := g.takeInput(ctx.InvocationID()); ok {
+		if input.blocked {
+			return g.blocked(append(g.takePending(ctx), input.violations...)), nil
+		}
+		g.addPending(ctx, input.violations)
+		return nil, nil
+	}
+
+	lastModelContent := -1
+	for i, c := range req.Contents {
+		if c != nil && c.Role == genai.RoleModel {
+			lastModelContent = i
+		}
+	}
+	var violations []Violation
+	for i := lastModelContent + 1; i < len(req.Contents); i++ {
+		c := req.Contents[i]
+		if c == nil || c.Role != genai.RoleUser {
+			continue
+		}
+		parts, vs, blocked, err := g.checkParts(ctx, StageInput, c.Parts)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, vs...)
+		if blocked {
+			return g.blocked(append(g.takePending(ctx), violations...)), nil
+		}
+		if parts != nil {
+			// The contents are shared with the session, so they are replaced
+			// rather than modified.
+			req.Contents[i] = &genai.Content{Role: c.Role, Parts: parts}
+		}
+	}
+	g.addPending(ctx, violations)
+	return nil, nil
+}
+
+// AfterModel checks the text of the model response. It returns the blocked
+// response if the text is blocked, and otherwise the redacted response, with
+// the violations of the model request and the tool results since the last
+// response recorded in its metadata. Partial responses aren't checked.
+func (g *Guardrail) AfterModel(ctx agent.CallbackContext, resp *model.LLMResponse, respErr error) (*model.LLMResponse, error) {
+	if respErr != nil || resp == nil || resp.Partial {
+		return nil, nil
+	}
+	var parts []*genai.Part
+	var violations []Violation
+	if resp.Content != nil {
+		var blocked bool
+		var err error
+		parts, violations, blocked, err = g.checkParts(ctx, StageOutput, resp.Content.Parts)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return g.blocked(append(g.takePending(ctx), violations...)), nil
+		}
+	}
+	violations = append(g.takePending(ctx), violations...)
+	if parts == nil && len(violations) == 0 {
+		return nil, nil
+	}
+
+	newResp := *resp
+	if parts != nil {
+		newResp.Content = &genai.Content{Role: resp.Content.Role, Parts: parts}
+	}
+	newResp.CustomMetadata = maps.Clone(resp.CustomMetadata)
+	if newResp.CustomMetadata == nil {
+		newResp.CustomMetadata = make(map[string]any)
+	}
+	newResp.CustomMetadata[MetadataKey] = violations
+	return &newResp, nil
+}
+
+// AfterTool checks the string values of the tool result. It returns an error
+// result with the blocked response if the result is blocked, and otherwise
+// the redacted result. The violations are recorded in the next model
+// response.
+func (g *Guardrail) AfterTool(ctx tool.Context, t tool.Tool, args, result map[string]any, toolErr error) (map[string]any, error) {
+	if toolErr != nil || result == nil {
+		return nil, nil
+	}
+	var violations []Violation
+	blocked := false
+	redacted, changed, err := mapStrings(result, func(s string) (string, error) {
+		if blocked {
+			return s, nil
+		}
+		text, vs, b, err := g.check(ctx, StageToolResult, s)
+		for i := range vs {
+			vs[i].Tool = t.Name()
+		}
+		violations = append(violations, vs...)
+		blocked = b
+		return text, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	g.addPending(ctx, violations)
+	if blocked {
+		return map[string]any{"error": g.blockedResponse}, nil
+	}
+	if !changed {
+		return nil, nil
+	}
+	return redacted.(map[string]any), nil
+}
+
+// AfterAgent forgets the violations of the agent which weren't recorded,
+// because no model response followed them.
+func (g *Guardrail) AfterAgent(ctx agent.CallbackContext) (*genai.Content, error) {
+	g.takePending(ctx)
+	return nil, nil
+}
+
+// AfterRun forgets the violations of the invocation which weren't recorded,
+// e.g. when the run ended with an error.
+func (g *Guardrail) AfterRun(ctx agent.InvocationContext) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.inputs, ctx.InvocationID())
+	for key := range g.pending {
+		if key.invocationID == ctx.InvocationID() {
+			delete(g.pending, key)
+		}
+	}
+}
+
+// check checks the text against the rules of the stage. It returns the text
+// redacted by the redacting rules and the violations, and reports whether
+// the text is blocked.
+func (g *Guardrail) check(ctx context.Context, stage Stage, text string) (string, []Violation, bool, error) {
+	var violations []Violation
+	for _, rule := range g.rules {
+		if len(rule.Stages) > 0 && !slices.Contains(rule.Stages, stage) {
+			continue
+		}
+		finding, err := rule.Policy.Check(ctx, text)
+		if err != nil {
+			return "", nil, false, fmt.Errorf("guardrail policy %q failed: %w", rule.Policy.Name(), err)
+		}
+		if finding == nil {
+			continue
+		}
+		violation := Violation{
+			Policy: rule.Policy.Name(),
+			Stage:  stage,
+			Reason: finding.Reason,
+		}
+		if rule.Action == ActionRedact && finding.Redacted != "" {
+			violation.Action = ActionRedact
+			violations = append(violations, violation)
+			text = finding.Redacted
+			continue
+		}
+		violation.Action = ActionBlock
+		return text, append(violations, violation), true, nil
+	}
+	return text, violations, false, nil
+}
+
+// checkParts checks the text parts. It returns the redacted parts, or nil if
+// none is redacted, and the violations, and reports whether the parts are
+// blocked.
+func (g *Guardrail) checkParts(ctx context.Context, stage Stage, parts []*genai.Part) ([]*genai.Part, []Violation, bool, error) {
+	var violations []Violation
+	var redacted []*genai.Part
+	for i, p := range parts {
+		if p == nil || p.Text == "" || p.Thought {
+			continue
+		}
+		text, vs, blocked, err := g.check(ctx, stage, p.Text)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		violations = append(violations, vs...)
+		if blocked {
+			return nil, violations, true, nil
+		}
+		if text == p.Text {
+			continue
+		}
+		if redacted == nil {
+			redacted = slices.Clone(parts)
+		}
+		part := *p
+		part.Text = text
+		redacted[i] = &part
+	}
+	return redacted, violations, false, nil
+}
+
+func (g *Guardrail) blocked(violations []Violation) *model.LLMResponse {
+	return &model.LLMResponse{
+		Content:        genai.NewContentFromText(g.blockedResponse, genai.RoleModel),
+		CustomMetadata: map[string]any{MetadataKey: violations},
+	}
+}
+
+func newPendingKey(ctx agent.ReadonlyContext) pendingKey {
+	return pendingKey{invocationID: ctx.InvocationID(), branch: ctx.Branch(), agentName: ctx.AgentName()}
+}
+
+func (g *Guardrail) addPending(ctx agent.ReadonlyContext, violations []Violation) {
+	if len(violations) == 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	key := newPendingKey(ctx)
+	g.pending[key] = append(g.pending[key], violations...)
+}
+
+// takeInput returns the check of the user message of the invocation, if
+// checked by OnUserMessage. Its violations are only returned once.
+func (g *Guardrail) takeInput(invocationID string) (inputCheck, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	input, ok := g.inputs[invocationID]
+	if !ok {
+		return inputCheck{}, false
+	}
+	res := *input
+	input.violations = nil
+	return res, true
+}
+
+func (g *Guardrail) takePending(ctx agent.ReadonlyContext) []Violation {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	key := newPendingKey(ctx)
+	violations := g.pending[key]
+	delete(g.pending, key)
+	return violations
+}
+
+var _ plugin.Plugin = (*Guardrail)(nil)
+
+// mapStrings returns a copy of v with f applied to its string values, nested
+// in maps and slices, and reports whether any of them changed.
+func mapStrings(v any, f func(string) (string, error)) (any, bool, error) {
+	switch v := v.(type) {
+	case string:
+		s, err := f(v)
+		return s, s != v, err
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		changed := false
+		for k, e := range v {
+			ne, c, err := mapStrings(e, f)
+			if err != nil {
+				return nil, false, err
+			}
+			m[k] = ne
+			changed = changed || c
+		}
+		return m, changed, nil
+	case []any:
+		s := make([]any, len(v))
+		changed := false
+		for i, e := range v {
+			ne, c, err := mapStrings(e, f)
+			if err != nil {
+				return nil, false, err
+			}
+			s[i] = ne
+			changed = changed || c
+		}
+		return s, changed, nil
+	default:
+		return v, false, nil
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guardrail_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/guardrail"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model/modeltest"
+	"google.golang.org/adk/plugin"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+func TestGuardrail(t *testing.T) {
+	bannedWords := guardrail.Keywords("banned_words", "bomb")
+	tests := []struct {
+		name  string
+		rules []guardrail.Rule
+		// result of the lookup tool.
+		toolResult     map[string]any
+		turns          []modeltest.Turn
+		wantTexts      []string
+		wantViolations []guardrail.Violation
+		// wantContents are the contents of the last model request.
+		wantContents []string
+		// wantToolResponse is the tool response sent to the model.
+		wantToolResponse map[string]any
+	}{
+		{
+			name:      "InputBlocked",
+			rules:     []guardrail.Rule{{Policy: bannedWords}},
+			wantTexts: []string{guardrail.DefaultBlockedResponse},
+			wantViolations: []guardrail.Violation{
+				{Policy: "banned_words", Stage: guardrail.StageInput, Action: guardrail.ActionBlock, Reason: `denied content "bomb"`},
+			},
+		},
+		{
+			name:      "InputRedacted",
+			rules:     []guardrail.Rule{{Policy: guardrail.PII(), Action: guardrail.ActionRedact}},
+			turns:     []modeltest.Turn{modeltest.Text("Noted.")},
+			wantTexts: []string{"Noted."},
+			wantViolations: []guardrail.Violation{
+				{Policy: "pii", Stage: guardrail.StageInput, Action: guardrail.ActionRedact, Reason: "found email"},
+			},
+			wantContents: []string{"user: My email is [EMAIL], what about the bomb?"},
+		},
+		{
+			name:      "OutputBlocked",
+			rules:     []guardrail.Rule{{Policy: bannedWords, Stages: []guardrail.Stage{guardrail.StageOutput}}},
+			turns:     []modeltest.Turn{modeltest.Text("Here is how to build a bomb.")},
+			wantTexts: []string{guardrail.DefaultBlockedResponse},
+			wantViolations: []guardrail.Violation{
+				{Policy: "banned_words", Stage: guardrail.StageOutput, Action: guardrail.ActionBlock, Reason: `denied content "bomb"`},
+			},
+			wantContents: []string{"user: My email is jane@example.com, what about the bomb?"},
+		},
+		{
+			name:      "OutputRedacted",
+			rules:     []guardrail.Rule{{Policy: guardrail.PII(), Stages: []guardrail.Stage{guardrail.StageOutput}, Action: guardrail.ActionRedact}},
+			turns:     []modeltest.Turn{modeltest.Text("Call 650-555-0100.")},
+			wantTexts: []string{"Call [PHONE]."},
+			wantViolations: []guardrail.Violation{
+				{Policy: "pii", Stage: guardrail.StageOutput, Action: guardrail.ActionRedact, Reason: "found phone"},
+			},
+			wantContents: []string{"user: My email is jane@example.com, what about the bomb?"},
+		},
+		{
+			name:       "ToolResultRedacted",
+			rules:      []guardrail.Rule{{Policy: guardrail.PII(), Stages: []guardrail.Stage{guardrail.StageToolResult}, Action: guardrail.ActionRedact}},
+			toolResult: map[string]any{"contacts": []any{"john@example.com", map[string]any{"phone": "650-555-0100"}}, "count": 2},
+			turns: []modeltest.Turn{
+				modeltest.FunctionCall("lookup", map[string]any{}),
+				modeltest.Text("Found them."),
+			},
+			wantTexts: []string{"Found them."},
+			wantViolations: []guardrail.Violation{
+				{Policy: "pii", Stage: guardrail.StageToolResult, Action: guardrail.ActionRedact, Reason: "found email", Tool: "lookup"},
+				{Policy: "pii", Stage: guardrail.StageToolResult, Action: guardrail.ActionRedact, Reason: "found phone", Tool: "lookup"},
+			},
+			wantContents:     []string{"user: My email is jane@example.com, what about the bomb?", "model: call lookup", "user: response lookup"},
+			wantToolResponse: map[string]any{"contacts": []any{"[EMAIL]", map[string]any{"phone": "[PHONE]"}}, "count": 2.0},
+		},
+		{
+			name:       "ToolResultBlocked",
+			rules:      []guardrail.Rule{{Policy: bannedWords, Stages: []guardrail.Stage{guardrail.StageToolResult}}},
+			toolResult: map[string]any{"result": "a bomb recipe"},
+			turns: []modeltest.Turn{
+				modeltest.FunctionCall("lookup", map[string]any{}),
+				modeltest.Text("I can't share that."),
+			},
+			wantTexts: []string{"I can't share that."},
+			wantViolations: []guardrail.Violation{
+				{Policy: "banned_words", Stage: guardrail.StageToolResult, Action: guardrail.ActionBlock, Reason: `denied content "bomb"`, Tool: "lookup"},
+			},
+			wantContents:     []string{"user: My email is jane@example.com, what about the bomb?", "model: call lookup", "user: response lookup"},
+			wantToolResponse: map[string]any{"error": guardrail.DefaultBlockedResponse},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			lookup, err := functiontool.New(functiontool.Config{
+				Name:        "lookup",
+				Description: "looks up the contacts",
+			}, func(tool.Context, struct{}) (map[string]any, error) {
+				return tc.toolResult, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			llm := modeltest.New("test-model", tc.turns...)
+			g := guardrail.New(guardrail.Config{Rules: tc.rules})
+			a, err := llmagent.New(llmagent.Config{
+				Name:                 "agent",
+				Model:                llm,
+				Tools:                []tool.Tool{lookup},
+				BeforeModelCallbacks: []llmagent.BeforeModelCallback{g.BeforeModel},
+				AfterModelCallbacks:  []llmagent.AfterModelCallback{g.AfterModel},
+				AfterToolCallbacks:   []llmagent.AfterToolCallback{g.AfterTool},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			runner := testutil.NewTestAgentRunner(t, a)
+			events, err := testutil.CollectEvents(runner.Run(t, "session", "My email is jane@example.com, what about the bomb?"))
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			var gotTexts []string
+			var gotViolations []guardrail.Violation
+			for _, ev := range events {
+				if ev.Content != nil && ev.Content.Parts[0].Text != "" {
+					gotTexts = append(gotTexts, ev.Content.Parts[0].Text)
+				}
+				if vs, ok := ev.CustomMetadata[guardrail.MetadataKey].([]guardrail.Violation); ok {
+					gotViolations = append(gotViolations, vs...)
+				}
+			}
+			if diff := cmp.Diff(tc.wantTexts, gotTexts); diff != "" {
+				t.Errorf("Run() texts mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantViolations, gotViolations); diff != "" {
+				t.Errorf("Run() violations mismatch (-want +got):\n%s", diff)
+			}
+			if err := llm.Done(); err != nil {
+				t.Error(err)
+			}
+
+			req := llm.LastRequest()
+			if req == nil {
+				if tc.wantContents != nil {
+					t.Fatal("model wasn't called")
+				}
+				return
+			}
+			if diff := cmp.Diff(tc.wantContents, modeltest.Contents(req)); diff != "" {
+				t.Errorf("model request contents mismatch (-want +got):\n%s", diff)
+			}
+			if tc.wantToolResponse != nil {
+				got := req.Contents[len(req.Contents)-1].Parts[0].FunctionResponse.Response
+				if diff := cmp.Diff(tc.wantToolResponse, got); diff != "" {
+					t.Errorf("tool response mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestGuardrail_Plugin(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         guardrail.Policy
+		action         guardrail.Action
+		turns          []modeltest.Turn
+		wantTexts      []string
+		wantViolations []guardrail.Violation
+		// wantMessages are the user messages stored in the session.
+		wantMessages []string
+		// wantContents are the contents of the last model request.
+		wantContents []string
+	}{
+		{
+			name:   "Redacted",
+			policy: guardrail.PII(),
+			action: guardrail.ActionRedact,
+			turns: []modeltest.Turn{
+				modeltest.FunctionCall("lookup", map[string]any{}),
+				modeltest.Text("Noted."),
+				modeltest.Text("Bye."),
+			},
+			wantTexts: []string{"Noted.", "Bye."},
+			wantViolations: []guardrail.Violation{
+				{Policy: "pii", Stage: guardrail.StageInput, Action: guardrail.ActionRedact, Reason: "found email"},
+			},
+			wantMessages: []string{"My email is [EMAIL].", "Thanks."},
+			wantContents: []string{
+				"user: My email is [EMAIL].", "model: call lookup", "user: response lookup", "model: Noted.",
+				"user: Thanks.",
+			},
+		},
+		{
+			name:   "Blocked",
+			policy: guardrail.Keywords("banned_words", "jane"),
+			turns:  []modeltest.Turn{modeltest.Text("Bye.")},
+			// The blocked message isn't sent to the model in the next turn.
+			wantTexts: []string{guardrail.DefaultBlockedResponse, "Bye."},
+			wantViolations: []guardrail.Violation{
+				{Policy: "banned_words", Stage: guardrail.StageInput, Action: guardrail.ActionBlock, Reason: `denied content "jane"`},
+			},
+			wantMessages: []string{"[BLOCKED]", "Thanks."},
+			wantContents: []string{"user: [BLOCKED]", "model: " + guardrail.DefaultBlockedResponse, "user: Thanks."},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			lookup, err := functiontool.New(functiontool.Config{
+				Name:        "lookup",
+				Description: "looks up the contacts",
+			}, func(tool.Context, struct{}) (map[string]any, error) {
+				return map[string]any{"result": "none"}, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			llm := modeltest.New("test-model", tc.turns...)
+			a, err := llmagent.New(llmagent.Config{
+				Name:  "agent",
+				Model: llm,
+				Tools: []tool.Tool{lookup},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			policy := &countingPolicy{Policy: tc.policy}
+			g := guardrail.New(guardrail.Config{
+				Rules: []guardrail.Rule{{Policy: policy, Stages: []guardrail.Stage{guardrail.StageInput}, Action: tc.action}},
+			})
+			sessionService := session.InMemoryService()
+			r, err := runner.New(runner.Config{
+				AppName:        "app",
+				Agent:          a,
+				SessionService: sessionService,
+				Plugins:        []plugin.Plugin{g},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+				t.Fatal(err)
+			}
+
+			var gotTexts []string
+			var gotViolations []guardrail.Violation
+			for _, msg := range []string{"My email is jane@example.com.", "Thanks."} {
+				for ev, err := range r.Run(t.Context(), "user", "session", genai.NewContentFromText(msg, genai.RoleUser), agent.RunConfig{}) {
+					if err != nil {
+						t.Fatalf("Run(%q) error = %v", msg, err)
+					}
+					if ev.Content != nil && ev.Content.Parts[0].Text != "" {
+						gotTexts = append(gotTexts, ev.Content.Parts[0].Text)
+					}
+					if vs, ok := ev.CustomMetadata[guardrail.MetadataKey].([]guardrail.Violation); ok {
+						gotViolations = append(gotViolations, vs...)
+					}
+				}
+			}
+			if diff := cmp.Diff(tc.wantTexts, gotTexts); diff != "" {
+				t.Errorf("Run() texts mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantViolations, gotViolations); diff != "" {
+				t.Errorf("Run() violations mismatch (-want +got):\n%s", diff)
+			}
+			if err := llm.Done(); err != nil {
+				t.Error(err)
+			}
+			// Each message is checked once, regardless of the model calls.
+			if policy.checks != 2 {
+				t.Errorf("policy checked %d texts, want 2", policy.checks)
+			}
+
+			resp, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "session"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotMessages []string
+			for ev := range resp.Session.Events().All() {
+				if ev.Author == "user" {
+					gotMessages = append(gotMessages, ev.Content.Parts[0].Text)
+				}
+			}
+			if diff := cmp.Diff(tc.wantMessages, gotMessages); diff != "" {
+				t.Errorf("session user messages mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantContents, modeltest.Contents(llm.LastRequest())); diff != "" {
+				t.Errorf("model request contents mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// countingPolicy counts the texts checked by the policy.
+type countingPolicy struct {
+	guardrail.Policy
+	checks int
+}
+
+func (p *countingPolicy) Check(ctx context.Context, text string) (*guardrail.Finding, error) {
+	p.checks++
+	return p.Policy.Check(ctx, text)
+}
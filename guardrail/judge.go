@@ -0,0 +1,84 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+const judgeInstruction = `You are a content policy classifier. Decide whether the text sent by the user violates the following policy:
+
+%s
+
+Answer with SAFE if the text complies with the policy. Otherwise answer with UNSAFE, followed by a colon and a short reason.`
+
+// Judge returns a policy classifying the texts with the model, against the
+// policy described in natural language. The texts can't be redacted.
+func Judge(name string, llm model.LLM, policy string) Policy {
+	return &judge{name: name, llm: llm, policy: policy}
+}
+
+type judge struct {
+	name   string
+	llm    model.LLM
+	policy string
+}
+
+func (j *judge) Name() string {
+	return j.name
+}
+
+func (j *judge) Check(ctx context.Context, text string) (*Finding, error) {
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText(text, genai.RoleUser)},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText(fmt.Sprintf(judgeInstruction, j.policy), genai.RoleUser),
+		},
+	}
+	var answer string
+	for resp, err := range j.llm.GenerateContent(ctx, req, false) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to call judge model: %w", err)
+		}
+		if resp.Content == nil {
+			continue
+		}
+		for _, p := range resp.Content.Parts {
+			if !p.Thought {
+				answer += p.Text
+			}
+		}
+	}
+
+	answer = strings.TrimSpace(answer)
+	switch verdict, reason, _ := strings.Cut(answer, ":"); strings.ToUpper(strings.TrimSpace(verdict)) {
+	case "SAFE":
+		return nil, nil
+	case "UNSAFE":
+		reason = strings.TrimSpace(reason)
+		if reason == "" {
+			reason = "judged unsafe"
+		}
+		return &Finding{Reason: reason}, nil
+	default:
+		return nil, fmt.Errorf("unexpected judge answer %q", answer)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guardrail
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model/modeltest"
+	"google.golang.org/adk/plugin"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+func TestGuardrail_AfterRunForgetsPending(t *testing.T) {
+	lookup, err := functiontool.New(functiontool.Config{
+		Name:        "lookup",
+		Description: "looks up the contacts",
+	}, func(tool.Context, struct{}) (map[string]any, error) {
+		return map[string]any{"result": "jane@example.com"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The run fails before the violations of the tool result are recorded.
+	modelErr := errors.New("model failed")
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "agent",
+		Model: modeltest.New("test-model", modeltest.FunctionCall("lookup", map[string]any{}), modeltest.Error(modelErr)),
+		Tools: []tool.Tool{lookup},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New(Config{Rules: []Rule{{Policy: PII(), Action: ActionRedact}}})
+	sessionService := session.InMemoryService()
+	r, err := runner.New(runner.Config{
+		AppName:        "app",
+		Agent:          a,
+		SessionService: sessionService,
+		Plugins:        []plugin.Plugin{g},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotErr error
+	for _, err := range r.Run(t.Context(), "user", "session", genai.NewContentFromText("Find Jane.", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			gotErr = err
+		}
+	}
+	if !errors.Is(gotErr, modelErr) {
+		t.Fatalf("Run() error = %v, want %v", gotErr, modelErr)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.pending) != 0 || len(g.inputs) != 0 {
+		t.Errorf("after the run, pending = %v, inputs = %v, want them empty", g.pending, g.inputs)
+	}
+}
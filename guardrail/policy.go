@@ -0,0 +1,195 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// RedactedText replaces the matches of the deny list policies.
+const RedactedText = "[REDACTED]"
+
+// Regexp returns a policy denying the texts matching any of the regular
+// expressions. The matches are redacted with RedactedText.
+func Regexp(name string, exprs ...string) (Policy, error) {
+	var res []*regexp.Regexp
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression of policy %q: %w", name, err)
+		}
+		res = append(res, re)
+	}
+	return &denyList{name: name, res: res}, nil
+}
+
+// Keywords returns a policy denying the texts containing any of the keywords
+// as whole words, case-insensitively. The keywords are redacted with
+// RedactedText.
+func Keywords(name string, keywords ...string) Policy {
+	var res []*regexp.Regexp
+	for _, keyword := range keywords {
+		res = append(res, regexp.MustCompile(`(?i)\b`+regexp.QuoteMeta(keyword)+`\b`))
+	}
+	return &denyList{name: name, res: res}
+}
+
+type denyList struct {
+	name string
+	res  []*regexp.Regexp
+}
+
+func (p *denyList) Name() string {
+	return p.name
+}
+
+func (p *denyList) Check(_ context.Context, text string) (*Finding, error) {
+	var matches []string
+	redacted := text
+	for _, re := range p.res {
+		loc := re.FindStringIndex(redacted)
+		if loc == nil {
+			continue
+		}
+		matches = append(matches, fmt.Sprintf("%q", redacted[loc[0]:loc[1]]))
+		redacted = re.ReplaceAllLiteralString(redacted, RedactedText)
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	return &Finding{
+		Reason:   "denied content " + strings.Join(matches, ", "),
+		Redacted: redacted,
+	}, nil
+}
+
+// MaxLength returns a policy denying the texts longer than n characters. The
+// texts are redacted by truncating them.
+func MaxLength(n int) Policy {
+	return maxLength(n)
+}
+
+type maxLength int
+
+func (maxLength) Name() string {
+	return "max_length"
+}
+
+func (n maxLength) Check(_ context.Context, text string) (*Finding, error) {
+	length := utf8.RuneCountInString(text)
+	if length <= int(n) {
+		return nil, nil
+	}
+	return &Finding{
+		Reason:   fmt.Sprintf("text of %d characters exceeds the maximum of %d", length, n),
+		Redacted: string([]rune(text)[:n]),
+	}, nil
+}
+
+// PIIKind is a kind of personally identifiable information.
+type PIIKind string
+
+const (
+	PIIEmail      PIIKind = "email"
+	PIIPhone      PIIKind = "phone"
+	PIICreditCard PIIKind = "credit_card"
+	PIISSN        PIIKind = "ssn"
+	PIIIPAddress  PIIKind = "ip_address"
+)
+
+// piiDetectors holds the detectors of the kinds of PII, in the order they
+// are applied: the credit card and social security numbers are detected
+// before the phone numbers, which they resemble.
+var piiDetectors = []struct {
+	kind PIIKind
+	re   *regexp.Regexp
+	// valid validates the matches, if set.
+	valid func(string) bool
+}{
+	{kind: PIIEmail, re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	{kind: PIICreditCard, re: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), valid: luhn},
+	{kind: PIISSN, re: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	{kind: PIIIPAddress, re: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`)},
+	{kind: PIIPhone, re: regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{3}\)|\b\d{3})[ .-]?\d{3}[ .-]?\d{4}\b`)},
+}
+
+// PII returns a policy detecting the kinds of personally identifiable
+// information, all of them if none is given. The information is redacted
+// with its kind, e.g. "[EMAIL]".
+func PII(kinds ...PIIKind) Policy {
+	return &pii{kinds: kinds}
+}
+
+type pii struct {
+	kinds []PIIKind
+}
+
+func (p *pii) Name() string {
+	return "pii"
+}
+
+func (p *pii) Check(_ context.Context, text string) (*Finding, error) {
+	var found []string
+	redacted := text
+	for _, d := range piiDetectors {
+		if len(p.kinds) > 0 && !slices.Contains(p.kinds, d.kind) {
+			continue
+		}
+		detected := false
+		redacted = d.re.ReplaceAllStringFunc(redacted, func(m string) string {
+			if d.valid != nil && !d.valid(m) {
+				return m
+			}
+			detected = true
+			return "[" + strings.ToUpper(string(d.kind)) + "]"
+		})
+		if detected {
+			found = append(found, string(d.kind))
+		}
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+	return &Finding{
+		Reason:   "found " + strings.Join(found, ", "),
+		Redacted: redacted,
+	}, nil
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum of the credit
+// card numbers.
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guardrail_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/adk/guardrail"
+	"google.golang.org/adk/model/modeltest"
+)
+
+func TestPolicies(t *testing.T) {
+	regexpPolicy, err := guardrail.Regexp("ids", `ID-\d+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		policy guardrail.Policy
+		text   string
+		want   *guardrail.Finding
+	}{
+		{
+			name:   "RegexpMatch",
+			policy: regexpPolicy,
+			text:   "Order ID-123 and ID-456.",
+			want:   &guardrail.Finding{Reason: `denied content "ID-123"`, Redacted: "Order [REDACTED] and [REDACTED]."},
+		},
+		{
+			name:   "RegexpNoMatch",
+			policy: regexpPolicy,
+			text:   "Order 123.",
+		},
+		{
+			name:   "KeywordsMatch",
+			policy: guardrail.Keywords("banned", "secret", "password"),
+			text:   "The Secret is the password.",
+			want:   &guardrail.Finding{Reason: `denied content "Secret", "password"`, Redacted: "The [REDACTED] is the [REDACTED]."},
+		},
+		{
+			name:   "KeywordsWholeWords",
+			policy: guardrail.Keywords("banned", "secret"),
+			text:   "Secretary",
+		},
+		{
+			name:   "MaxLengthExceeded",
+			policy: guardrail.MaxLength(5),
+			text:   "héllo world",
+			want:   &guardrail.Finding{Reason: "text of 11 characters exceeds the maximum of 5", Redacted: "héllo"},
+		},
+		{
+			name:   "MaxLengthNotExceeded",
+			policy: guardrail.MaxLength(5),
+			text:   "héllo",
+		},
+		{
+			name:   "PII",
+			policy: guardrail.PII(),
+			text:   "Mail jane.doe@example.com or call +1 650-555-0100. Card 4111 1111 1111 1111, SSN 123-45-6789, IP 10.0.0.1.",
+			want: &guardrail.Finding{
+				Reason:   "found email, credit_card, ssn, ip_address, phone",
+				Redacted: "Mail [EMAIL] or call [PHONE]. Card [CREDIT_CARD], SSN [SSN], IP [IP_ADDRESS].",
+			},
+		},
+		{
+			name:   "PIIKinds",
+			policy: guardrail.PII(guardrail.PIIEmail),
+			text:   "Mail jane.doe@example.com or call 650-555-0100.",
+			want:   &guardrail.Finding{Reason: "found email", Redacted: "Mail [EMAIL] or call 650-555-0100."},
+		},
+		{
+			name:   "PIIInvalidCreditCard",
+			policy: guardrail.PII(guardrail.PIICreditCard),
+			text:   "Order 1234 5678 9012 3456.",
+		},
+		{
+			name:   "JudgeSafe",
+			policy: guardrail.Judge("judge", modeltest.New("judge", modeltest.Text("SAFE")), "No medical advice."),
+			text:   "What's the weather?",
+		},
+		{
+			name:   "JudgeUnsafe",
+			policy: guardrail.Judge("judge", modeltest.New("judge", modeltest.Text("UNSAFE: asks for a diagnosis")), "No medical advice."),
+			text:   "What's my illness?",
+			want:   &guardrail.Finding{Reason: "asks for a diagnosis"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.policy.Check(t.Context(), tc.text)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Check() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRegexp_Invalid(t *testing.T) {
+	if _, err := guardrail.Regexp("invalid", "("); err == nil {
+		t.Error("Regexp() error = nil, want error")
+	}
+}
+
+func TestJudge_Errors(t *testing.T) {
+	modelErr := errors.New("model failed")
+	for _, tc := range []struct {
+		name string
+		turn modeltest.Turn
+	}{
+		{name: "ModelError", turn: modeltest.Error(modelErr)},
+		{name: "UnexpectedAnswer", turn: modeltest.Text("Maybe")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := guardrail.Judge("judge", modeltest.New("judge", tc.turn), "No medical advice.")
+			if _, err := policy.Check(t.Context(), "text"); err == nil {
+				t.Error("Check() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import "context"
+
+// PluginCallbacks holds the agent callbacks of the runner plugins, which run
+// before the callbacks of the agents. It's instantiated with the callback
+// types of the agent package, which this package can't depend on:
+// PluginCallbacks[agent.BeforeAgentCallback, agent.AfterAgentCallback].
+type PluginCallbacks[BeforeAgentCallback, AfterAgentCallback any] struct {
+	BeforeAgent []BeforeAgentCallback
+	AfterAgent  []AfterAgentCallback
+}
+
+func PluginCallbacksToContext[B, A any](ctx context.Context, callbacks *PluginCallbacks[B, A]) context.Context {
+	return context.WithValue(ctx, pluginCallbacksCtxKey, callbacks)
+}
+
+// PluginCallbacksFromContext returns the plugin callbacks of the context, or
+// no callbacks if the context is nil or has none.
+func PluginCallbacksFromContext[B, A any](ctx context.Context) *PluginCallbacks[B, A] {
+	if ctx == nil {
+		return &PluginCallbacks[B, A]{}
+	}
+	callbacks, ok := ctx.Value(pluginCallbacksCtxKey).(*PluginCallbacks[B, A])
+	if !ok {
+		return &PluginCallbacks[B, A]{}
+	}
+	return callbacks
+}
+
+type ctxKey int
+
+const pluginCallbacksCtxKey ctxKey = 0
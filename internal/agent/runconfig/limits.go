@@ -0,0 +1,116 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runconfig
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/agent"
+)
+
+// Limits counts the LLM calls, tool calls and agent transfers of an
+// invocation against the limits of its [agent.RunConfig]. They're shared by
+// the agents of the invocation, including the agents called as tools. The
+// methods of a nil Limits never fail.
+type Limits struct {
+	maxLLMCalls       int
+	maxToolCalls      int
+	maxAgentTransfers int
+	timeout           time.Duration
+	deadline          time.Time
+
+	mu             sync.Mutex
+	llmCalls       int
+	toolCalls      int
+	agentTransfers int
+}
+
+// NewLimits returns the limits of an invocation starting now.
+func NewLimits(cfg *agent.RunConfig) *Limits {
+	l := &Limits{
+		maxLLMCalls:       cfg.MaxLLMCalls,
+		maxToolCalls:      cfg.MaxToolCalls,
+		maxAgentTransfers: cfg.MaxAgentTransfers,
+		timeout:           cfg.Timeout,
+	}
+	if cfg.Timeout > 0 {
+		l.deadline = time.Now().Add(cfg.Timeout)
+	}
+	return l
+}
+
+// Deadline returns the deadline of the invocation, if it has a timeout.
+func (l *Limits) Deadline() (time.Time, bool) {
+	if l == nil {
+		return time.Time{}, false
+	}
+	return l.deadline, !l.deadline.IsZero()
+}
+
+// AddLLMCall counts a call to a model, failing if it exceeds the limits.
+func (l *Limits) AddLLMCall() error {
+	return l.add(&l.llmCalls, l.maxLLMCalls, agent.LimitLLMCalls)
+}
+
+// AddToolCall counts a function call, failing if it exceeds the limits.
+func (l *Limits) AddToolCall() error {
+	return l.add(&l.toolCalls, l.maxToolCalls, agent.LimitToolCalls)
+}
+
+// AddAgentTransfer counts a transfer to another agent, failing if it exceeds
+// the limits.
+func (l *Limits) AddAgentTransfer() error {
+	return l.add(&l.agentTransfers, l.maxAgentTransfers, agent.LimitAgentTransfers)
+}
+
+func (l *Limits) add(count *int, maxCount int, limit agent.Limit) error {
+	if l == nil {
+		return nil
+	}
+	if err := l.checkDeadline(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if maxCount > 0 && *count >= maxCount {
+		return &agent.LimitExceededError{Limit: limit, Max: maxCount}
+	}
+	*count++
+	return nil
+}
+
+func (l *Limits) checkDeadline() *agent.LimitExceededError {
+	if !l.deadline.IsZero() && !time.Now().Before(l.deadline) {
+		return &agent.LimitExceededError{Limit: agent.LimitTimeout, Timeout: l.timeout}
+	}
+	return nil
+}
+
+// Exceeded returns the limit error of err, if any. The context errors after
+// the deadline of the invocation are timeout errors.
+func (l *Limits) Exceeded(err error) (*agent.LimitExceededError, bool) {
+	var limitErr *agent.LimitExceededError
+	if errors.As(err, &limitErr) {
+		return limitErr, true
+	}
+	if l == nil || !errors.Is(err, context.DeadlineExceeded) {
+		return nil, false
+	}
+	limitErr = l.checkDeadline()
+	return limitErr, limitErr != nil
+}
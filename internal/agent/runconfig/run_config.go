@@ -14,7 +14,14 @@
 
 package runconfig
 
-import "context"
+import (
+	"context"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/plugin"
+	"google.golang.org/adk/usage"
+)
 
 type StreamingMode string
 
@@ -26,6 +33,46 @@ const (
 
 type RunConfig struct {
 	StreamingMode StreamingMode
+	// LiveRequestQueue carries the user input in the bidi streaming mode.
+	LiveRequestQueue *agent.LiveRequestQueue
+	// Usage configures the token usage accounting. Nil disables it.
+	Usage *usage.Config
+	// CredentialService stores the credentials used by the tools.
+	CredentialService auth.CredentialService
+	// Scope is the scope of the invocation that started the run. The agents
+	// called as tools run in the scope of the invocation calling them. Nil
+	// means the scope of the session of the run.
+	Scope *Scope
+	// Plugins hook into the runs ahead of the callbacks of the agents.
+	Plugins []plugin.Plugin
+	// Limits enforces the limits of the invocation.
+	Limits *Limits
+}
+
+// Scope identifies an invocation and its user. The credentials are stored
+// under its user and the usage is recorded under it.
+type Scope struct {
+	AppName      string
+	UserID       string
+	SessionID    string
+	InvocationID string
+}
+
+// ScopeOf returns the scope of the run in the invocation ctx.
+func (c *RunConfig) ScopeOf(ctx agent.InvocationContext) Scope {
+	if c.Scope != nil {
+		return *c.Scope
+	}
+	s := ctx.Session()
+	return Scope{AppName: s.AppName(), UserID: s.UserID(), SessionID: s.ID(), InvocationID: ctx.InvocationID()}
+}
+
+// ReadonlyScopeOf returns the scope of the run in the readonly context ctx.
+func (c *RunConfig) ReadonlyScopeOf(ctx agent.ReadonlyContext) Scope {
+	if c.Scope != nil {
+		return *c.Scope
+	}
+	return Scope{AppName: ctx.AppName(), UserID: ctx.UserID(), SessionID: ctx.SessionID(), InvocationID: ctx.InvocationID()}
 }
 
 func ToContext(ctx context.Context, cfg *RunConfig) context.Context {
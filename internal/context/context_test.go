@@ -15,11 +15,24 @@
 package context
 
 import (
+	"strings"
 	"testing"
 
 	"google.golang.org/adk/agent"
 )
 
+func TestInvocationContext_InvocationID(t *testing.T) {
+	inv := NewInvocationContext(t.Context(), InvocationContextParams{InvocationID: "e-parent"})
+	if got := inv.InvocationID(); got != "e-parent" {
+		t.Errorf("InvocationID() = %q, want %q", got, "e-parent")
+	}
+
+	inv = NewInvocationContext(t.Context(), InvocationContextParams{})
+	if got := inv.InvocationID(); !strings.HasPrefix(got, "e-") || got == "e-" {
+		t.Errorf("InvocationID() = %q, want a generated ID", got)
+	}
+}
+
 func TestReadonlyContext(t *testing.T) {
 	inv := NewInvocationContext(t.Context(), InvocationContextParams{})
 	readonly := NewReadonlyContext(inv)
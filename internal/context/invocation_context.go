@@ -16,6 +16,7 @@ package context
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"google.golang.org/genai"
@@ -32,16 +33,24 @@ type InvocationContextParams struct {
 	Branch string
 	Agent  agent.Agent
 
+	// InvocationID is the ID of the invocation. A new ID is generated if
+	// it's empty.
+	InvocationID string
+
 	UserContent   *genai.Content
 	RunConfig     *agent.RunConfig
 	EndInvocation bool
 }
 
 func NewInvocationContext(ctx context.Context, params InvocationContextParams) agent.InvocationContext {
+	invocationID := params.InvocationID
+	if invocationID == "" {
+		invocationID = "e-" + uuid.NewString()
+	}
 	return &InvocationContext{
 		Context:      ctx,
 		params:       params,
-		invocationID: "e-" + uuid.NewString(),
+		invocationID: invocationID,
 	}
 }
 
@@ -91,3 +100,45 @@ func (c *InvocationContext) EndInvocation() {
 func (c *InvocationContext) Ended() bool {
 	return c.params.EndInvocation
 }
+
+// WithContext returns the invocation context with the deadline, cancellation
+// and values of c, which must be derived from ctx.
+func WithContext(ctx agent.InvocationContext, c context.Context) agent.InvocationContext {
+	return &invocationContextWithContext{InvocationContext: ctx, ctx: c}
+}
+
+type invocationContextWithContext struct {
+	agent.InvocationContext
+	ctx context.Context
+}
+
+func (c *invocationContextWithContext) Deadline() (time.Time, bool) {
+	return c.ctx.Deadline()
+}
+
+func (c *invocationContextWithContext) Done() <-chan struct{} {
+	return c.ctx.Done()
+}
+
+func (c *invocationContextWithContext) Err() error {
+	return c.ctx.Err()
+}
+
+func (c *invocationContextWithContext) Value(key any) any {
+	return c.ctx.Value(key)
+}
+
+// WithUserContent returns the invocation context with the content of the
+// user replaced.
+func WithUserContent(ctx agent.InvocationContext, content *genai.Content) agent.InvocationContext {
+	return &invocationContextWithUserContent{InvocationContext: ctx, userContent: content}
+}
+
+type invocationContextWithUserContent struct {
+	agent.InvocationContext
+	userContent *genai.Content
+}
+
+func (c *invocationContextWithUserContent) UserContent() *genai.Content {
+	return c.userContent
+}
@@ -18,7 +18,10 @@ import (
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/codeexecutor"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/planner"
+	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 )
 
@@ -34,6 +37,7 @@ type State struct {
 	Toolsets []tool.Toolset
 
 	IncludeContents string
+	ContentsBuilder ContentsBuilder
 
 	GenerateContentConfig *genai.GenerateContentConfig
 
@@ -49,10 +53,16 @@ type State struct {
 	OutputSchema *genai.Schema
 
 	OutputKey string
+
+	Planner planner.Planner
+
+	CodeExecutor codeexecutor.CodeExecutor
 }
 
 type InstructionProvider func(ctx agent.ReadonlyContext) (string, error)
 
+type ContentsBuilder func(ctx agent.InvocationContext, events []*session.Event) ([]*session.Event, error)
+
 func (s *State) internal() *State { return s }
 
 func Reveal(a Agent) *State { return a.internal() }
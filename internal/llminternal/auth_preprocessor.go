@@ -0,0 +1,154 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/internal/agent/runconfig"
+	"google.golang.org/adk/internal/converters"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+// authToolArguments are the arguments of a credential request function call.
+type authToolArguments struct {
+	// FunctionCallID is the ID of the function call which requested the
+	// credential.
+	FunctionCallID string `json:"functionCallId"`
+	// AuthConfig is the config of the requested credential.
+	AuthConfig *auth.Config `json:"authConfig"`
+}
+
+// generateAuthEvent returns the event requesting the client for the
+// credentials requested by the tools in the function response event, or nil
+// if there are none. The credential requests are long running function calls
+// to "adk_request_credential", so the agent run pauses until the client
+// provides the credentials in their function responses.
+//
+// reference: adk-python src/google/adk/flows/llm_flows/functions.py generate_auth_event
+func generateAuthEvent(ctx agent.InvocationContext, fnResponseEvent *session.Event) (*session.Event, error) {
+	configs := fnResponseEvent.Actions.RequestedAuthConfigs
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	var parts []*genai.Part
+	for _, id := range slices.Sorted(maps.Keys(configs)) {
+		req, err := auth.GenerateAuthRequest(configs[id])
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate the credential request of function call %q: %w", id, err)
+		}
+		args, err := converters.ToMapStructure(authToolArguments{FunctionCallID: id, AuthConfig: req})
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert the credential request of function call %q: %w", id, err)
+		}
+		parts = append(parts, genai.NewPartFromFunctionCall(requestEUCFunctionCallName, args))
+	}
+	content := &genai.Content{Role: fnResponseEvent.Content.Role, Parts: parts}
+	utils.PopulateClientFunctionCallID(content)
+
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	ev.LLMResponse = model.LLMResponse{Content: content}
+	for _, fc := range utils.FunctionCalls(content) {
+		ev.LongRunningToolIDs = append(ev.LongRunningToolIDs, fc.ID)
+	}
+	return ev, nil
+}
+
+// resumeAuthorizedCalls stores the credentials provided by the client in the
+// last event of the session, in response to credential requests, and calls
+// the tools which requested them again. It returns the function response
+// event, or nil if the last event doesn't respond to credential requests.
+//
+// reference: adk-python src/google/adk/auth/auth_preprocessor.py
+func (f *Flow) resumeAuthorizedCalls(ctx agent.InvocationContext, tools map[string]tool.Tool) (*session.Event, error) {
+	events := ctx.Session().Events()
+	if events.Len() == 0 {
+		return nil, nil
+	}
+	last := events.At(events.Len() - 1)
+	if last.Author != "user" {
+		return nil, nil
+	}
+	responded := make(map[string]bool)
+	for _, fr := range utils.FunctionResponses(last.Content) {
+		if fr.Name != requestEUCFunctionCallName {
+			continue
+		}
+		cfg, err := converters.FromMapStructure[auth.Config](fr.Response)
+		if err != nil {
+			return nil, fmt.Errorf("invalid credential response %q: %w", fr.ID, err)
+		}
+		if err := storeAuthResponse(ctx, cfg); err != nil {
+			return nil, err
+		}
+		responded[fr.ID] = true
+	}
+	if len(responded) == 0 {
+		return nil, nil
+	}
+
+	// Find the function calls which requested the credentials.
+	toResume := make(map[string]bool)
+	for i := events.Len() - 2; i >= 0 && len(responded) > 0; i-- {
+		for _, fc := range utils.FunctionCalls(events.At(i).Content) {
+			if fc.Name != requestEUCFunctionCallName || !responded[fc.ID] {
+				continue
+			}
+			delete(responded, fc.ID)
+			args, err := converters.FromMapStructure[authToolArguments](fc.Args)
+			if err != nil {
+				return nil, fmt.Errorf("invalid credential request %q: %w", fc.ID, err)
+			}
+			toResume[args.FunctionCallID] = true
+		}
+	}
+	var calls []*genai.Part
+	for i := events.Len() - 2; i >= 0 && len(toResume) > 0; i-- {
+		for _, fc := range utils.FunctionCalls(events.At(i).Content) {
+			if toResume[fc.ID] {
+				delete(toResume, fc.ID)
+				calls = append(calls, &genai.Part{FunctionCall: fc})
+			}
+		}
+	}
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	return f.handleFunctionCalls(ctx, tools, &model.LLMResponse{Content: genai.NewContentFromParts(calls, genai.RoleModel)}, nil)
+}
+
+func storeAuthResponse(ctx agent.InvocationContext, cfg *auth.Config) error {
+	runCfg := runconfig.FromContext(ctx)
+	if runCfg == nil || runCfg.CredentialService == nil {
+		return errors.New("credential service is not configured")
+	}
+	scope := runCfg.ScopeOf(ctx)
+	if err := auth.StoreAuthResponse(ctx, runCfg.CredentialService, scope.AppName, scope.UserID, cfg); err != nil {
+		return fmt.Errorf("failed to store credential: %w", err)
+	}
+	return nil
+}
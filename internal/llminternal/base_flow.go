@@ -15,15 +15,21 @@
 package llminternal
 
 import (
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
 	"iter"
 	"maps"
 	"slices"
+	"time"
 
+	"github.com/google/jsonschema-go/jsonschema"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/internal/agent/parentmap"
 	"google.golang.org/adk/internal/agent/runconfig"
 	icontext "google.golang.org/adk/internal/context"
@@ -31,8 +37,10 @@ import (
 	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/plugin"
 	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/toolconfirmation"
 )
 
 var ErrModelNotConfigured = errors.New("model not configured; ensure Model is set in llmagent.Config")
@@ -54,12 +62,40 @@ type Flow struct {
 	AfterModelCallbacks  []AfterModelCallback
 	BeforeToolCallbacks  []BeforeToolCallback
 	AfterToolCallbacks   []AfterToolCallback
+
+	// MaxContinuations is the maximum number of times the model is asked to
+	// continue a response cut off by the output token limit.
+	MaxContinuations int
+	// MaxOutputRepairs is the maximum number of times the model is asked to
+	// fix a response that doesn't match the output schema of the agent.
+	MaxOutputRepairs int
+	// MaxConcurrentToolCalls is the maximum number of function calls of a
+	// model response that run concurrently. Zero runs them sequentially and
+	// a negative value means no limit.
+	MaxConcurrentToolCalls int
+	// ToolTimeout is the default timeout of the tool calls, which don't have
+	// their own timeout. It doesn't apply to long-running tools. Zero means
+	// no timeout. The tools must return once their context is done: the
+	// result and actions of a call returning after its deadline are
+	// discarded.
+	ToolTimeout time.Duration
+	// MaxToolErrorRetries is the maximum number of times the model is asked
+	// to correct function calls of unknown tools or with arguments not
+	// matching the parameters schema of the tool. Zero means the calls of
+	// unknown tools fail the run.
+	MaxToolErrorRetries int
+
+	// ToolConfirmationPolicy, if set, decides which tool calls must be
+	// confirmed by the user, in addition to the tools requiring it.
+	ToolConfirmationPolicy tool.ConfirmationPolicy
+
+	// toolErrorRetries counts the function calls corrected by the model.
+	toolErrorRetries int
 }
 
 var (
 	DefaultRequestProcessors = []func(ctx agent.InvocationContext, req *model.LLMRequest) error{
 		basicRequestProcessor,
-		authPreprocessor,
 		instructionsRequestProcessor,
 		identityRequestProcessor,
 		ContentsRequestProcessor,
@@ -97,9 +133,9 @@ func (f *Flow) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error]
 				return
 			}
 			if lastEvent.LLMResponse.Partial {
-				// We may have reached max token limit during streaming mode.
-				// TODO: handle Partial response in model level. CL 781377328
-				yield(nil, fmt.Errorf("TODO: last event is not final"))
+				// The model stream ended without a complete response; calling the
+				// model again with the same history wouldn't make progress.
+				yield(nil, fmt.Errorf("agent %q: model response stream ended with a partial response", ctx.Agent().Name()))
 				return
 			}
 		}
@@ -125,11 +161,45 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 		if ctx.Ended() {
 			return
 		}
+
+		// Call again the tools which requested credentials or confirmations,
+		// if the client provided them. The model is called in the next step,
+		// with the function responses.
+		tools, err := requestTools(req)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		ev, err := f.resumeAuthorizedCalls(ctx, tools)
+		if err == nil && ev == nil {
+			ev, err = f.resumeConfirmedCalls(ctx, tools)
+		}
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if ev != nil {
+			if !yield(ev, nil) {
+				return
+			}
+			requestEvents, err := generateClientRequestEvents(ctx, ev)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, ev := range requestEvents {
+				if !yield(ev, nil) {
+					return
+				}
+			}
+			return
+		}
+
 		spans := telemetry.StartTrace(ctx, "call_llm")
 		// Create event to pass to callback state delta
 		stateDelta := make(map[string]any)
 		// Calls the LLM.
-		for resp, err := range f.callLLM(ctx, req, stateDelta) {
+		for resp, err := range f.callLLMWithValidation(ctx, req, stateDelta) {
 			if err != nil {
 				yield(nil, err)
 				return
@@ -145,29 +215,29 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 				continue
 			}
 
-			// TODO: temporarily convert
-			tools := make(map[string]tool.Tool)
-			for k, v := range req.Tools {
-				tool, ok := v.(tool.Tool)
-				if !ok {
-					if !yield(nil, fmt.Errorf("unexpected tool type %T for tool %v", v, k)) {
-						return
-					}
-				}
-				tools[k] = tool
-			}
-
 			// Build the event and yield.
 			modelResponseEvent := f.finalizeModelResponseEvent(ctx, resp, tools, stateDelta)
 			telemetry.TraceLLMCall(spans, ctx, req, modelResponseEvent)
 			if !yield(modelResponseEvent, nil) {
 				return
 			}
-			// TODO: generate and yield an auth event if needed.
+			// Run the code written by the model, if any. The model is called
+			// again with the result.
+			ev, err := f.handleCodeExecution(ctx, resp)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if ev != nil {
+				if !yield(ev, nil) {
+					return
+				}
+				continue
+			}
 
 			// Handle function calls.
 
-			ev, err := f.handleFunctionCalls(ctx, tools, resp)
+			ev, err = f.handleFunctionCalls(ctx, tools, resp, nil)
 			if err != nil {
 				yield(nil, err)
 				return
@@ -180,6 +250,22 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 				return
 			}
 
+			// Pause the run if tools requested credentials or confirmations
+			// from the client.
+			requestEvents, err := generateClientRequestEvents(ctx, ev)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if len(requestEvents) > 0 {
+				for _, ev := range requestEvents {
+					if !yield(ev, nil) {
+						return
+					}
+				}
+				return
+			}
+
 			// Actually handle "transfer_to_agent" tool. The function call sets the ev.Actions.TransferToAgent field.
 			// We are following python's execution flow which is
 			//   BaseLlmFlow._postprocess_async
@@ -193,6 +279,10 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 				yield(nil, fmt.Errorf("failed to find agent: %s", ev.Actions.TransferToAgent))
 				return
 			}
+			if err := limits(ctx).AddAgentTransfer(); err != nil {
+				yield(nil, err)
+				return
+			}
 			for ev, err := range nextAgent.Run(ctx) {
 				if !yield(ev, err) || err != nil { // forward
 					return
@@ -202,6 +292,20 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 	}
 }
 
+// requestTools returns the tools of the request by name.
+func requestTools(req *model.LLMRequest) (map[string]tool.Tool, error) {
+	// TODO: temporarily convert
+	tools := make(map[string]tool.Tool)
+	for k, v := range req.Tools {
+		tool, ok := v.(tool.Tool)
+		if !ok {
+			return nil, fmt.Errorf("unexpected tool type %T for tool %v", v, k)
+		}
+		tools[k] = tool
+	}
+	return tools, nil
+}
+
 func (f *Flow) preprocess(ctx agent.InvocationContext, req *model.LLMRequest) error {
 	llmAgent, ok := ctx.Agent().(Agent)
 	if !ok {
@@ -249,7 +353,7 @@ func toolPreprocess(ctx agent.InvocationContext, req *model.LLMRequest, tools []
 
 func (f *Flow) callLLM(ctx agent.InvocationContext, req *model.LLMRequest, stateDelta map[string]any) iter.Seq2[*model.LLMResponse, error] {
 	return func(yield func(*model.LLMResponse, error) bool) {
-		for _, callback := range f.BeforeModelCallbacks {
+		for _, callback := range f.beforeModelCallbacks(ctx) {
 			cctx := icontext.NewCallbackContextWithDelta(ctx, stateDelta)
 			callbackResponse, callbackErr := callback(cctx, req)
 
@@ -259,13 +363,21 @@ func (f *Flow) callLLM(ctx agent.InvocationContext, req *model.LLMRequest, state
 			}
 		}
 
+		if err := checkBudget(ctx); err != nil {
+			yield(nil, err)
+			return
+		}
+		if err := limits(ctx).AddLLMCall(); err != nil {
+			yield(nil, err)
+			return
+		}
+
 		// TODO: Set _ADK_AGENT_NAME_LABEL_KEY in req.GenerateConfig.Labels
 		// to help with slicing the billing reports on a per-agent basis.
 
-		// TODO: RunLive mode when invocation_context.run_config.support_cfc is true.
 		useStream := runconfig.FromContext(ctx).StreamingMode == runconfig.StreamingModeSSE
 
-		for resp, err := range f.Model.GenerateContent(ctx, req, useStream) {
+		for resp, err := range f.generateContent(ctx, req, useStream) {
 			callbackResp, callbackErr := f.runAfterModelCallbacks(ctx, resp, stateDelta, err)
 			// TODO: check if we should stop iterator on the first error from stream or continue yielding next results.
 			if callbackErr != nil {
@@ -294,7 +406,7 @@ func (f *Flow) callLLM(ctx agent.InvocationContext, req *model.LLMRequest, state
 }
 
 func (f *Flow) runAfterModelCallbacks(ctx agent.InvocationContext, llmResp *model.LLMResponse, stateDelta map[string]any, llmErr error) (*model.LLMResponse, error) {
-	for _, callback := range f.AfterModelCallbacks {
+	for _, callback := range f.afterModelCallbacks(ctx) {
 		cctx := icontext.NewCallbackContextWithDelta(ctx, stateDelta)
 		callbackResponse, callbackErr := callback(cctx, llmResp, llmErr)
 
@@ -306,6 +418,62 @@ func (f *Flow) runAfterModelCallbacks(ctx agent.InvocationContext, llmResp *mode
 	return nil, nil
 }
 
+// plugins returns the plugins of the runner, whose callbacks run ahead of the
+// callbacks of the agent.
+func plugins(ctx context.Context) []plugin.Plugin {
+	if ctx == nil {
+		return nil
+	}
+	if cfg := runconfig.FromContext(ctx); cfg != nil {
+		return cfg.Plugins
+	}
+	return nil
+}
+
+// limits returns the limits of the invocation, nil if it isn't run by a
+// runner.
+func limits(ctx context.Context) *runconfig.Limits {
+	if ctx == nil {
+		return nil
+	}
+	if cfg := runconfig.FromContext(ctx); cfg != nil {
+		return cfg.Limits
+	}
+	return nil
+}
+
+func (f *Flow) beforeModelCallbacks(ctx context.Context) []BeforeModelCallback {
+	var callbacks []BeforeModelCallback
+	for _, p := range plugins(ctx) {
+		callbacks = append(callbacks, p.BeforeModel)
+	}
+	return append(callbacks, f.BeforeModelCallbacks...)
+}
+
+func (f *Flow) afterModelCallbacks(ctx context.Context) []AfterModelCallback {
+	var callbacks []AfterModelCallback
+	for _, p := range plugins(ctx) {
+		callbacks = append(callbacks, p.AfterModel)
+	}
+	return append(callbacks, f.AfterModelCallbacks...)
+}
+
+func (f *Flow) beforeToolCallbacks(ctx context.Context) []BeforeToolCallback {
+	var callbacks []BeforeToolCallback
+	for _, p := range plugins(ctx) {
+		callbacks = append(callbacks, p.BeforeTool)
+	}
+	return append(callbacks, f.BeforeToolCallbacks...)
+}
+
+func (f *Flow) afterToolCallbacks(ctx context.Context) []AfterToolCallback {
+	var callbacks []AfterToolCallback
+	for _, p := range plugins(ctx) {
+		callbacks = append(callbacks, p.AfterTool)
+	}
+	return append(callbacks, f.AfterToolCallbacks...)
+}
+
 func (f *Flow) postprocess(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error {
 	// apply response processor functions to the response in the configured order.
 	for _, processor := range f.ResponseProcessors {
@@ -365,60 +533,211 @@ func findLongRunningFunctionCallIDs(c *genai.Content, tools map[string]tool.Tool
 
 // handleFunctionCalls calls the functions and returns the function response event.
 //
+// The calls of the response run sequentially, or concurrently up to
+// f.MaxConcurrentToolCalls at a time. The calls requiring confirmation only run once confirmed, with
+// their confirmation in confirmations, by function call ID.
+//
 // TODO: accept filters to include/exclude function calls.
-// TODO: check feasibility of running tool.Run concurrently.
-func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[string]tool.Tool, resp *model.LLMResponse) (*session.Event, error) {
-	var fnResponseEvents []*session.Event
-
+func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[string]tool.Tool, resp *model.LLMResponse, confirmations map[string]*toolconfirmation.Confirmation) (*session.Event, error) {
 	fnCalls := utils.FunctionCalls(resp.Content)
-	for _, fnCall := range fnCalls {
+	// Resolve all the tools first, so that none of them runs if the model
+	// called a tool that doesn't exist and can't correct the call.
+	funcTools := make([]toolinternal.FunctionTool, len(fnCalls))
+	// The responses of the calls which don't run, e.g. the calls the model
+	// must correct, are set beforehand. Each response is stored at the index
+	// of its call to keep the order of the merged event deterministic.
+	fnResponseEvents := make([]*session.Event, len(fnCalls))
+	var callErr error
+	for i, fnCall := range fnCalls {
 		curTool, ok := toolsDict[fnCall.Name]
 		if !ok {
-			return nil, fmt.Errorf("unknown tool: %q", fnCall.Name)
+			callErr = cmp.Or(callErr, fmt.Errorf("unknown tool: %q", fnCall.Name))
+			fnResponseEvents[i] = newFunctionResponseEvent(ctx, fnCall, map[string]any{
+				"error":          fmt.Sprintf("tool %q not found", fnCall.Name),
+				"availableTools": slices.Sorted(maps.Keys(toolsDict)),
+			}, &session.EventActions{})
+			continue
 		}
 		funcTool, ok := curTool.(toolinternal.FunctionTool)
 		if !ok {
 			return nil, fmt.Errorf("tool %q is not a function tool", curTool.Name())
 		}
-		toolCtx := toolinternal.NewToolContext(ctx, fnCall.ID, &session.EventActions{StateDelta: make(map[string]any)})
-		// toolCtx := tool.
-		spans := telemetry.StartTrace(ctx, "execute_tool "+fnCall.Name)
-
-		result := f.callTool(funcTool, fnCall.Args, toolCtx)
-
-		// TODO: agent.canonical_after_tool_callbacks
-		// TODO: handle long-running tool.
-		ev := session.NewEvent(ctx.InvocationID())
-		ev.LLMResponse = model.LLMResponse{
-			Content: &genai.Content{
-				Role: "user",
-				Parts: []*genai.Part{
-					{
-						FunctionResponse: &genai.FunctionResponse{
-							ID:       fnCall.ID,
-							Name:     fnCall.Name,
-							Response: result,
-						},
-					},
-				},
-			},
+		funcTools[i] = funcTool
+		if f.MaxToolErrorRetries > 0 {
+			if err := validateArguments(funcTool, fnCall.Args); err != nil {
+				err = fmt.Errorf("invalid arguments for tool %q: %w", fnCall.Name, err)
+				callErr = cmp.Or(callErr, err)
+				fnResponseEvents[i] = newFunctionResponseEvent(ctx, fnCall, map[string]any{"error": err.Error()}, &session.EventActions{})
+				continue
+			}
+		}
+		if c, ok := confirmations[fnCall.ID]; ok {
+			switch {
+			case !c.Confirmed:
+				fnResponseEvents[i] = newFunctionResponseEvent(ctx, fnCall, map[string]any{"error": "This tool call is rejected."}, &session.EventActions{})
+			case c.Args != nil:
+				// The arguments edited by the user are validated like
+				// those of the model, but the error is only reported to
+				// the model in the function response.
+				if err := validateArguments(funcTool, c.Args); err != nil {
+					err = fmt.Errorf("invalid arguments for tool %q: %w", fnCall.Name, err)
+					fnResponseEvents[i] = newFunctionResponseEvent(ctx, fnCall, map[string]any{"error": err.Error()}, &session.EventActions{})
+					continue
+				}
+				fnCalls[i] = &genai.FunctionCall{ID: fnCall.ID, Name: fnCall.Name, Args: c.Args}
+			}
+		} else if f.requireConfirmation(ctx, funcTool, fnCall.Args) {
+			fnResponseEvents[i] = newFunctionResponseEvent(ctx, fnCall, map[string]any{
+				"error": "This tool call requires confirmation, please approve or reject.",
+			}, &session.EventActions{
+				RequestedToolConfirmations: map[string]*toolconfirmation.Confirmation{fnCall.ID: {
+					Hint: fmt.Sprintf("Please approve or reject the call of the tool %q.", fnCall.Name),
+					Args: fnCall.Args,
+				}},
+			})
 		}
-		ev.Author = ctx.Agent().Name()
-		ev.Branch = ctx.Branch()
-		ev.Actions = *toolCtx.Actions()
-		telemetry.TraceToolCall(spans, curTool, fnCall.Args, ev)
-		fnResponseEvents = append(fnResponseEvents, ev)
 	}
-	mergedEvent, err := mergeParallelFunctionResponseEvents(fnResponseEvents)
-	if err != nil {
-		return mergedEvent, err
+	if callErr != nil {
+		if f.toolErrorRetries >= f.MaxToolErrorRetries {
+			return nil, callErr
+		}
+		f.toolErrorRetries++
+	}
+	for i := range fnCalls {
+		if fnResponseEvents[i] != nil {
+			continue
+		}
+		if err := limits(ctx).AddToolCall(); err != nil {
+			return nil, err
+		}
 	}
+
+	// The other calls run concurrently, if enabled.
+	var g errgroup.Group
+	if f.MaxConcurrentToolCalls >= 0 {
+		g.SetLimit(max(f.MaxConcurrentToolCalls, 1))
+	}
+	for i, fnCall := range fnCalls {
+		if fnResponseEvents[i] != nil {
+			continue
+		}
+		g.Go(func() error {
+			fnResponseEvents[i] = f.runFunctionCall(ctx, funcTools[i], fnCall)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	mergedEvent := mergeParallelFunctionResponseEvents(fnResponseEvents)
 	// this is needed for debug traces of parallel calls
 	spans := telemetry.StartTrace(ctx, "execute_tool (merged)")
 	telemetry.TraceMergedToolCalls(spans, mergedEvent)
 	return mergedEvent, nil
 }
 
+func (f *Flow) runFunctionCall(ctx agent.InvocationContext, funcTool toolinternal.FunctionTool, fnCall *genai.FunctionCall) *session.Event {
+	timeout := f.toolTimeout(funcTool)
+	if timeout > 0 {
+		timeoutErr := fmt.Errorf("tool %q timed out after %v", funcTool.Name(), timeout)
+		timeoutCtx, cancel := context.WithTimeoutCause(ctx, timeout, timeoutErr)
+		defer cancel()
+		ctx = icontext.WithContext(ctx, timeoutCtx)
+	}
+	spans := telemetry.StartTrace(ctx, "execute_tool "+fnCall.Name)
+
+	actions := &session.EventActions{StateDelta: make(map[string]any)}
+	var result map[string]any
+	if timeout <= 0 {
+		result = f.callTool(funcTool, fnCall.Args, toolinternal.NewToolContext(ctx, fnCall.ID, actions))
+	} else {
+		// The tool runs with its own actions, which are only used if it
+		// returns before the deadline. A tool ignoring the cancellation may
+		// still be running after it, and its late writes must not reach
+		// the event.
+		toolActions := &session.EventActions{StateDelta: make(map[string]any)}
+		done := make(chan map[string]any, 1)
+		go func() {
+			done <- f.callTool(funcTool, fnCall.Args, toolinternal.NewToolContext(ctx, fnCall.ID, toolActions))
+		}()
+		select {
+		case result = <-done:
+			if ctx.Err() == nil {
+				actions = toolActions
+			} else {
+				// The tool returned after the deadline, most likely the
+				// cancellation error of its context.
+				result = map[string]any{"error": context.Cause(ctx).Error()}
+			}
+		case <-ctx.Done():
+			result = map[string]any{"error": context.Cause(ctx).Error()}
+		}
+	}
+
+	// TODO: agent.canonical_after_tool_callbacks
+	// TODO: handle long-running tool.
+	ev := newFunctionResponseEvent(ctx, fnCall, result, actions)
+	telemetry.TraceToolCall(spans, funcTool, fnCall.Args, ev)
+	return ev
+}
+
+func newFunctionResponseEvent(ctx agent.InvocationContext, fnCall *genai.FunctionCall, result map[string]any, actions *session.EventActions) *session.Event {
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.LLMResponse = model.LLMResponse{
+		Content: &genai.Content{
+			Role: "user",
+			Parts: []*genai.Part{
+				{
+					FunctionResponse: &genai.FunctionResponse{
+						ID:       fnCall.ID,
+						Name:     fnCall.Name,
+						Response: result,
+					},
+				},
+			},
+		},
+	}
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	ev.Actions = *actions
+	return ev
+}
+
+// validateArguments validates the arguments of the function call against the
+// JSON schema of the tool parameters, if any.
+func validateArguments(t toolinternal.FunctionTool, args map[string]any) error {
+	decl := t.Declaration()
+	if decl == nil {
+		return nil
+	}
+	schema, ok := decl.ParametersJsonSchema.(*jsonschema.Schema)
+	if !ok || schema == nil {
+		return nil
+	}
+	resolved, err := schema.Resolve(nil)
+	if err != nil {
+		// The tool can't be called with any arguments, it's not for the
+		// model to correct.
+		return nil
+	}
+	if args == nil {
+		args = map[string]any{}
+	}
+	return resolved.Validate(args)
+}
+
+// toolTimeout returns the timeout of the tool calls, which is the own timeout
+// of the tool if it has one, or f.ToolTimeout for the other tools except the
+// long-running ones.
+func (f *Flow) toolTimeout(t toolinternal.FunctionTool) time.Duration {
+	if tt, ok := t.(toolinternal.TimeoutTool); ok && tt.Timeout() > 0 {
+		return tt.Timeout()
+	}
+	if t.IsLongRunning() {
+		return 0
+	}
+	return f.ToolTimeout
+}
+
 func (f *Flow) callTool(tool toolinternal.FunctionTool, fArgs map[string]any, toolCtx tool.Context) map[string]any {
 	result, err := f.invokeBeforeToolCallbacks(tool, fArgs, toolCtx)
 	if result == nil && err == nil {
@@ -432,7 +751,7 @@ func (f *Flow) callTool(tool toolinternal.FunctionTool, fArgs map[string]any, to
 }
 
 func (f *Flow) invokeBeforeToolCallbacks(tool toolinternal.FunctionTool, fArgs map[string]any, toolCtx tool.Context) (map[string]any, error) {
-	for _, callback := range f.BeforeToolCallbacks {
+	for _, callback := range f.beforeToolCallbacks(toolCtx) {
 		result, err := callback(toolCtx, tool, fArgs)
 		if err != nil {
 			return nil, err
@@ -447,7 +766,7 @@ func (f *Flow) invokeBeforeToolCallbacks(tool toolinternal.FunctionTool, fArgs m
 }
 
 func (f *Flow) invokeAfterToolCallbacks(tool toolinternal.FunctionTool, fArgs map[string]any, toolCtx tool.Context, fResult map[string]any, fErr error) (map[string]any, error) {
-	for _, callback := range f.AfterToolCallbacks {
+	for _, callback := range f.afterToolCallbacks(toolCtx) {
 		result, err := callback(toolCtx, tool, fArgs, fResult, fErr)
 		if err != nil {
 			return nil, err
@@ -462,12 +781,12 @@ func (f *Flow) invokeAfterToolCallbacks(tool toolinternal.FunctionTool, fArgs ma
 	return fResult, fErr
 }
 
-func mergeParallelFunctionResponseEvents(events []*session.Event) (*session.Event, error) {
+func mergeParallelFunctionResponseEvents(events []*session.Event) *session.Event {
 	switch len(events) {
 	case 0:
-		return nil, nil
+		return nil
 	case 1:
-		return events[0], nil
+		return events[0]
 	}
 	var parts []*genai.Part
 	var actions *session.EventActions
@@ -487,15 +806,16 @@ func mergeParallelFunctionResponseEvents(events []*session.Event) (*session.Even
 		},
 	}
 	ev.Actions = *actions
-	return ev, nil
+	return ev
 }
 
+// mergeEventActions merges the actions of parallel function calls into base.
+//
+// As in flows/llm_flows/functions.py merge_parallel_function_response_events,
+// the calls are merged in the order of the function calls, regardless of the
+// order they ran in, and the later calls win: the state deltas are merged key
+// by key, and the last transfer is kept.
 func mergeEventActions(base, other *session.EventActions) *session.EventActions {
-	// flows/llm_flows/functions.py merge_parallel_function_response_events
-	//
-	// TODO: merge_parallel_function_response_events creates a "last one wins" scenario
-	// except parts and requested_auth_configs. Check with the ADK team about
-	// the intention.
 	if other == nil {
 		return base
 	}
@@ -511,8 +831,30 @@ func mergeEventActions(base, other *session.EventActions) *session.EventActions
 	if other.Escalate {
 		base.Escalate = true
 	}
-	if other.StateDelta != nil {
-		base.StateDelta = other.StateDelta
+	for k, v := range other.StateDelta {
+		if base.StateDelta == nil {
+			base.StateDelta = make(map[string]any)
+		}
+		base.StateDelta[k] = v
+	}
+	for k, v := range other.ArtifactDelta {
+		if base.ArtifactDelta == nil {
+			base.ArtifactDelta = make(map[string]int64)
+		}
+		// Artifact versions only increase, the latest one is kept.
+		base.ArtifactDelta[k] = max(base.ArtifactDelta[k], v)
+	}
+	if other.RequestedAuthConfigs != nil {
+		if base.RequestedAuthConfigs == nil {
+			base.RequestedAuthConfigs = make(map[string]*auth.Config)
+		}
+		maps.Copy(base.RequestedAuthConfigs, other.RequestedAuthConfigs)
+	}
+	if other.RequestedToolConfirmations != nil {
+		if base.RequestedToolConfirmations == nil {
+			base.RequestedToolConfirmations = make(map[string]*toolconfirmation.Confirmation)
+		}
+		maps.Copy(base.RequestedToolConfirmations, other.RequestedToolConfirmations)
 	}
 	return base
 }
@@ -23,6 +23,7 @@ import (
 
 	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 )
 
@@ -273,3 +274,51 @@ func TestCallTool(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeEventActions(t *testing.T) {
+	tests := []struct {
+		name  string
+		base  *session.EventActions
+		other *session.EventActions
+		want  *session.EventActions
+	}{
+		{
+			name:  "merges state deltas",
+			base:  &session.EventActions{StateDelta: map[string]any{"a": 1, "b": 2}},
+			other: &session.EventActions{StateDelta: map[string]any{"b": 2, "c": 3}},
+			want:  &session.EventActions{StateDelta: map[string]any{"a": 1, "b": 2, "c": 3}},
+		},
+		{
+			name:  "conflicting state deltas",
+			base:  &session.EventActions{StateDelta: map[string]any{"a": 1, "b": 1}},
+			other: &session.EventActions{StateDelta: map[string]any{"a": 2}},
+			want:  &session.EventActions{StateDelta: map[string]any{"a": 2, "b": 1}},
+		},
+		{
+			name:  "keeps latest artifact versions",
+			base:  &session.EventActions{ArtifactDelta: map[string]int64{"a.txt": 2, "b.txt": 0}},
+			other: &session.EventActions{ArtifactDelta: map[string]int64{"a.txt": 1, "c.txt": 0}},
+			want:  &session.EventActions{ArtifactDelta: map[string]int64{"a.txt": 2, "b.txt": 0, "c.txt": 0}},
+		},
+		{
+			name:  "transfers to different agents",
+			base:  &session.EventActions{TransferToAgent: "a"},
+			other: &session.EventActions{TransferToAgent: "b"},
+			want:  &session.EventActions{TransferToAgent: "b"},
+		},
+		{
+			name:  "merges flags",
+			base:  &session.EventActions{SkipSummarization: true},
+			other: &session.EventActions{Escalate: true, TransferToAgent: "a"},
+			want:  &session.EventActions{SkipSummarization: true, Escalate: true, TransferToAgent: "a"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := mergeEventActions(tc.base, tc.other)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("mergeEventActions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -41,8 +41,15 @@ func basicRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) e
 		req.Config.ResponseSchema = llmAgent.internal().OutputSchema
 		req.Config.ResponseMIMEType = "application/json"
 	}
-	// TODO: missing features
-	//  populate LLMRequest LiveConnectConfig setting
+	if cfg := ctx.RunConfig(); cfg != nil && cfg.StreamingMode == agent.StreamingModeBidi {
+		req.LiveConnectConfig = &genai.LiveConnectConfig{
+			ResponseModalities:       cfg.ResponseModalities,
+			SpeechConfig:             cfg.SpeechConfig,
+			InputAudioTranscription:  cfg.InputAudioTranscription,
+			OutputAudioTranscription: cfg.OutputAudioTranscription,
+			RealtimeInputConfig:      cfg.RealtimeInputConfig,
+		}
+	}
 	return nil
 }
 
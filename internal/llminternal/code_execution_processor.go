@@ -0,0 +1,215 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/codeexecutor"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+// codeExecutionToolApplier is implemented by the code executors relying on
+// the code execution of the model, such as [codeexecutor.BuiltIn].
+type codeExecutionToolApplier interface {
+	ApplyCodeExecutionTool(req *model.LLMRequest)
+}
+
+// codeExecutionRequestProcessor prepares the request for the code executor
+// of the agent. The built-in code executor adds the code execution tool,
+// while the code and results of the other executors are formatted as text
+// with their delimiters.
+//
+// reference: adk-python src/google/adk/flows/llm_flows/_code_execution.py
+func codeExecutionRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
+	executor := agentCodeExecutor(ctx)
+	if executor == nil {
+		return nil
+	}
+	if e, ok := executor.(codeExecutionToolApplier); ok {
+		e.ApplyCodeExecutionTool(req)
+		return nil
+	}
+	codeDelimiters := executor.CodeBlockDelimiters()
+	if len(codeDelimiters) == 0 {
+		return fmt.Errorf("code executor %T has no code block delimiters", executor)
+	}
+	for i, c := range req.Contents {
+		req.Contents[i] = convertCodeExecutionParts(c, codeDelimiters[0], executor.ExecutionResultDelimiter())
+	}
+	return nil
+}
+
+// convertCodeExecutionParts returns the content with a trailing executable
+// code, or a sole code execution result, converted to text. The content
+// isn't modified.
+func convertCodeExecutionParts(c *genai.Content, codeDelimiter, resultDelimiter codeexecutor.Delimiter) *genai.Content {
+	if c == nil || len(c.Parts) == 0 {
+		return c
+	}
+	last := c.Parts[len(c.Parts)-1]
+	switch {
+	case last.ExecutableCode != nil:
+		parts := append(c.Parts[:len(c.Parts)-1:len(c.Parts)-1],
+			genai.NewPartFromText(codeDelimiter.Start+last.ExecutableCode.Code+codeDelimiter.End))
+		return &genai.Content{Role: c.Role, Parts: parts}
+	case len(c.Parts) == 1 && last.CodeExecutionResult != nil:
+		text := resultDelimiter.Start + last.CodeExecutionResult.Output + resultDelimiter.End
+		return genai.NewContentFromText(text, genai.RoleUser)
+	}
+	return c
+}
+
+// codeExecutionResponseProcessor replaces the response of the model with its
+// text up to the first code block, followed by the code as an executable
+// code part, which is run by [Flow.handleCodeExecution].
+func codeExecutionResponseProcessor(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error {
+	if resp == nil || resp.Partial || resp.Content == nil {
+		return nil
+	}
+	executor := agentCodeExecutor(ctx)
+	if executor == nil {
+		return nil
+	}
+	if _, ok := executor.(codeExecutionToolApplier); ok {
+		// The model runs the code itself.
+		return nil
+	}
+	if parts := extractCode(resp.Content.Parts, executor.CodeBlockDelimiters()); parts != nil {
+		resp.Content = &genai.Content{Role: resp.Content.Role, Parts: parts}
+	}
+	return nil
+}
+
+// extractCode returns the parts up to the first code to run, which ends them
+// as an executable code part, or nil if there's no code to run.
+func extractCode(parts []*genai.Part, delimiters []codeexecutor.Delimiter) []*genai.Part {
+	for i, p := range parts {
+		if p.ExecutableCode == nil {
+			continue
+		}
+		if i == len(parts)-1 || parts[i+1].CodeExecutionResult == nil {
+			return parts[:i+1]
+		}
+	}
+
+	var texts []string
+	for _, p := range parts {
+		if p.Text != "" && !p.Thought {
+			texts = append(texts, p.Text)
+		}
+	}
+	text := strings.Join(texts, "\n")
+	start, delimiter := -1, codeexecutor.Delimiter{}
+	for _, d := range delimiters {
+		if i := strings.Index(text, d.Start); i >= 0 && (start < 0 || i < start) {
+			start, delimiter = i, d
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+	code, _, ok := strings.Cut(text[start+len(delimiter.Start):], delimiter.End)
+	if !ok || code == "" {
+		return nil
+	}
+
+	var result []*genai.Part
+	if prefix := text[:start]; prefix != "" {
+		result = append(result, genai.NewPartFromText(prefix))
+	}
+	return append(result, genai.NewPartFromExecutableCode(code, genai.LanguagePython))
+}
+
+// handleCodeExecution runs the trailing executable code of the response with
+// the code executor of the agent, and returns the event with the result, or
+// nil if there's no code to run. The files created by the code are saved as
+// artifacts.
+func (f *Flow) handleCodeExecution(ctx agent.InvocationContext, resp *model.LLMResponse) (*session.Event, error) {
+	if resp.Content == nil || len(resp.Content.Parts) == 0 {
+		return nil, nil
+	}
+	code := resp.Content.Parts[len(resp.Content.Parts)-1].ExecutableCode
+	if code == nil {
+		return nil, nil
+	}
+	executor := agentCodeExecutor(ctx)
+	if executor == nil {
+		return nil, nil
+	}
+	if _, ok := executor.(codeExecutionToolApplier); ok {
+		return nil, nil
+	}
+
+	result, err := executor.ExecuteCode(ctx, &codeexecutor.Input{Code: code.Code, ExecutionID: ctx.InvocationID()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute code: %w", err)
+	}
+
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	if artifacts := ctx.Artifacts(); artifacts != nil {
+		for _, file := range result.OutputFiles {
+			saveResp, err := artifacts.Save(ctx, file.Name, genai.NewPartFromBytes(file.Content, file.MIMEType))
+			if err != nil {
+				return nil, fmt.Errorf("failed to save output file %q: %w", file.Name, err)
+			}
+			if ev.Actions.ArtifactDelta == nil {
+				ev.Actions.ArtifactDelta = make(map[string]int64)
+			}
+			ev.Actions.ArtifactDelta[file.Name] = saveResp.Version
+		}
+	}
+	ev.LLMResponse = model.LLMResponse{
+		Content: &genai.Content{
+			Role:  genai.RoleModel,
+			Parts: []*genai.Part{codeExecutionResultPart(result)},
+		},
+	}
+	return ev, nil
+}
+
+// codeExecutionResultPart returns the part reporting the result to the model.
+func codeExecutionResultPart(result *codeexecutor.Result) *genai.Part {
+	if result.Stderr != "" {
+		return genai.NewPartFromCodeExecutionResult(genai.OutcomeFailed, result.Stderr)
+	}
+	var output []string
+	if result.Stdout != "" || len(result.OutputFiles) == 0 {
+		output = append(output, "Code execution result:\n"+result.Stdout+"\n")
+	}
+	if len(result.OutputFiles) > 0 {
+		names := make([]string, len(result.OutputFiles))
+		for i, f := range result.OutputFiles {
+			names[i] = "`" + f.Name + "`"
+		}
+		output = append(output, "Saved artifacts:\n"+strings.Join(names, ","))
+	}
+	return genai.NewPartFromCodeExecutionResult(genai.OutcomeOK, strings.Join(output, "\n\n"))
+}
+
+func agentCodeExecutor(ctx agent.InvocationContext) codeexecutor.CodeExecutor {
+	llmAgent := asLLMAgent(ctx.Agent())
+	if llmAgent == nil {
+		return nil
+	}
+	return llmAgent.internal().CodeExecutor
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/codeexecutor"
+)
+
+func TestExtractCode(t *testing.T) {
+	code := func(code string) *genai.Part {
+		return genai.NewPartFromExecutableCode(code, genai.LanguagePython)
+	}
+	result := genai.NewPartFromCodeExecutionResult(genai.OutcomeOK, "42")
+	tests := []struct {
+		name  string
+		parts []*genai.Part
+		want  []*genai.Part
+	}{
+		{
+			name:  "no code",
+			parts: []*genai.Part{genai.NewPartFromText("The answer is 42.")},
+			want:  nil,
+		},
+		{
+			name: "code block",
+			parts: []*genai.Part{
+				genai.NewPartFromText("Let me compute it.\n```tool_code\nprint(6 * 7)\n```\nThe answer is"),
+				genai.NewPartFromText("```python\nprint(42)\n```"),
+			},
+			want: []*genai.Part{
+				genai.NewPartFromText("Let me compute it.\n"),
+				code("print(6 * 7)"),
+			},
+		},
+		{
+			name:  "code block in a later part",
+			parts: []*genai.Part{genai.NewPartFromText("Let me compute it."), genai.NewPartFromText("```python\nprint(6 * 7)\n```")},
+			want:  []*genai.Part{genai.NewPartFromText("Let me compute it.\n"), code("print(6 * 7)")},
+		},
+		{
+			name:  "unterminated code block",
+			parts: []*genai.Part{genai.NewPartFromText("```python\nprint(6 * 7)")},
+			want:  nil,
+		},
+		{
+			name:  "thought",
+			parts: []*genai.Part{{Text: "```python\nprint(6 * 7)\n```", Thought: true}},
+			want:  nil,
+		},
+		{
+			name:  "executable code",
+			parts: []*genai.Part{genai.NewPartFromText("Computing."), code("print(6 * 7)"), genai.NewPartFromText("ignored")},
+			want:  []*genai.Part{genai.NewPartFromText("Computing."), code("print(6 * 7)")},
+		},
+		{
+			name:  "executed code",
+			parts: []*genai.Part{code("print(6 * 7)"), result},
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractCode(tt.parts, codeexecutor.DefaultCodeBlockDelimiters)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("extractCode() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConvertCodeExecutionParts(t *testing.T) {
+	codeDelimiter := codeexecutor.DefaultCodeBlockDelimiters[0]
+	resultDelimiter := codeexecutor.DefaultExecutionResultDelimiter
+	tests := []struct {
+		name    string
+		content *genai.Content
+		want    *genai.Content
+	}{
+		{
+			name:    "text",
+			content: genai.NewContentFromText("hello", genai.RoleModel),
+			want:    genai.NewContentFromText("hello", genai.RoleModel),
+		},
+		{
+			name: "executable code",
+			content: genai.NewContentFromParts([]*genai.Part{
+				genai.NewPartFromText("Let me compute it.\n"),
+				genai.NewPartFromExecutableCode("print(6 * 7)", genai.LanguagePython),
+			}, genai.RoleModel),
+			want: genai.NewContentFromParts([]*genai.Part{
+				genai.NewPartFromText("Let me compute it.\n"),
+				genai.NewPartFromText("```tool_code\nprint(6 * 7)\n```"),
+			}, genai.RoleModel),
+		},
+		{
+			name: "code execution result",
+			content: genai.NewContentFromParts([]*genai.Part{
+				genai.NewPartFromCodeExecutionResult(genai.OutcomeOK, "42"),
+			}, genai.RoleModel),
+			want: genai.NewContentFromText("```tool_output\n42\n```", genai.RoleUser),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := clone(tt.content)
+			got := convertCodeExecutionParts(tt.content, codeDelimiter, resultDelimiter)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("convertCodeExecutionParts() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(orig, tt.content); diff != "" {
+				t.Errorf("convertCodeExecutionParts() modified the content (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
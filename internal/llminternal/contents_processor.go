@@ -25,9 +25,11 @@ import (
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool/toolconfirmation"
 )
 
 // ContentRequestProcessor populates the LLMRequest's Contents based on
@@ -50,14 +52,93 @@ func ContentsRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest
 			events = append(events, e)
 		}
 	}
+	events = applyCompactions(events)
+	if builder := llmAgent.internal().ContentsBuilder; builder != nil {
+		// The builder selects the events, which are then processed as usual.
+		var err error
+		if events, err = builder(ctx, events); err != nil {
+			return fmt.Errorf("failed to build contents: %w", err)
+		}
+		fn = buildContentsDefault
+	}
 	contents, err := fn(ctx.Agent().Name(), ctx.Branch(), events)
 	if err != nil {
 		return err
 	}
+	if llmAgent.internal().Planner != nil {
+		// The planning of the previous responses isn't sent back to the
+		// model. Without a planner, the thoughts are kept for the models
+		// replaying them, e.g. with their signatures.
+		contents = withoutThoughts(contents)
+	}
 	req.Contents = append(req.Contents, contents...)
 	return nil
 }
 
+// applyCompactions replaces the events compacted by the compaction events
+// with the summaries of the compactions. The compactions covered by later
+// compactions, which summarize their summaries, are ignored.
+func applyCompactions(events []*session.Event) []*session.Event {
+	var compactions []*session.EventCompaction
+	for _, ev := range slices.Backward(events) {
+		c := ev.Actions.Compaction
+		if c == nil {
+			continue
+		}
+		if !slices.ContainsFunc(compactions, func(o *session.EventCompaction) bool {
+			return !c.StartTime.Before(o.StartTime) && !c.EndTime.After(o.EndTime)
+		}) {
+			compactions = append(compactions, c)
+		}
+	}
+	if len(compactions) == 0 {
+		return events
+	}
+
+	var res []*session.Event
+	summarized := make(map[*session.EventCompaction]bool)
+	for _, ev := range events {
+		if ev.Actions.Compaction != nil {
+			continue
+		}
+		i := slices.IndexFunc(compactions, func(c *session.EventCompaction) bool {
+			return !ev.Timestamp.Before(c.StartTime) && !ev.Timestamp.After(c.EndTime)
+		})
+		if i < 0 {
+			res = append(res, ev)
+			continue
+		}
+		// The summary takes the place of the first compacted event.
+		if c := compactions[i]; !summarized[c] {
+			summarized[c] = true
+			res = append(res, &session.Event{ // made-up event. Don't go through types.NewEvent.
+				Timestamp:   c.StartTime,
+				Author:      "user",
+				LLMResponse: model.LLMResponse{Content: c.Content},
+			})
+		}
+	}
+	return res
+}
+
+// withoutThoughts removes the thought parts, such as the planning and the
+// reasoning of the model, from the history. The contents left without parts
+// are dropped.
+func withoutThoughts(contents []*genai.Content) []*genai.Content {
+	var res []*genai.Content
+	for _, c := range contents {
+		if !slices.ContainsFunc(c.Parts, func(p *genai.Part) bool { return p.Thought }) {
+			res = append(res, c)
+			continue
+		}
+		parts := slices.DeleteFunc(slices.Clone(c.Parts), func(p *genai.Part) bool { return p.Thought })
+		if len(parts) > 0 {
+			res = append(res, &genai.Content{Role: c.Role, Parts: parts})
+		}
+	}
+	return res
+}
+
 // buildContentsDefault returns the contents for the LLM request by applying
 // filtering, rearrangement, and content processing to the given events.
 func buildContentsDefault(agentName, invocationBranch string, events []*session.Event) ([]*genai.Content, error) {
@@ -79,7 +160,7 @@ func buildContentsDefault(agentName, invocationBranch string, events []*session.
 		if !eventBelongsToBranch(invocationBranch, ev) {
 			continue
 		}
-		if isAuthEvent(ev) {
+		if isAuthEvent(ev) || isConfirmationEvent(ev) {
 			continue
 		}
 		if isOtherAgentReply(agentName, ev) {
@@ -439,16 +520,22 @@ func mergeFunctionResponseEvents(functionResponseEvents []*session.Event) (*sess
 //	In multi-agent scenarios, the "current turn" for an agent starts from an
 //	actual user or from another agent.
 func buildContentsCurrentTurnContextOnly(agentName, branch string, events []*session.Event) ([]*genai.Content, error) {
+	return buildContentsDefault(agentName, branch, CurrentTurnEvents(agentName, events))
+}
+
+// CurrentTurnEvents returns the events of the current turn of the agent,
+// starting with the latest event of the user or of another agent.
+func CurrentTurnEvents(agentName string, events []*session.Event) []*session.Event {
 	// Find the latest event that starts the current turn and process from there
 	for i := len(events) - 1; i >= 0; i-- {
 		event := events[i]
 		if event.Author == "user" || isOtherAgentReply(agentName, event) {
-			return buildContentsDefault(agentName, branch, events[i:])
+			return events[i:]
 		}
 	}
 	// NOTE: in Python, it returns [] if there is no event authored by a user or another agent,
 	// but that may be a bug.
-	return buildContentsDefault(agentName, branch, events)
+	return events
 }
 
 func isOtherAgentReply(currentAgentName string, ev *session.Event) bool {
@@ -504,7 +591,7 @@ func stringify(v any) string {
 
 // requestEUCFunctionCallName is a special function to handle credential
 // request.
-const requestEUCFunctionCallName = "adk_request_credential"
+const requestEUCFunctionCallName = auth.FunctionCallName
 
 func isAuthEvent(ev *session.Event) bool {
 	c := utils.Content(ev)
@@ -522,6 +609,24 @@ func isAuthEvent(ev *session.Event) bool {
 	return false
 }
 
+// isConfirmationEvent reports whether the event requests or responds to the
+// confirmation of tool calls.
+func isConfirmationEvent(ev *session.Event) bool {
+	c := utils.Content(ev)
+	if c == nil {
+		return false
+	}
+	for _, p := range c.Parts {
+		if p.FunctionCall != nil && p.FunctionCall.Name == toolconfirmation.FunctionCallName {
+			return true
+		}
+		if p.FunctionResponse != nil && p.FunctionResponse.Name == toolconfirmation.FunctionCallName {
+			return true
+		}
+	}
+	return false
+}
+
 func listFunctionCallsFromEvent(e *session.Event) []*genai.FunctionCall {
 	funcCalls := make([]*genai.FunctionCall, 0)
 	if e.LLMResponse.Content != nil && e.LLMResponse.Content.Parts != nil {
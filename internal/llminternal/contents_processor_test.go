@@ -30,6 +30,7 @@ import (
 	"google.golang.org/adk/internal/llminternal"
 	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/planner"
 	"google.golang.org/adk/session"
 )
 
@@ -247,10 +248,11 @@ func TestContentsRequestProcessor(t *testing.T) {
 
 	t.Parallel()
 	testCases := []struct {
-		name   string
-		branch string
-		events []*session.Event
-		want   []*genai.Content
+		name    string
+		branch  string
+		planner planner.Planner
+		events  []*session.Event
+		want    []*genai.Content
 	}{
 		{
 			name:   "NilEvent",
@@ -283,6 +285,41 @@ func TestContentsRequestProcessor(t *testing.T) {
 				genai.NewContentFromText("Hi there", "model"),
 			},
 		},
+		{
+			// The thoughts are replayed to the models requiring them, e.g.
+			// to resume the tool use with the extended thinking.
+			name: "ThoughtPartsWithoutPlanner",
+			events: []*session.Event{
+				{
+					Author: "user",
+					LLMResponse: model.LLMResponse{
+						Content: genai.NewContentFromText("Hello", "user"),
+					},
+				},
+				{
+					Author: agentName,
+					LLMResponse: model.LLMResponse{
+						Content: &genai.Content{
+							Role: "model",
+							Parts: []*genai.Part{
+								{Text: "greet back", Thought: true, ThoughtSignature: []byte("signature")},
+								{Text: "Hi there"},
+							},
+						},
+					},
+				},
+			},
+			want: []*genai.Content{
+				genai.NewContentFromText("Hello", "user"),
+				{
+					Role: "model",
+					Parts: []*genai.Part{
+						{Text: "greet back", Thought: true, ThoughtSignature: []byte("signature")},
+						{Text: "Hi there"},
+					},
+				},
+			},
+		},
 		{
 			name: "anotherAgentEvent",
 			events: []*session.Event{
@@ -365,13 +402,53 @@ func TestContentsRequestProcessor(t *testing.T) {
 			},
 			want: nil,
 		},
+		{
+			name:    "ThoughtParts",
+			planner: planner.NewPlanReAct(),
+			events: []*session.Event{
+				{
+					Author: "user",
+					LLMResponse: model.LLMResponse{
+						Content: genai.NewContentFromText("Hello", "user"),
+					},
+				},
+				{
+					Author: agentName,
+					LLMResponse: model.LLMResponse{
+						Content: &genai.Content{
+							Role: "model",
+							Parts: []*genai.Part{
+								{Text: "/*PLANNING*/ greet back", Thought: true},
+							},
+						},
+					},
+				},
+				{
+					Author: agentName,
+					LLMResponse: model.LLMResponse{
+						Content: &genai.Content{
+							Role: "model",
+							Parts: []*genai.Part{
+								{Text: "/*FINAL_ANSWER*/", Thought: true},
+								{Text: "Hi there"},
+							},
+						},
+					},
+				},
+			},
+			want: []*genai.Content{
+				genai.NewContentFromText("Hello", "user"),
+				genai.NewContentFromText("Hi there", "model"),
+			},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			testAgent := utils.Must(llmagent.New(llmagent.Config{
-				Name:  "testAgent",
-				Model: testModel,
+				Name:    "testAgent",
+				Model:   testModel,
+				Planner: tc.planner,
 			}))
 
 			ctx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
@@ -882,6 +959,93 @@ func TestContentsRequestProcessor_Rearrange(t *testing.T) {
 	}
 }
 
+func TestContentsRequestProcessor_Compaction(t *testing.T) {
+	const agentName = "test_agent"
+	start := time.Now()
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Second) }
+	textEvent := func(i int, author, text string) *session.Event {
+		var role genai.Role = genai.RoleModel
+		if author == "user" {
+			role = genai.RoleUser
+		}
+		return &session.Event{
+			Timestamp:   at(i),
+			Author:      author,
+			LLMResponse: model.LLMResponse{Content: genai.NewContentFromText(text, role)},
+		}
+	}
+	compactionEvent := func(i, startTime, endTime int, summary string) *session.Event {
+		return &session.Event{
+			Timestamp: at(i),
+			Author:    "user",
+			Actions: session.EventActions{Compaction: &session.EventCompaction{
+				StartTime: at(startTime),
+				EndTime:   at(endTime),
+				Content:   genai.NewContentFromText(summary, genai.RoleModel),
+			}},
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		events []*session.Event
+		want   []*genai.Content
+	}{
+		{
+			name: "Compaction",
+			events: []*session.Event{
+				textEvent(1, "user", "a"),
+				textEvent(2, agentName, "b"),
+				compactionEvent(3, 1, 2, "summary of a, b"),
+				textEvent(4, "user", "c"),
+			},
+			want: []*genai.Content{
+				genai.NewContentFromText("summary of a, b", genai.RoleModel),
+				genai.NewContentFromText("c", genai.RoleUser),
+			},
+		},
+		{
+			name: "LaterCompactionCoversEarlierOne",
+			events: []*session.Event{
+				textEvent(1, "user", "a"),
+				textEvent(2, agentName, "b"),
+				compactionEvent(3, 1, 2, "summary of a, b"),
+				textEvent(4, "user", "c"),
+				textEvent(5, agentName, "d"),
+				compactionEvent(6, 1, 4, "summary of a, b, c"),
+				textEvent(7, "user", "e"),
+			},
+			want: []*genai.Content{
+				genai.NewContentFromText("summary of a, b, c", genai.RoleModel),
+				genai.NewContentFromText("d", genai.RoleModel),
+				genai.NewContentFromText("e", genai.RoleUser),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testAgent := utils.Must(llmagent.New(llmagent.Config{
+				Name:  agentName,
+				Model: &testModel{},
+			}))
+
+			ctx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
+				Agent:   testAgent,
+				Session: &fakeSession{events: tc.events},
+			})
+
+			req := &model.LLMRequest{}
+			if err := llminternal.ContentsRequestProcessor(ctx, req); err != nil {
+				t.Fatalf("ContentsRequestProcessor failed: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, req.Contents); diff != "" {
+				t.Errorf("LLMRequest.Contents mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 // NewContentFromFunctionCall creates a new Content struct with a single FunctionCall part.
 // It assigns the provided role to the Content.
 func NewContentFromFunctionCall(fc *genai.FunctionCall, role string) *genai.Content {
@@ -0,0 +1,181 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"bytes"
+	"iter"
+	"slices"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+)
+
+// continuationPrompt asks the model to continue a response that was cut off
+// by the output token limit.
+const continuationPrompt = "Your previous response was cut off because it reached the maximum number of output tokens. " +
+	"Continue exactly where it stopped, without repeating what was already written. " +
+	"If a function call was cut off, make the whole function call again."
+
+// callLLMWithContinuation calls the LLM and, when the response is cut off by
+// the output token limit, asks the model to continue up to f.MaxContinuations
+// times.
+//
+// The partial responses are forwarded as they arrive. The complete responses
+// of all the rounds are stitched into one response, so that the caller sees a
+// single logical response.
+func (f *Flow) callLLMWithContinuation(ctx agent.InvocationContext, req *model.LLMRequest, stateDelta map[string]any) iter.Seq2[*model.LLMResponse, error] {
+	if f.MaxContinuations <= 0 {
+		return f.callLLM(ctx, req, stateDelta)
+	}
+	return func(yield func(*model.LLMResponse, error) bool) {
+		var pieces []*model.LLMResponse
+		roundReq := req
+		for round := 0; ; round++ {
+			var complete []*model.LLMResponse
+			for resp, err := range f.callLLM(ctx, roundReq, stateDelta) {
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if resp.Partial {
+					if !yield(resp, nil) {
+						return
+					}
+					continue
+				}
+				complete = append(complete, resp)
+			}
+
+			last := len(complete) - 1
+			if last < 0 || complete[last].FinishReason != genai.FinishReasonMaxTokens || round >= f.MaxContinuations || ctx.Ended() {
+				if round == 0 {
+					// Not truncated: forward the responses as is.
+					for _, resp := range complete {
+						if !yield(resp, nil) {
+							return
+						}
+					}
+					return
+				}
+				pieces = append(pieces, complete...)
+				yield(stitchResponses(pieces), nil)
+				return
+			}
+
+			// A function call cut off by the token limit is incomplete, and the
+			// model is asked to make it again.
+			for _, resp := range complete {
+				pieces = append(pieces, withoutFunctionCalls(resp))
+			}
+			roundReq = continuationRequest(req, stitchResponses(pieces).Content)
+		}
+	}
+}
+
+// continuationRequest returns a copy of the request that asks the model to
+// continue the output generated so far.
+func continuationRequest(req *model.LLMRequest, output *genai.Content) *model.LLMRequest {
+	r := *req
+	r.Contents = slices.Clip(req.Contents)
+	if output != nil && len(output.Parts) > 0 {
+		r.Contents = append(r.Contents, output)
+	}
+	r.Contents = append(r.Contents, genai.NewContentFromText(continuationPrompt, genai.RoleUser))
+	return &r
+}
+
+// withoutFunctionCalls returns a copy of the response without the function
+// call parts.
+func withoutFunctionCalls(resp *model.LLMResponse) *model.LLMResponse {
+	if resp.Content == nil {
+		return resp
+	}
+	r := *resp
+	r.Content = &genai.Content{
+		Role: resp.Content.Role,
+		Parts: slices.DeleteFunc(slices.Clone(resp.Content.Parts), func(p *genai.Part) bool {
+			return p.FunctionCall != nil
+		}),
+	}
+	return &r
+}
+
+// stitchResponses merges the responses into one. The parts are concatenated,
+// with the adjacent text parts joined, and the token counts are summed.
+// The other fields come from the last response.
+func stitchResponses(resps []*model.LLMResponse) *model.LLMResponse {
+	stitched := *resps[len(resps)-1]
+	content := &genai.Content{Role: genai.RoleModel}
+	var usage *genai.GenerateContentResponseUsageMetadata
+	for _, resp := range resps {
+		if resp.Content != nil {
+			if resp.Content.Role != "" {
+				content.Role = resp.Content.Role
+			}
+			for _, p := range resp.Content.Parts {
+				content.Parts = appendPart(content.Parts, p)
+			}
+		}
+		usage = addUsage(usage, resp.UsageMetadata)
+	}
+	stitched.Content = nil
+	if len(content.Parts) > 0 {
+		stitched.Content = content
+	}
+	stitched.UsageMetadata = usage
+	return &stitched
+}
+
+// appendPart appends the part, joining it with the last part if both are
+// plain text of the same kind. The parts with different thought signatures
+// are kept apart, as the model needs them back as they were.
+func appendPart(parts []*genai.Part, p *genai.Part) []*genai.Part {
+	if len(parts) > 0 {
+		last := parts[len(parts)-1]
+		if isPlainText(last) && isPlainText(p) && last.Thought == p.Thought && bytes.Equal(last.ThoughtSignature, p.ThoughtSignature) {
+			joined := *last
+			joined.Text += p.Text
+			parts[len(parts)-1] = &joined
+			return parts
+		}
+	}
+	return append(parts, p)
+}
+
+func isPlainText(p *genai.Part) bool {
+	return p.Text != "" && p.FunctionCall == nil && p.FunctionResponse == nil && p.InlineData == nil &&
+		p.FileData == nil && p.ExecutableCode == nil && p.CodeExecutionResult == nil
+}
+
+func addUsage(a, b *genai.GenerateContentResponseUsageMetadata) *genai.GenerateContentResponseUsageMetadata {
+	if a == nil || b == nil {
+		if a == nil {
+			return b
+		}
+		return a
+	}
+	return &genai.GenerateContentResponseUsageMetadata{
+		CachedContentTokenCount: a.CachedContentTokenCount + b.CachedContentTokenCount,
+		CandidatesTokenCount:    a.CandidatesTokenCount + b.CandidatesTokenCount,
+		PromptTokenCount:        a.PromptTokenCount + b.PromptTokenCount,
+		ThoughtsTokenCount:      a.ThoughtsTokenCount + b.ThoughtsTokenCount,
+		ToolUsePromptTokenCount: a.ToolUsePromptTokenCount + b.ToolUsePromptTokenCount,
+		TotalTokenCount:         a.TotalTokenCount + b.TotalTokenCount,
+		TrafficType:             b.TrafficType,
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+func TestStitchResponses(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts [][]*genai.Part
+		want  []*genai.Part
+	}{
+		{
+			name:  "JoinedText",
+			parts: [][]*genai.Part{{{Text: "Hello, "}}, {{Text: "world!"}}},
+			want:  []*genai.Part{{Text: "Hello, world!"}},
+		},
+		{
+			name:  "ThoughtsApart",
+			parts: [][]*genai.Part{{{Text: "Thinking", Thought: true}}, {{Text: "Answer"}}},
+			want:  []*genai.Part{{Text: "Thinking", Thought: true}, {Text: "Answer"}},
+		},
+		{
+			name: "SameSignatureJoined",
+			parts: [][]*genai.Part{
+				{{Text: "Hello, ", ThoughtSignature: []byte("sig")}},
+				{{Text: "world!", ThoughtSignature: []byte("sig")}},
+			},
+			want: []*genai.Part{{Text: "Hello, world!", ThoughtSignature: []byte("sig")}},
+		},
+		{
+			name: "DifferentSignaturesApart",
+			parts: [][]*genai.Part{
+				{{Text: "Hello, ", ThoughtSignature: []byte("sig1")}},
+				{{Text: "world!", ThoughtSignature: []byte("sig2")}},
+				{{Text: " Bye."}},
+			},
+			want: []*genai.Part{
+				{Text: "Hello, ", ThoughtSignature: []byte("sig1")},
+				{Text: "world!", ThoughtSignature: []byte("sig2")},
+				{Text: " Bye."},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var resps []*model.LLMResponse
+			for _, parts := range tc.parts {
+				resps = append(resps, &model.LLMResponse{Content: genai.NewContentFromParts(parts, genai.RoleModel)})
+			}
+			got := stitchResponses(resps)
+			if diff := cmp.Diff(tc.want, got.Content.Parts); diff != "" {
+				t.Errorf("stitchResponses() parts mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converters
+
+import (
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// FunctionParameters returns the JSON schema describing the parameters of the
+// function declaration. ParametersJsonSchema takes precedence over Parameters.
+// An empty object schema is returned if the declaration has no parameters,
+// since most non-Gemini providers require the field to be present.
+func FunctionParameters(decl *genai.FunctionDeclaration) any {
+	if decl == nil {
+		return nil
+	}
+	if decl.ParametersJsonSchema != nil {
+		return decl.ParametersJsonSchema
+	}
+	if decl.Parameters != nil {
+		return Schema2JSONSchema(decl.Parameters)
+	}
+	return map[string]any{"type": "object", "properties": map[string]any{}}
+}
+
+// Schema2JSONSchema converts the OpenAPI subset used by [genai.Schema] into a
+// plain JSON schema document.
+func Schema2JSONSchema(s *genai.Schema) map[string]any {
+	if s == nil {
+		return nil
+	}
+	m := make(map[string]any)
+	if s.Type != "" && s.Type != genai.TypeUnspecified {
+		typ := strings.ToLower(string(s.Type))
+		if s.Nullable != nil && *s.Nullable {
+			m["type"] = []string{typ, "null"}
+		} else {
+			m["type"] = typ
+		}
+	}
+	if s.Title != "" {
+		m["title"] = s.Title
+	}
+	if s.Description != "" {
+		m["description"] = s.Description
+	}
+	if s.Format != "" {
+		m["format"] = s.Format
+	}
+	if s.Pattern != "" {
+		m["pattern"] = s.Pattern
+	}
+	if len(s.Enum) > 0 {
+		m["enum"] = s.Enum
+	}
+	if s.Default != nil {
+		m["default"] = s.Default
+	}
+	if s.Items != nil {
+		m["items"] = Schema2JSONSchema(s.Items)
+	}
+	if len(s.Properties) > 0 {
+		props := make(map[string]any, len(s.Properties))
+		for name, p := range s.Properties {
+			props[name] = Schema2JSONSchema(p)
+		}
+		m["properties"] = props
+	}
+	if len(s.Required) > 0 {
+		m["required"] = s.Required
+	}
+	if len(s.AnyOf) > 0 {
+		anyOf := make([]any, 0, len(s.AnyOf))
+		for _, a := range s.AnyOf {
+			anyOf = append(anyOf, Schema2JSONSchema(a))
+		}
+		m["anyOf"] = anyOf
+	}
+	if s.MinItems != nil {
+		m["minItems"] = *s.MinItems
+	}
+	if s.MaxItems != nil {
+		m["maxItems"] = *s.MaxItems
+	}
+	if s.MinLength != nil {
+		m["minLength"] = *s.MinLength
+	}
+	if s.MaxLength != nil {
+		m["maxLength"] = *s.MaxLength
+	}
+	if s.Minimum != nil {
+		m["minimum"] = *s.Minimum
+	}
+	if s.Maximum != nil {
+		m["maximum"] = *s.Maximum
+	}
+	return m
+}
@@ -0,0 +1,276 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"sync"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/agent/runconfig"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+// RunLive runs the agent in the bidi streaming mode. It connects to the
+// model, forwards the requests of the live request queue to the connection
+// and turns the model responses into events, until the queue is closed or
+// the control is transferred to another agent.
+//
+// The connection counts as one LLM call towards the limits of the run config.
+// The usage reported by the responses is recorded, each response as a model
+// call, and the live session ends when it exceeds the usage budget.
+//
+// reference: adk-python src/google/adk/flows/llm_flows/base_llm_flow.py BaseLlmFlow.run_live
+func (f *Flow) RunLive(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+	return func(yield func(*session.Event, error) bool) {
+		if f.Model == nil {
+			yield(nil, fmt.Errorf("agent %q: %w", ctx.Agent().Name(), ErrModelNotConfigured))
+			return
+		}
+		liveModel, ok := f.Model.(model.LiveLLM)
+		if !ok {
+			yield(nil, fmt.Errorf("agent %q: model %q does not support the bidi streaming mode", ctx.Agent().Name(), f.Model.Name()))
+			return
+		}
+		var queue *agent.LiveRequestQueue
+		if cfg := runconfig.FromContext(ctx); cfg != nil {
+			queue = cfg.LiveRequestQueue
+		}
+		if queue == nil {
+			yield(nil, fmt.Errorf("agent %q: live request queue is required in the bidi streaming mode", ctx.Agent().Name()))
+			return
+		}
+
+		req := &model.LLMRequest{
+			Model: f.Model.Name(),
+		}
+		if err := f.preprocess(ctx, req); err != nil {
+			yield(nil, err)
+			return
+		}
+		if ctx.Ended() {
+			return
+		}
+		tools, err := requestTools(req)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		if err := checkBudget(ctx); err != nil {
+			yield(nil, err)
+			return
+		}
+		if err := limits(ctx).AddLLMCall(); err != nil {
+			yield(nil, err)
+			return
+		}
+		conn, err := liveModel.Connect(ctx, req)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to connect to model %q: %w", f.Model.Name(), err))
+			return
+		}
+
+		sender := &liveSender{ctx: ctx, conn: conn, queue: queue}
+		sendCtx, cancelSend := context.WithCancel(ctx)
+		sendDone := make(chan struct{})
+		go func() {
+			defer close(sendDone)
+			sender.run(sendCtx)
+		}()
+		stopSender := func() {
+			cancelSend()
+			<-sendDone
+		}
+		defer func() {
+			// Closing the connection first unblocks a pending Send.
+			conn.Close()
+			stopSender()
+		}()
+
+		for resp, err := range conn.Receive(ctx) {
+			// The user input precedes the responses it caused.
+			for _, ev := range sender.takeEvents() {
+				if !yield(ev, nil) {
+					return
+				}
+			}
+
+			stateDelta := make(map[string]any)
+			callbackResp, callbackErr := f.runAfterModelCallbacks(ctx, resp, stateDelta, err)
+			if callbackErr != nil {
+				yield(nil, callbackErr)
+				return
+			}
+			if callbackResp != nil {
+				resp = callbackResp
+			} else if err != nil {
+				yield(nil, err)
+				return
+			}
+			if err := f.postprocess(ctx, req, resp); err != nil {
+				yield(nil, err)
+				return
+			}
+			if resp.Content == nil && resp.ErrorCode == "" && !resp.Interrupted && !resp.TurnComplete &&
+				resp.InputTranscription == nil && resp.OutputTranscription == nil && resp.UsageMetadata == nil {
+				continue
+			}
+
+			if !yield(f.finalizeLiveResponseEvent(ctx, resp, tools, stateDelta), nil) {
+				return
+			}
+			if resp.UsageMetadata != nil {
+				if err := f.recordLiveUsage(ctx, resp.UsageMetadata); err != nil {
+					yield(nil, err)
+					return
+				}
+			}
+
+			ev, err := f.handleFunctionCalls(ctx, tools, resp, nil)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if ev == nil {
+				continue
+			}
+			if !yield(ev, nil) {
+				return
+			}
+			if err := conn.Send(ctx, &model.LiveRequest{Content: ev.Content}); err != nil {
+				yield(nil, fmt.Errorf("failed to send function responses: %w", err))
+				return
+			}
+
+			if ev.Actions.TransferToAgent == "" {
+				continue
+			}
+			nextAgent := f.agentToRun(ctx, ev.Actions.TransferToAgent)
+			if nextAgent == nil {
+				yield(nil, fmt.Errorf("failed to find agent: %s", ev.Actions.TransferToAgent))
+				return
+			}
+			// The next agent opens its own connection and takes over the queue.
+			// Stop the sender before closing the connection, so that no request
+			// is taken from the queue and lost.
+			stopSender()
+			conn.Close()
+			for ev, err := range nextAgent.Run(ctx) {
+				if !yield(ev, err) || err != nil { // forward
+					return
+				}
+			}
+			return
+		}
+
+		for _, ev := range sender.takeEvents() {
+			if !yield(ev, nil) {
+				return
+			}
+		}
+		if err := sender.error(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// finalizeLiveResponseEvent builds the event of a live model response.
+// Finished transcriptions are also stored as the event content, so that
+// they are part of the conversation history.
+func (f *Flow) finalizeLiveResponseEvent(ctx agent.InvocationContext, resp *model.LLMResponse, tools map[string]tool.Tool, stateDelta map[string]any) *session.Event {
+	ev := f.finalizeModelResponseEvent(ctx, resp, tools, stateDelta)
+	if t := resp.InputTranscription; t != nil {
+		ev.Author = "user"
+		if !resp.Partial && resp.Content == nil && t.Text != "" {
+			ev.Content = genai.NewContentFromText(t.Text, genai.RoleUser)
+		}
+	} else if t := resp.OutputTranscription; t != nil {
+		if !resp.Partial && resp.Content == nil && t.Text != "" {
+			ev.Content = genai.NewContentFromText(t.Text, genai.RoleModel)
+		}
+	}
+	return ev
+}
+
+// liveSender forwards the requests of the live request queue to the model
+// connection.
+type liveSender struct {
+	ctx   agent.InvocationContext
+	conn  model.LiveConnection
+	queue *agent.LiveRequestQueue
+
+	mu sync.Mutex
+	// events are the user events not yet yielded by the flow.
+	events []*session.Event
+	err    error
+}
+
+// run sends the requests until the queue is closed, in which case it closes
+// the connection to end the live session, or until ctx is canceled.
+func (s *liveSender) run(ctx context.Context) {
+	for {
+		req, err := s.queue.Receive(ctx)
+		if errors.Is(err, io.EOF) {
+			s.conn.Close()
+			return
+		}
+		if err != nil {
+			return
+		}
+		if req.Content != nil {
+			// The user content is recorded like in the non-live mode.
+			content := req.Content
+			if content.Role == "" {
+				content = &genai.Content{Role: genai.RoleUser, Parts: content.Parts}
+			}
+			ev := session.NewEvent(s.ctx.InvocationID())
+			ev.Author = "user"
+			ev.LLMResponse = model.LLMResponse{Content: content}
+			s.mu.Lock()
+			s.events = append(s.events, ev)
+			s.mu.Unlock()
+		}
+		if err := s.conn.Send(ctx, req); err != nil {
+			s.mu.Lock()
+			s.err = fmt.Errorf("failed to send live request: %w", err)
+			s.mu.Unlock()
+			s.conn.Close()
+			return
+		}
+	}
+}
+
+func (s *liveSender) takeEvents() []*session.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.events
+	s.events = nil
+	return events
+}
+
+func (s *liveSender) error() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
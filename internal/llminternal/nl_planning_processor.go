@@ -0,0 +1,84 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+
+	"google.golang.org/adk/agent"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/planner"
+)
+
+// thinkingConfigApplier is implemented by the planners relying on the
+// built-in thinking of the model, such as [planner.BuiltIn].
+type thinkingConfigApplier interface {
+	ApplyThinkingConfig(req *model.LLMRequest)
+}
+
+// nlPlanningRequestProcessor applies the planner of the agent to the request.
+//
+// reference: adk-python src/google/adk/flows/llm_flows/_nl_planning.py
+func nlPlanningRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest) error {
+	p := agentPlanner(ctx)
+	if p == nil {
+		return nil
+	}
+	if tp, ok := p.(thinkingConfigApplier); ok {
+		tp.ApplyThinkingConfig(req)
+	}
+	instruction, err := p.BuildPlanningInstruction(icontext.NewReadonlyContext(ctx), req)
+	if err != nil {
+		return fmt.Errorf("failed to build planning instruction: %w", err)
+	}
+	if instruction != "" {
+		utils.AppendInstructions(req, instruction)
+	}
+	return nil
+}
+
+// nlPlanningResponseProcessor lets the planner of the agent process the parts
+// of the response, e.g. to mark the planning as thoughts.
+func nlPlanningResponseProcessor(ctx agent.InvocationContext, req *model.LLMRequest, resp *model.LLMResponse) error {
+	if resp == nil || resp.Content == nil || len(resp.Content.Parts) == 0 {
+		return nil
+	}
+	p := agentPlanner(ctx)
+	if p == nil {
+		return nil
+	}
+	if _, ok := p.(thinkingConfigApplier); ok {
+		// The model marks its thoughts itself.
+		return nil
+	}
+	parts, err := p.ProcessPlanningResponse(icontext.NewCallbackContext(ctx), resp.Content.Parts)
+	if err != nil {
+		return fmt.Errorf("failed to process planning response: %w", err)
+	}
+	if parts != nil {
+		resp.Content.Parts = parts
+	}
+	return nil
+}
+
+func agentPlanner(ctx agent.InvocationContext) planner.Planner {
+	llmAgent := asLLMAgent(ctx.Agent())
+	if llmAgent == nil {
+		return nil
+	}
+	return llmAgent.internal().Planner
+}
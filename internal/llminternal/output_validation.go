@@ -0,0 +1,136 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+)
+
+// OutputValidationError is returned when the final response of an agent
+// doesn't match its output schema, after all the repair attempts.
+type OutputValidationError struct {
+	// Agent is the name of the agent.
+	Agent string
+	// Output is the text of the last response.
+	Output string
+	// Attempts is the number of responses that failed the validation.
+	Attempts int
+	// Err is the validation error of the last response.
+	Err error
+}
+
+func (e *OutputValidationError) Error() string {
+	return fmt.Sprintf("agent %q: output doesn't match the output schema after %d attempt(s): %v", e.Agent, e.Attempts, e.Err)
+}
+
+func (e *OutputValidationError) Unwrap() error {
+	return e.Err
+}
+
+// callLLMWithValidation calls the LLM and validates its final responses
+// against the output schema of the agent, if any. A response that fails the
+// validation is not forwarded: the model is asked to fix it up to
+// f.MaxOutputRepairs times, after which an [OutputValidationError] is
+// returned.
+func (f *Flow) callLLMWithValidation(ctx agent.InvocationContext, req *model.LLMRequest, stateDelta map[string]any) iter.Seq2[*model.LLMResponse, error] {
+	llmAgent := asLLMAgent(ctx.Agent())
+	if llmAgent == nil || llmAgent.internal().OutputSchema == nil {
+		return f.callLLMWithContinuation(ctx, req, stateDelta)
+	}
+	schema := llmAgent.internal().OutputSchema
+
+	return func(yield func(*model.LLMResponse, error) bool) {
+		roundReq := req
+		for attempt := 1; ; attempt++ {
+			var invalid *model.LLMResponse
+			var validationErr error
+			for resp, err := range f.callLLMWithContinuation(ctx, roundReq, stateDelta) {
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if !resp.Partial {
+					if validationErr = validateOutput(resp, schema); validationErr != nil {
+						invalid = resp
+						break
+					}
+				}
+				if !yield(resp, nil) {
+					return
+				}
+			}
+			if invalid == nil {
+				return
+			}
+			if attempt > f.MaxOutputRepairs || ctx.Ended() {
+				yield(nil, &OutputValidationError{
+					Agent:    ctx.Agent().Name(),
+					Output:   outputText(invalid.Content),
+					Attempts: attempt,
+					Err:      validationErr,
+				})
+				return
+			}
+			roundReq = repairRequest(req, invalid.Content, validationErr)
+		}
+	}
+}
+
+// validateOutput validates the text of a final response against the schema.
+// Responses without content, with an error code or with function calls are
+// not meant to be the agent output and aren't validated.
+func validateOutput(resp *model.LLMResponse, schema *genai.Schema) error {
+	if resp.ErrorCode != "" || resp.Content == nil || len(resp.Content.Parts) == 0 {
+		return nil
+	}
+	if len(utils.FunctionCalls(resp.Content)) > 0 || len(utils.FunctionResponses(resp.Content)) > 0 {
+		return nil
+	}
+	_, err := utils.ValidateOutputSchema(outputText(resp.Content), schema)
+	return err
+}
+
+// outputText returns the text of the content, without thoughts.
+func outputText(c *genai.Content) string {
+	if c == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, p := range c.Parts {
+		if p.Text != "" && !p.Thought {
+			sb.WriteString(p.Text)
+		}
+	}
+	return sb.String()
+}
+
+// repairRequest returns a copy of the request that shows the invalid output
+// to the model along with the validation error, and asks for a fixed one.
+func repairRequest(req *model.LLMRequest, output *genai.Content, validationErr error) *model.LLMRequest {
+	r := *req
+	r.Contents = append(slices.Clip(req.Contents), output, genai.NewContentFromText(fmt.Sprintf(
+		"Your previous response doesn't match the required output schema: %v. "+
+			"Respond again with only the JSON object that matches the schema.", validationErr), genai.RoleUser))
+	return &r
+}
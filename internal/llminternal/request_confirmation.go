@@ -0,0 +1,163 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/converters"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/toolconfirmation"
+)
+
+// requireConfirmation reports whether the call of the tool must be confirmed
+// by the user, as required by the tool itself or by the confirmation policy
+// of the agent.
+func (f *Flow) requireConfirmation(ctx agent.InvocationContext, t toolinternal.FunctionTool, args map[string]any) bool {
+	if ct, ok := t.(toolinternal.ConfirmationTool); ok && ct.RequireConfirmation() {
+		return true
+	}
+	return f.ToolConfirmationPolicy != nil && f.ToolConfirmationPolicy(icontext.NewReadonlyContext(ctx), t, args)
+}
+
+// generateConfirmationEvent returns the event requesting the client for the
+// confirmations of the tool calls in the function response event, or nil if
+// there are none. The confirmation requests are long running function calls
+// to [toolconfirmation.FunctionCallName], so the agent run pauses until the
+// client responds.
+//
+// reference: adk-python src/google/adk/flows/llm_flows/functions.py generate_request_confirmation_event
+func generateConfirmationEvent(ctx agent.InvocationContext, fnResponseEvent *session.Event) (*session.Event, error) {
+	confirmations := fnResponseEvent.Actions.RequestedToolConfirmations
+	if len(confirmations) == 0 {
+		return nil, nil
+	}
+	names := make(map[string]string)
+	for _, fr := range utils.FunctionResponses(fnResponseEvent.Content) {
+		names[fr.ID] = fr.Name
+	}
+	var parts []*genai.Part
+	for _, id := range slices.Sorted(maps.Keys(confirmations)) {
+		c := confirmations[id]
+		args, err := converters.ToMapStructure(toolconfirmation.Request{
+			OriginalFunctionCall: &genai.FunctionCall{ID: id, Name: names[id], Args: c.Args},
+			ToolConfirmation:     c,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert the confirmation request of function call %q: %w", id, err)
+		}
+		parts = append(parts, genai.NewPartFromFunctionCall(toolconfirmation.FunctionCallName, args))
+	}
+	content := &genai.Content{Role: fnResponseEvent.Content.Role, Parts: parts}
+	utils.PopulateClientFunctionCallID(content)
+
+	ev := session.NewEvent(ctx.InvocationID())
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	ev.LLMResponse = model.LLMResponse{Content: content}
+	for _, fc := range utils.FunctionCalls(content) {
+		ev.LongRunningToolIDs = append(ev.LongRunningToolIDs, fc.ID)
+	}
+	return ev, nil
+}
+
+// resumeConfirmedCalls calls the tools whose calls were confirmed or rejected
+// by the client in the last event of the session, in response to
+// confirmation requests. It returns the function response event, or nil if
+// the last event doesn't respond to confirmation requests.
+//
+// reference: adk-python src/google/adk/flows/llm_flows/request_confirmation.py
+func (f *Flow) resumeConfirmedCalls(ctx agent.InvocationContext, tools map[string]tool.Tool) (*session.Event, error) {
+	events := ctx.Session().Events()
+	if events.Len() == 0 {
+		return nil, nil
+	}
+	last := events.At(events.Len() - 1)
+	if last.Author != "user" {
+		return nil, nil
+	}
+	responses := make(map[string]*toolconfirmation.Confirmation)
+	for _, fr := range utils.FunctionResponses(last.Content) {
+		if fr.Name != toolconfirmation.FunctionCallName {
+			continue
+		}
+		c, err := converters.FromMapStructure[toolconfirmation.Confirmation](fr.Response)
+		if err != nil {
+			return nil, fmt.Errorf("invalid confirmation response %q: %w", fr.ID, err)
+		}
+		responses[fr.ID] = c
+	}
+	if len(responses) == 0 {
+		return nil, nil
+	}
+
+	// Find the function calls to confirm from the confirmation requests.
+	var calls []*genai.Part
+	confirmations := make(map[string]*toolconfirmation.Confirmation)
+	for i := events.Len() - 2; i >= 0 && len(responses) > 0; i-- {
+		for _, fc := range utils.FunctionCalls(events.At(i).Content) {
+			c, ok := responses[fc.ID]
+			if fc.Name != toolconfirmation.FunctionCallName || !ok {
+				continue
+			}
+			delete(responses, fc.ID)
+			req, err := converters.FromMapStructure[toolconfirmation.Request](fc.Args)
+			if err != nil {
+				return nil, fmt.Errorf("invalid confirmation request %q: %w", fc.ID, err)
+			}
+			if req.OriginalFunctionCall == nil {
+				return nil, fmt.Errorf("invalid confirmation request %q: missing the original function call", fc.ID)
+			}
+			confirmations[req.OriginalFunctionCall.ID] = c
+			calls = append(calls, &genai.Part{FunctionCall: req.OriginalFunctionCall})
+		}
+	}
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	return f.handleFunctionCalls(ctx, tools, &model.LLMResponse{Content: genai.NewContentFromParts(calls, genai.RoleModel)}, confirmations)
+}
+
+// generateClientRequestEvents returns the events requesting the client for
+// the credentials and confirmations of the tool calls in the function
+// response event. The run pauses after them.
+func generateClientRequestEvents(ctx agent.InvocationContext, fnResponseEvent *session.Event) ([]*session.Event, error) {
+	var events []*session.Event
+	authEvent, err := generateAuthEvent(ctx, fnResponseEvent)
+	if err != nil {
+		return nil, err
+	}
+	if authEvent != nil {
+		events = append(events, authEvent)
+	}
+	confirmationEvent, err := generateConfirmationEvent(ctx, fnResponseEvent)
+	if err != nil {
+		return nil, err
+	}
+	if confirmationEvent != nil {
+		events = append(events, confirmationEvent)
+	}
+	return events, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"iter"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/agent/runconfig"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/usage"
+)
+
+// generateContent calls the model and records the usage of the call, if the
+// usage accounting is enabled.
+func (f *Flow) generateContent(ctx agent.InvocationContext, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	cfg := runconfig.FromContext(ctx)
+	if cfg == nil || cfg.Usage == nil {
+		return f.Model.GenerateContent(ctx, req, stream)
+	}
+	return func(yield func(*model.LLMResponse, error) bool) {
+		// The usage metadata of a streamed response is cumulative, so the
+		// last one is the usage of the whole call.
+		var md *genai.GenerateContentResponseUsageMetadata
+		for resp, err := range f.Model.GenerateContent(ctx, req, stream) {
+			if resp != nil && resp.UsageMetadata != nil {
+				md = resp.UsageMetadata
+			}
+			if !yield(resp, err) {
+				_ = recordUsage(ctx, cfg.Usage, f.Model.Name(), md)
+				return
+			}
+		}
+		if err := recordUsage(ctx, cfg.Usage, f.Model.Name(), md); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// recordLiveUsage records the usage reported by a live response and checks
+// the budget, which the live session can't otherwise check between the
+// model turns.
+func (f *Flow) recordLiveUsage(ctx agent.InvocationContext, md *genai.GenerateContentResponseUsageMetadata) error {
+	cfg := runconfig.FromContext(ctx)
+	if cfg == nil || cfg.Usage == nil {
+		return nil
+	}
+	if err := recordUsage(ctx, cfg.Usage, f.Model.Name(), md); err != nil {
+		return err
+	}
+	return checkBudget(ctx)
+}
+
+// recordUsage stores the usage of a model call.
+func recordUsage(ctx agent.InvocationContext, cfg *usage.Config, modelName string, md *genai.GenerateContentResponseUsageMetadata) error {
+	u := usage.FromMetadata(md)
+	if price, ok := cfg.Prices.Lookup(modelName); ok {
+		u.Cost = price.Cost(u)
+	}
+	scope := runconfig.FromContext(ctx).ScopeOf(ctx)
+	err := cfg.Service.Add(ctx, &usage.Record{
+		AppName:      scope.AppName,
+		UserID:       scope.UserID,
+		SessionID:    scope.SessionID,
+		InvocationID: scope.InvocationID,
+		Agent:        ctx.Agent().Name(),
+		Model:        modelName,
+		Usage:        u,
+		Time:         time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+// checkBudget returns a [usage.BudgetExceededError] if the usage of the
+// invocation, session or user exceeds its budget, before a model call.
+func checkBudget(ctx agent.InvocationContext) error {
+	cfg := runconfig.FromContext(ctx)
+	if cfg == nil || cfg.Usage == nil {
+		return nil
+	}
+	budget := cfg.Usage.Budget
+	s := cfg.ScopeOf(ctx)
+	budgets := []struct {
+		scope string
+		limit usage.Limit
+		req   *usage.GetRequest
+	}{
+		{"invocation", budget.Invocation, &usage.GetRequest{AppName: s.AppName, UserID: s.UserID, SessionID: s.SessionID, InvocationID: s.InvocationID}},
+		{"session", budget.Session, &usage.GetRequest{AppName: s.AppName, UserID: s.UserID, SessionID: s.SessionID}},
+		{"user", budget.User, &usage.GetRequest{AppName: s.AppName, UserID: s.UserID}},
+	}
+	for _, b := range budgets {
+		if b.limit.IsZero() {
+			continue
+		}
+		resp, err := cfg.Usage.Service.Get(ctx, b.req)
+		if err != nil {
+			return fmt.Errorf("failed to get usage: %w", err)
+		}
+		if b.limit.Exceeded(resp.Total) {
+			return &usage.BudgetExceededError{Scope: b.scope, Limit: b.limit, Usage: resp.Total}
+		}
+	}
+	return nil
+}
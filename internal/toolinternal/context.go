@@ -16,12 +16,15 @@ package toolinternal
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/internal/agent/runconfig"
 	contextinternal "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/session"
@@ -99,3 +102,21 @@ func (c *toolContext) AgentName() string {
 func (c *toolContext) SearchMemory(ctx context.Context, query string) (*memory.SearchResponse, error) {
 	return c.invocationContext.Memory().Search(ctx, query)
 }
+
+var _ tool.CredentialContext = (*toolContext)(nil)
+
+func (c *toolContext) Credential(cfg *auth.Config) (*auth.Credential, error) {
+	runCfg := runconfig.FromContext(c.invocationContext)
+	if runCfg == nil || runCfg.CredentialService == nil {
+		return nil, errors.New("credential service is not configured")
+	}
+	scope := runCfg.ScopeOf(c.invocationContext)
+	return auth.GetCredential(c, runCfg.CredentialService, scope.AppName, scope.UserID, cfg)
+}
+
+func (c *toolContext) RequestCredential(cfg *auth.Config) {
+	if c.eventActions.RequestedAuthConfigs == nil {
+		c.eventActions.RequestedAuthConfigs = make(map[string]*auth.Config)
+	}
+	c.eventActions.RequestedAuthConfigs[c.functionCallID] = cfg
+}
@@ -16,6 +16,8 @@
 package toolinternal
 
 import (
+	"time"
+
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/model"
@@ -28,6 +30,19 @@ type FunctionTool interface {
 	Run(ctx tool.Context, args any) (result map[string]any, err error)
 }
 
+// TimeoutTool is implemented by the tools with their own timeout, which
+// overrides the default tool timeout of the agent. Zero means no timeout of
+// their own.
+type TimeoutTool interface {
+	Timeout() time.Duration
+}
+
+// ConfirmationTool is implemented by the tools which can require the
+// confirmation of their calls by the user.
+type ConfirmationTool interface {
+	RequireConfirmation() bool
+}
+
 type RequestProcessor interface {
 	ProcessRequest(ctx tool.Context, req *model.LLMRequest) error
 }
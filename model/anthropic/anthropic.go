@@ -0,0 +1,374 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package anthropic implements the [model.LLM] interface for Claude models
+// served through the Anthropic Messages API.
+package anthropic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/internal/llminternal/converters"
+	"google.golang.org/adk/internal/version"
+	"google.golang.org/adk/model"
+)
+
+const (
+	defaultBaseURL = "https://api.anthropic.com"
+	apiVersion     = "2023-06-01"
+)
+
+// ClientConfig configures the connection to the Messages API.
+type ClientConfig struct {
+	// BaseURL is the API root. If empty, ANTHROPIC_BASE_URL is used, falling
+	// back to the Anthropic API.
+	BaseURL string
+	// APIKey is sent in the x-api-key header. If empty, ANTHROPIC_API_KEY is
+	// used.
+	APIKey string
+	// HTTPClient is used to send the requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Header contains additional headers sent with every request, e.g.
+	// "anthropic-beta".
+	Header http.Header
+}
+
+type anthropicModel struct {
+	name               string
+	baseURL            string
+	apiKey             string
+	httpClient         *http.Client
+	header             http.Header
+	versionHeaderValue string
+}
+
+// NewModel returns [model.LLM], backed by the Anthropic Messages API.
+//
+// The modelName specifies which Claude model to target
+// (e.g., "claude-sonnet-4-5").
+func NewModel(ctx context.Context, modelName string, cfg *ClientConfig) (model.LLM, error) {
+	if modelName == "" {
+		return nil, fmt.Errorf("model name is required")
+	}
+	if cfg == nil {
+		cfg = &ClientConfig{}
+	}
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = os.Getenv("ANTHROPIC_BASE_URL")
+	}
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	apiKey := cfg.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	headerValue := fmt.Sprintf("google-adk/%s gl-go/%s", version.Version,
+		strings.TrimPrefix(runtime.Version(), "go"))
+
+	return &anthropicModel{
+		name:               modelName,
+		baseURL:            strings.TrimSuffix(baseURL, "/"),
+		apiKey:             apiKey,
+		httpClient:         httpClient,
+		header:             cfg.Header.Clone(),
+		versionHeaderValue: headerValue,
+	}, nil
+}
+
+func (m *anthropicModel) Name() string {
+	return m.name
+}
+
+// GenerateContent calls the underlying model.
+func (m *anthropicModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	maybeAppendUserContent(req)
+
+	if stream {
+		return m.generateStream(ctx, req)
+	}
+
+	return func(yield func(*model.LLMResponse, error) bool) {
+		resp, err := m.generate(ctx, req)
+		yield(resp, err)
+	}
+}
+
+// generate calls the model synchronously.
+func (m *anthropicModel) generate(ctx context.Context, req *model.LLMRequest) (*model.LLMResponse, error) {
+	msgReq, err := toMessagesRequest(m.name, req)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := m.do(ctx, msgReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call model: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	var resp messageResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	genResp, err := toGenaiResponse(&resp)
+	if err != nil {
+		return nil, err
+	}
+	return converters.Genai2LLMResponse(genResp), nil
+}
+
+// generateStream returns a stream of responses from the model.
+//
+// Text and thinking deltas are yielded as partial responses, followed by an
+// aggregated response. Tool use blocks are only yielded when the message is
+// complete, since their input arrives in fragments.
+func (m *anthropicModel) generateStream(ctx context.Context, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
+	aggregator := llminternal.NewStreamingResponseAggregator()
+
+	return func(yield func(*model.LLMResponse, error) bool) {
+		msgReq, err := toMessagesRequest(m.name, req)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		msgReq.Stream = true
+
+		httpResp, err := m.do(ctx, msgReq)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to call model: %w", err))
+			return
+		}
+		defer httpResp.Body.Close()
+
+		acc := &streamAccumulator{}
+		process := func(genResps []*genai.GenerateContentResponse) bool {
+			for _, genResp := range genResps {
+				for llmResponse, err := range aggregator.ProcessResponse(ctx, genResp) {
+					acc.attachSignature(llmResponse)
+					if !yield(llmResponse, err) {
+						return false // Consumer stopped
+					}
+				}
+			}
+			return true
+		}
+
+		for ev, err := range readEvents(httpResp.Body) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			genResps, err := acc.add(ev)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !process(genResps) {
+				return
+			}
+		}
+		genResps, err := acc.close()
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if !process(genResps) {
+			return
+		}
+		if closeResult := aggregator.Close(); closeResult != nil {
+			acc.attachSignature(closeResult)
+			yield(closeResult, nil)
+		}
+	}
+}
+
+// do sends the messages request and returns the response if the API replied
+// with a successful status code.
+func (m *anthropicModel) do(ctx context.Context, msgReq *messagesRequest) (*http.Response, error) {
+	body, err := json.Marshal(msgReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range m.header {
+		httpReq.Header[k] = v
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", m.versionHeaderValue)
+	httpReq.Header.Set("anthropic-version", apiVersion)
+	if msgReq.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	if m.apiKey != "" {
+		httpReq.Header.Set("x-api-key", m.apiKey)
+	}
+
+	httpResp, err := m.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		defer httpResp.Body.Close()
+		return nil, newAPIError(httpResp)
+	}
+	return httpResp, nil
+}
+
+// readEvents parses the server-sent events stream of the Messages API.
+// The event type is also part of the data payload, so event names are ignored.
+func readEvents(r io.Reader) iter.Seq2[*streamEvent, error] {
+	return func(yield func(*streamEvent, error) bool) {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
+			var ev streamEvent
+			if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &ev); err != nil {
+				yield(nil, fmt.Errorf("failed to decode stream event: %w", err))
+				return
+			}
+			switch ev.Type {
+			case "error":
+				if ev.Error == nil {
+					ev.Error = &APIError{Type: "error"}
+				}
+				yield(nil, ev.Error)
+				return
+			case "message_stop":
+				return
+			}
+			if !yield(&ev, nil) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			yield(nil, fmt.Errorf("failed to read stream: %w", err))
+		}
+	}
+}
+
+// maybeAppendUserContent appends a user content, so that model can continue to output.
+func maybeAppendUserContent(req *model.LLMRequest) {
+	if len(req.Contents) == 0 {
+		req.Contents = append(req.Contents, genai.NewContentFromText("Handle the requests as specified in the System Instruction.", "user"))
+	}
+
+	if last := req.Contents[len(req.Contents)-1]; last != nil && last.Role != "user" {
+		req.Contents = append(req.Contents, genai.NewContentFromText("Continue processing previous requests as instructed. Exit or provide a summary if no more outputs are needed.", "user"))
+	}
+}
+
+// APIError is returned when the API responds with an error.
+type APIError struct {
+	// StatusCode is the HTTP response status code. It is zero for errors
+	// reported in the middle of a stream.
+	StatusCode int `json:"-"`
+	// Type is the error type, e.g. "overloaded_error" or "rate_limit_error".
+	Type string `json:"type"`
+	// Message is the error message reported by the API.
+	Message string `json:"message"`
+	// RetryAfter is the delay requested by the Retry-After response header.
+	// It is zero if the header is absent.
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("anthropic: status %d, type %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// HTTPStatusCode implements [model.APIError]. The errors reported in the
+// middle of a stream get the status code the API uses for their type.
+func (e *APIError) HTTPStatusCode() int {
+	if e.StatusCode != 0 {
+		return e.StatusCode
+	}
+	switch e.Type {
+	case "rate_limit_error":
+		return http.StatusTooManyRequests
+	case "overloaded_error":
+		return 529
+	case "api_error":
+		return http.StatusInternalServerError
+	}
+	return 0
+}
+
+// RetryDelay implements [model.APIError].
+func (e *APIError) RetryDelay() time.Duration {
+	return e.RetryAfter
+}
+
+var _ model.APIError = (*APIError)(nil)
+
+func newAPIError(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read error response (status %d): %w", resp.StatusCode, err)
+	}
+	var wrapper struct {
+		Error *APIError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &wrapper); err == nil && wrapper.Error != nil {
+		wrapper.Error.StatusCode = resp.StatusCode
+		wrapper.Error.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		return wrapper.Error
+	}
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, given either in
+// seconds or as an HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
@@ -0,0 +1,294 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// newTestServer starts a Messages API stand-in that records the decoded
+// request body and replies with the given handler.
+func newTestServer(t *testing.T, gotBody *map[string]any, handler func(w http.ResponseWriter)) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected path: %q", r.URL.Path)
+		}
+		if got, want := r.Header.Get("x-api-key"), "test-key"; got != want {
+			t.Errorf("x-api-key header = %q, want %q", got, want)
+		}
+		if got, want := r.Header.Get("anthropic-version"), apiVersion; got != want {
+			t.Errorf("anthropic-version header = %q, want %q", got, want)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotBody != nil {
+			if err := json.Unmarshal(body, gotBody); err != nil {
+				t.Fatal(err)
+			}
+		}
+		handler(w)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestModel(t *testing.T, srv *httptest.Server) model.LLM {
+	t.Helper()
+	m, err := NewModel(t.Context(), "claude-test", &ClientConfig{
+		BaseURL:    srv.URL,
+		APIKey:     "test-key",
+		HTTPClient: srv.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestModel_Generate(t *testing.T) {
+	var gotBody map[string]any
+	srv := newTestServer(t, &gotBody, func(w http.ResponseWriter) {
+		fmt.Fprint(w, `{
+			"id": "msg_1",
+			"type": "message",
+			"role": "assistant",
+			"model": "claude-test",
+			"content": [
+				{"type": "thinking", "thinking": "Need the weather.", "signature": "sig1"},
+				{"type": "text", "text": "Checking."},
+				{"type": "tool_use", "id": "toolu_abc", "name": "get_weather", "input": {"city": "Paris"}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 10, "output_tokens": 5, "cache_read_input_tokens": 30}
+		}`)
+	})
+
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{
+			genai.NewContentFromText("What's the weather in Rome?", genai.RoleUser),
+			{Role: genai.RoleModel, Parts: []*genai.Part{
+				{Text: "unsigned thought", Thought: true},
+				{FunctionCall: &genai.FunctionCall{Name: "get_weather", Args: map[string]any{"city": "Rome"}}},
+				{Text: "signed thought", Thought: true, ThoughtSignature: []byte("sig0")},
+			}},
+			{Role: genai.RoleUser, Parts: []*genai.Part{
+				{FunctionResponse: &genai.FunctionResponse{Name: "get_weather", Response: map[string]any{"temp": 21.0}}},
+			}},
+			{Role: genai.RoleUser, Parts: []*genai.Part{
+				{Text: "And Paris?"},
+				{InlineData: &genai.Blob{MIMEType: "image/png", Data: []byte("png")}},
+			}},
+		},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("You are a weather bot.", genai.RoleUser),
+			Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{
+				Name:        "get_weather",
+				Description: "Returns the weather.",
+				Parameters: &genai.Schema{
+					Type:       genai.TypeObject,
+					Properties: map[string]*genai.Schema{"city": {Type: genai.TypeString}},
+					Required:   []string{"city"},
+				},
+			}}}},
+			ToolConfig: &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeAuto}},
+		},
+	}
+
+	var got []*model.LLMResponse
+	for resp, err := range newTestModel(t, srv).GenerateContent(t.Context(), req, false) {
+		if err != nil {
+			t.Fatalf("GenerateContent() error = %v", err)
+		}
+		got = append(got, resp)
+	}
+
+	wantBody := map[string]any{
+		"model":      "claude-test",
+		"max_tokens": float64(defaultMaxTokens),
+		"system":     "You are a weather bot.",
+		"messages": []any{
+			map[string]any{"role": "user", "content": []any{
+				map[string]any{"type": "text", "text": "What's the weather in Rome?"},
+			}},
+			map[string]any{"role": "assistant", "content": []any{
+				map[string]any{"type": "thinking", "thinking": "signed thought", "signature": "sig0"},
+				map[string]any{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": map[string]any{"city": "Rome"}},
+			}},
+			map[string]any{"role": "user", "content": []any{
+				map[string]any{"type": "tool_result", "tool_use_id": "toolu_1", "content": `{"temp":21}`},
+				map[string]any{"type": "text", "text": "And Paris?"},
+				map[string]any{"type": "image", "source": map[string]any{"type": "base64", "media_type": "image/png", "data": "cG5n"}},
+			}},
+		},
+		"tools": []any{map[string]any{
+			"name":        "get_weather",
+			"description": "Returns the weather.",
+			"input_schema": map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"city": map[string]any{"type": "string"}},
+				"required":   []any{"city"},
+			},
+		}},
+		"tool_choice": map[string]any{"type": "auto"},
+	}
+	if diff := cmp.Diff(wantBody, gotBody); diff != "" {
+		t.Errorf("request body mismatch (-want +got):\n%s", diff)
+	}
+
+	want := []*model.LLMResponse{{
+		Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+			{Text: "Need the weather.", Thought: true, ThoughtSignature: []byte("sig1")},
+			{Text: "Checking."},
+			{FunctionCall: &genai.FunctionCall{ID: "toolu_abc", Name: "get_weather", Args: map[string]any{"city": "Paris"}}},
+		}},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:        40,
+			CachedContentTokenCount: 30,
+			CandidatesTokenCount:    5,
+			TotalTokenCount:         45,
+		},
+		FinishReason: genai.FinishReasonStop,
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GenerateContent() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_GenerateStream(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_1","model":"claude-test","role":"assistant","content":[],"usage":{"input_tokens":12,"output_tokens":1}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Roll a die."}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+		`{"type":"ping"}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Rolling"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":" now."}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_1","name":"roll","input":{}}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"sides\":"}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":" 6}"}}`,
+		`{"type":"content_block_stop","index":2}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":20}}`,
+		`{"type":"message_stop"}`,
+	}
+	var gotBody map[string]any
+	srv := newTestServer(t, &gotBody, func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, ev := range events {
+			var typ struct {
+				Type string `json:"type"`
+			}
+			_ = json.Unmarshal([]byte(ev), &typ)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typ.Type, ev)
+		}
+	})
+
+	var got []*model.LLMResponse
+	for resp, err := range newTestModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{Contents: genai.Text("Roll a die")}, true) {
+		if err != nil {
+			t.Fatalf("GenerateContent() error = %v", err)
+		}
+		got = append(got, resp)
+	}
+
+	if gotBody["stream"] != true {
+		t.Errorf("request stream = %v, want true", gotBody["stream"])
+	}
+	usage := &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 12, CandidatesTokenCount: 20, TotalTokenCount: 32}
+	want := []*model.LLMResponse{
+		{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{Text: "Roll a die.", Thought: true}}}, Partial: true},
+		{Content: genai.NewContentFromText("Rolling", genai.RoleModel), Partial: true},
+		{Content: genai.NewContentFromText(" now.", genai.RoleModel), Partial: true},
+		{
+			Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+				{Text: "Roll a die.", Thought: true, ThoughtSignature: []byte("sig")},
+				{Text: "Rolling now."},
+			}},
+			UsageMetadata: usage,
+			FinishReason:  genai.FinishReasonStop,
+		},
+		{
+			Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+				{FunctionCall: &genai.FunctionCall{ID: "toolu_1", Name: "roll", Args: map[string]any{"sides": 6.0}}},
+			}},
+			UsageMetadata: usage,
+			FinishReason:  genai.FinishReasonStop,
+			TurnComplete:  true,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GenerateContent() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_Generate_APIError(t *testing.T) {
+	srv := newTestServer(t, nil, func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(529)
+		fmt.Fprint(w, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)
+	})
+
+	for _, stream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stream=%v", stream), func(t *testing.T) {
+			var gotErr error
+			for _, err := range newTestModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{Contents: genai.Text("Hi")}, stream) {
+				gotErr = err
+			}
+			var apiErr *APIError
+			if !errors.As(gotErr, &apiErr) {
+				t.Fatalf("GenerateContent() error = %v, want *APIError", gotErr)
+			}
+			if apiErr.StatusCode != 529 || apiErr.Type != "overloaded_error" {
+				t.Errorf("GenerateContent() error = %+v, want status 529 and type overloaded_error", apiErr)
+			}
+			if got, want := apiErr.RetryAfter, 2*time.Second; got != want {
+				t.Errorf("RetryAfter = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestModel_GenerateStream_Error(t *testing.T) {
+	srv := newTestServer(t, nil, func(w http.ResponseWriter) {
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"model\":\"claude-test\"}}\n\n")
+		fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	})
+
+	var gotErr error
+	for _, err := range newTestModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{Contents: genai.Text("Hi")}, true) {
+		gotErr = err
+	}
+	var apiErr *APIError
+	if !errors.As(gotErr, &apiErr) || apiErr.Type != "overloaded_error" {
+		t.Fatalf("GenerateContent() error = %v, want overloaded_error", gotErr)
+	}
+}
@@ -0,0 +1,636 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/llminternal/converters"
+	"google.golang.org/adk/model"
+)
+
+// defaultMaxTokens is used when GenerateContentConfig.MaxOutputTokens is not
+// set, since max_tokens is required by the Messages API.
+const defaultMaxTokens = 4096
+
+// Wire types of the Messages API. Only the fields used by the converters
+// below are declared.
+
+type messagesRequest struct {
+	Model         string      `json:"model"`
+	MaxTokens     int32       `json:"max_tokens"`
+	System        string      `json:"system,omitempty"`
+	Messages      []*message  `json:"messages"`
+	Tools         []*toolDef  `json:"tools,omitempty"`
+	ToolChoice    *toolChoice `json:"tool_choice,omitempty"`
+	Temperature   *float32    `json:"temperature,omitempty"`
+	TopP          *float32    `json:"top_p,omitempty"`
+	TopK          *int32      `json:"top_k,omitempty"`
+	StopSequences []string    `json:"stop_sequences,omitempty"`
+	Thinking      *thinking   `json:"thinking,omitempty"`
+	Stream        bool        `json:"stream,omitempty"`
+}
+
+type message struct {
+	Role    string   `json:"role"`
+	Content []*block `json:"content"`
+}
+
+// block is a content block of a message. The set fields depend on the Type.
+type block struct {
+	Type string `json:"type"`
+	// text
+	Text string `json:"text,omitempty"`
+	// image, document
+	Source *source `json:"source,omitempty"`
+	// tool_use
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Input any    `json:"input,omitempty"`
+	// tool_result
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+	IsError   bool   `json:"is_error,omitempty"`
+	// thinking
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+type source struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type toolDef struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+type toolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+type thinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int32  `json:"budget_tokens"`
+}
+
+type messageResponse struct {
+	ID         string   `json:"id"`
+	Model      string   `json:"model"`
+	Role       string   `json:"role"`
+	Content    []*block `json:"content"`
+	StopReason string   `json:"stop_reason"`
+	Usage      *usage   `json:"usage"`
+}
+
+type usage struct {
+	InputTokens              int32 `json:"input_tokens"`
+	OutputTokens             int32 `json:"output_tokens"`
+	CacheCreationInputTokens int32 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int32 `json:"cache_read_input_tokens"`
+}
+
+type streamEvent struct {
+	Type         string           `json:"type"`
+	Message      *messageResponse `json:"message"`
+	Index        int              `json:"index"`
+	ContentBlock *block           `json:"content_block"`
+	Delta        *streamDelta     `json:"delta"`
+	Usage        *usage           `json:"usage"`
+	Error        *APIError        `json:"error"`
+}
+
+type streamDelta struct {
+	Type        string `json:"type"`
+	Text        string `json:"text"`
+	PartialJSON string `json:"partial_json"`
+	Thinking    string `json:"thinking"`
+	Signature   string `json:"signature"`
+	StopReason  string `json:"stop_reason"`
+}
+
+// toMessagesRequest converts the LLMRequest into a Messages API request.
+func toMessagesRequest(modelName string, req *model.LLMRequest) (*messagesRequest, error) {
+	cfg := req.Config
+	if cfg == nil {
+		cfg = &genai.GenerateContentConfig{}
+	}
+
+	msgReq := &messagesRequest{
+		Model:         modelName,
+		MaxTokens:     cfg.MaxOutputTokens,
+		System:        contentText(cfg.SystemInstruction),
+		Temperature:   cfg.Temperature,
+		TopP:          cfg.TopP,
+		StopSequences: cfg.StopSequences,
+	}
+	if msgReq.MaxTokens == 0 {
+		msgReq.MaxTokens = defaultMaxTokens
+	}
+	if cfg.TopK != nil {
+		msgReq.TopK = genai.Ptr(int32(*cfg.TopK))
+	}
+	if tc := cfg.ThinkingConfig; tc != nil && tc.ThinkingBudget != nil && *tc.ThinkingBudget > 0 {
+		msgReq.Thinking = &thinking{Type: "enabled", BudgetTokens: *tc.ThinkingBudget}
+	}
+
+	ids := &toolUseIDs{}
+	for _, c := range req.Contents {
+		if c == nil {
+			continue
+		}
+		msg, err := toMessage(c, ids)
+		if err != nil {
+			return nil, err
+		}
+		if msg == nil {
+			continue
+		}
+		// The API requires alternating roles, so merge consecutive messages
+		// of the same role, e.g. a function response followed by user text.
+		if n := len(msgReq.Messages); n > 0 && msgReq.Messages[n-1].Role == msg.Role {
+			msgReq.Messages[n-1].Content = append(msgReq.Messages[n-1].Content, msg.Content...)
+			continue
+		}
+		msgReq.Messages = append(msgReq.Messages, msg)
+	}
+	for _, msg := range msgReq.Messages {
+		sortBlocks(msg)
+	}
+
+	tools, err := toTools(req)
+	if err != nil {
+		return nil, err
+	}
+	msgReq.Tools = tools
+	if len(tools) > 0 && cfg.ToolConfig != nil {
+		msgReq.ToolChoice = toToolChoice(cfg.ToolConfig.FunctionCallingConfig)
+	}
+	return msgReq, nil
+}
+
+// sortBlocks moves the blocks that the API requires to come first in a
+// message to the front: thinking blocks in assistant messages and tool results
+// in user messages.
+func sortBlocks(msg *message) {
+	first := "tool_result"
+	if msg.Role == "assistant" {
+		first = "thinking"
+	}
+	slices.SortStableFunc(msg.Content, func(a, b *block) int {
+		switch {
+		case a.Type == first && b.Type != first:
+			return -1
+		case a.Type != first && b.Type == first:
+			return 1
+		}
+		return 0
+	})
+}
+
+// toolUseIDs keeps tool_use and tool_result IDs consistent.
+//
+// The Messages API requires every tool result to reference the ID of a
+// preceding tool use, but genai function calls may have no ID (ADK strips the
+// IDs it generated itself before calling the model). Missing IDs are
+// synthesized and matched with the responses by function name, in order.
+type toolUseIDs struct {
+	n       int
+	pending map[string][]string
+}
+
+func (t *toolUseIDs) call(fc *genai.FunctionCall) string {
+	if fc.ID != "" {
+		return fc.ID
+	}
+	t.n++
+	id := fmt.Sprintf("toolu_%d", t.n)
+	if t.pending == nil {
+		t.pending = make(map[string][]string)
+	}
+	t.pending[fc.Name] = append(t.pending[fc.Name], id)
+	return id
+}
+
+func (t *toolUseIDs) response(fr *genai.FunctionResponse) string {
+	if fr.ID != "" {
+		return fr.ID
+	}
+	if ids := t.pending[fr.Name]; len(ids) > 0 {
+		t.pending[fr.Name] = ids[1:]
+		return ids[0]
+	}
+	t.n++
+	return fmt.Sprintf("toolu_%d", t.n)
+}
+
+// toMessage converts a genai content into a message. It returns nil if the
+// content has nothing to send.
+func toMessage(c *genai.Content, ids *toolUseIDs) (*message, error) {
+	msg := &message{Role: "user"}
+	if c.Role == genai.RoleModel {
+		msg.Role = "assistant"
+	}
+	for _, p := range c.Parts {
+		if p == nil {
+			continue
+		}
+		switch {
+		case p.Thought:
+			// Thinking can only be replayed together with its signature.
+			if p.Text != "" && len(p.ThoughtSignature) > 0 {
+				msg.Content = append(msg.Content, &block{Type: "thinking", Thinking: p.Text, Signature: string(p.ThoughtSignature)})
+			}
+		case p.FunctionCall != nil:
+			args := p.FunctionCall.Args
+			if args == nil {
+				args = map[string]any{}
+			}
+			msg.Content = append(msg.Content, &block{Type: "tool_use", ID: ids.call(p.FunctionCall), Name: p.FunctionCall.Name, Input: args})
+		case p.FunctionResponse != nil:
+			resp, err := json.Marshal(p.FunctionResponse.Response)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal response of function %q: %w", p.FunctionResponse.Name, err)
+			}
+			_, isError := p.FunctionResponse.Response["error"]
+			msg.Content = append(msg.Content, &block{
+				Type:      "tool_result",
+				ToolUseID: ids.response(p.FunctionResponse),
+				Content:   string(resp),
+				IsError:   isError && len(p.FunctionResponse.Response) == 1,
+			})
+		case p.InlineData != nil:
+			b, err := blobBlock(p.InlineData)
+			if err != nil {
+				return nil, err
+			}
+			msg.Content = append(msg.Content, b)
+		case p.FileData != nil:
+			b, err := fileBlock(p.FileData)
+			if err != nil {
+				return nil, err
+			}
+			msg.Content = append(msg.Content, b)
+		default:
+			if text := partText(p); text != "" {
+				msg.Content = append(msg.Content, &block{Type: "text", Text: text})
+			}
+		}
+	}
+	if len(msg.Content) == 0 {
+		return nil, nil
+	}
+	return msg, nil
+}
+
+// partText returns the textual representation of the part, if any.
+func partText(p *genai.Part) string {
+	switch {
+	case p.Text != "":
+		return p.Text
+	case p.ExecutableCode != nil:
+		return fmt.Sprintf("```%s\n%s\n```", strings.ToLower(string(p.ExecutableCode.Language)), p.ExecutableCode.Code)
+	case p.CodeExecutionResult != nil:
+		return fmt.Sprintf("Code execution result:\n%s", p.CodeExecutionResult.Output)
+	}
+	return ""
+}
+
+func blobBlock(b *genai.Blob) (*block, error) {
+	data := base64.StdEncoding.EncodeToString(b.Data)
+	switch {
+	case strings.HasPrefix(b.MIMEType, "image/"):
+		return &block{Type: "image", Source: &source{Type: "base64", MediaType: b.MIMEType, Data: data}}, nil
+	case b.MIMEType == "application/pdf":
+		return &block{Type: "document", Source: &source{Type: "base64", MediaType: b.MIMEType, Data: data}}, nil
+	case b.MIMEType == "text/plain":
+		return &block{Type: "document", Source: &source{Type: "text", MediaType: b.MIMEType, Data: string(b.Data)}}, nil
+	}
+	return nil, fmt.Errorf("unsupported inline data MIME type %q", b.MIMEType)
+}
+
+func fileBlock(f *genai.FileData) (*block, error) {
+	switch {
+	case strings.HasPrefix(f.MIMEType, "image/"):
+		return &block{Type: "image", Source: &source{Type: "url", URL: f.FileURI}}, nil
+	case f.MIMEType == "application/pdf":
+		return &block{Type: "document", Source: &source{Type: "url", URL: f.FileURI}}, nil
+	}
+	return nil, fmt.Errorf("unsupported file data MIME type %q", f.MIMEType)
+}
+
+// contentText concatenates the text parts of the content.
+func contentText(c *genai.Content) string {
+	if c == nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range c.Parts {
+		if p != nil && p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// toTools collects the function declarations of the request.
+func toTools(req *model.LLMRequest) ([]*toolDef, error) {
+	var tools []*toolDef
+	seen := make(map[string]bool)
+	add := func(decl *genai.FunctionDeclaration) {
+		if decl == nil || seen[decl.Name] {
+			return
+		}
+		seen[decl.Name] = true
+		tools = append(tools, &toolDef{
+			Name:        decl.Name,
+			Description: decl.Description,
+			InputSchema: converters.FunctionParameters(decl),
+		})
+	}
+	if req.Config != nil {
+		for _, t := range req.Config.Tools {
+			if t == nil {
+				continue
+			}
+			if t.FunctionDeclarations == nil {
+				return nil, fmt.Errorf("only function declaration tools are supported by Anthropic models")
+			}
+			for _, decl := range t.FunctionDeclarations {
+				add(decl)
+			}
+		}
+	}
+	// Tools packed only into req.Tools (e.g. by custom tool implementations)
+	// are declared as well. Sort the names to keep the request stable.
+	names := make([]string, 0, len(req.Tools))
+	for name := range req.Tools {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if t, ok := req.Tools[name].(interface {
+			Declaration() *genai.FunctionDeclaration
+		}); ok {
+			add(t.Declaration())
+		}
+	}
+	return tools, nil
+}
+
+func toToolChoice(cfg *genai.FunctionCallingConfig) *toolChoice {
+	if cfg == nil {
+		return nil
+	}
+	switch cfg.Mode {
+	case genai.FunctionCallingConfigModeNone:
+		return &toolChoice{Type: "none"}
+	case genai.FunctionCallingConfigModeAny:
+		if len(cfg.AllowedFunctionNames) == 1 {
+			return &toolChoice{Type: "tool", Name: cfg.AllowedFunctionNames[0]}
+		}
+		return &toolChoice{Type: "any"}
+	case genai.FunctionCallingConfigModeAuto:
+		return &toolChoice{Type: "auto"}
+	}
+	return nil
+}
+
+// toGenaiResponse converts a complete message into its genai counterpart, so
+// that it can share the conversion to LLMResponse with the Gemini model.
+func toGenaiResponse(resp *messageResponse) (*genai.GenerateContentResponse, error) {
+	candidate := &genai.Candidate{FinishReason: toFinishReason(resp.StopReason)}
+	var parts []*genai.Part
+	for _, b := range resp.Content {
+		switch b.Type {
+		case "text":
+			parts = append(parts, &genai.Part{Text: b.Text})
+		case "thinking":
+			parts = append(parts, &genai.Part{Text: b.Thinking, Thought: true, ThoughtSignature: []byte(b.Signature)})
+		case "tool_use":
+			fc, err := toFunctionCall(b.ID, b.Name, b.Input)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, &genai.Part{FunctionCall: fc})
+		}
+	}
+	if len(parts) > 0 {
+		candidate.Content = &genai.Content{Role: genai.RoleModel, Parts: parts}
+	}
+	return &genai.GenerateContentResponse{
+		Candidates:    []*genai.Candidate{candidate},
+		ModelVersion:  resp.Model,
+		ResponseID:    resp.ID,
+		UsageMetadata: toUsageMetadata(resp.Usage),
+	}, nil
+}
+
+func toFunctionCall(id, name string, input any) (*genai.FunctionCall, error) {
+	switch in := input.(type) {
+	case nil:
+		return &genai.FunctionCall{ID: id, Name: name, Args: map[string]any{}}, nil
+	case map[string]any:
+		return &genai.FunctionCall{ID: id, Name: name, Args: in}, nil
+	}
+	return nil, fmt.Errorf("unexpected input type %T for tool use %q", input, name)
+}
+
+func toFinishReason(reason string) genai.FinishReason {
+	switch reason {
+	case "":
+		return ""
+	case "end_turn", "stop_sequence", "tool_use", "pause_turn":
+		return genai.FinishReasonStop
+	case "max_tokens":
+		return genai.FinishReasonMaxTokens
+	case "refusal":
+		return genai.FinishReasonSafety
+	}
+	return genai.FinishReasonOther
+}
+
+func toUsageMetadata(u *usage) *genai.GenerateContentResponseUsageMetadata {
+	if u == nil {
+		return nil
+	}
+	// input_tokens doesn't include the tokens read from or written to the
+	// prompt cache, while Gemini's prompt token count does.
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:        prompt,
+		CachedContentTokenCount: u.CacheReadInputTokens,
+		CandidatesTokenCount:    u.OutputTokens,
+		TotalTokenCount:         prompt + u.OutputTokens,
+	}
+}
+
+// streamAccumulator turns stream events into genai responses suitable for the
+// streaming response aggregator.
+type streamAccumulator struct {
+	// pending is the last text response, held back until it's known whether
+	// it is the final one.
+	pending    *genai.GenerateContentResponse
+	toolUses   []*toolUse
+	signatures []string
+	stopReason string
+	usage      *usage
+	model      string
+}
+
+type toolUse struct {
+	index int
+	id    string
+	name  string
+	input strings.Builder
+}
+
+// add processes the event and returns the responses ready to be emitted.
+func (a *streamAccumulator) add(ev *streamEvent) ([]*genai.GenerateContentResponse, error) {
+	switch ev.Type {
+	case "message_start":
+		if ev.Message != nil {
+			a.model = ev.Message.Model
+			a.usage = ev.Message.Usage
+		}
+	case "content_block_start":
+		if b := ev.ContentBlock; b != nil {
+			switch b.Type {
+			case "tool_use":
+				a.toolUses = append(a.toolUses, &toolUse{index: ev.Index, id: b.ID, name: b.Name})
+			case "text":
+				return a.text(&genai.Part{Text: b.Text}), nil
+			case "thinking":
+				return a.text(&genai.Part{Text: b.Thinking, Thought: true}), nil
+			}
+		}
+	case "content_block_delta":
+		d := ev.Delta
+		if d == nil {
+			return nil, nil
+		}
+		switch d.Type {
+		case "text_delta":
+			return a.text(&genai.Part{Text: d.Text}), nil
+		case "thinking_delta":
+			return a.text(&genai.Part{Text: d.Thinking, Thought: true}), nil
+		case "signature_delta":
+			a.signatures = append(a.signatures, d.Signature)
+		case "input_json_delta":
+			for _, tu := range a.toolUses {
+				if tu.index == ev.Index {
+					tu.input.WriteString(d.PartialJSON)
+				}
+			}
+		}
+	case "message_delta":
+		if ev.Delta != nil && ev.Delta.StopReason != "" {
+			a.stopReason = ev.Delta.StopReason
+		}
+		if ev.Usage != nil {
+			if a.usage == nil {
+				a.usage = &usage{}
+			}
+			// Output tokens are cumulative.
+			a.usage.OutputTokens = ev.Usage.OutputTokens
+		}
+	}
+	return nil, nil
+}
+
+// text holds the part back and returns the previously held response, if any.
+func (a *streamAccumulator) text(p *genai.Part) []*genai.GenerateContentResponse {
+	if p.Text == "" {
+		return nil
+	}
+	var ready []*genai.GenerateContentResponse
+	if a.pending != nil {
+		ready = append(ready, a.pending)
+	}
+	a.pending = a.newResponse(&genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{p}})
+	return ready
+}
+
+// close returns the remaining responses once the stream is over. The last one
+// carries the finish reason and usage metadata.
+func (a *streamAccumulator) close() ([]*genai.GenerateContentResponse, error) {
+	var ready []*genai.GenerateContentResponse
+	last := a.pending
+	if len(a.toolUses) > 0 {
+		if a.pending != nil {
+			ready = append(ready, a.pending)
+		}
+		content := &genai.Content{Role: genai.RoleModel}
+		for _, tu := range a.toolUses {
+			var input any
+			if s := strings.TrimSpace(tu.input.String()); s != "" {
+				if err := json.Unmarshal([]byte(s), &input); err != nil {
+					return nil, fmt.Errorf("failed to parse input of tool use %q: %w", tu.name, err)
+				}
+			}
+			fc, err := toFunctionCall(tu.id, tu.name, input)
+			if err != nil {
+				return nil, err
+			}
+			content.Parts = append(content.Parts, &genai.Part{FunctionCall: fc})
+		}
+		last = a.newResponse(content)
+	}
+	if last == nil {
+		last = a.newResponse(nil)
+	}
+	last.Candidates[0].FinishReason = toFinishReason(a.stopReason)
+	last.UsageMetadata = toUsageMetadata(a.usage)
+	return append(ready, last), nil
+}
+
+// attachSignature sets the signature of the thinking block on the aggregated
+// thought part, since the aggregator only keeps the text. Without it the
+// thinking couldn't be sent back to the model in the following requests.
+func (a *streamAccumulator) attachSignature(resp *model.LLMResponse) {
+	if resp == nil || resp.Partial || resp.Content == nil || len(a.signatures) == 0 {
+		return
+	}
+	for _, p := range resp.Content.Parts {
+		if !p.Thought || len(p.ThoughtSignature) > 0 {
+			continue
+		}
+		// Thinking blocks are concatenated by the aggregator, so a single
+		// signature can only be attributed when there was one block.
+		if len(a.signatures) == 1 {
+			p.ThoughtSignature = []byte(a.signatures[0])
+		}
+		a.signatures = nil
+		return
+	}
+}
+
+func (a *streamAccumulator) newResponse(c *genai.Content) *genai.GenerateContentResponse {
+	return &genai.GenerateContentResponse{
+		Candidates:   []*genai.Candidate{{Content: c}},
+		ModelVersion: a.model,
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/genai"
+)
+
+// Embedder provides the access to an embedding model, which maps contents to
+// vectors whose distance reflects their semantic similarity.
+type Embedder interface {
+	Name() string
+	// Embed returns one embedding per content of the request, in order.
+	Embed(ctx context.Context, req *EmbedRequest) (*EmbedResponse, error)
+}
+
+// TaskType is the intended use of embeddings. Some embedding models use it
+// to compute embeddings better suited to the task.
+type TaskType string
+
+const (
+	TaskTypeUnspecified        TaskType = ""
+	TaskTypeRetrievalQuery     TaskType = "RETRIEVAL_QUERY"
+	TaskTypeRetrievalDocument  TaskType = "RETRIEVAL_DOCUMENT"
+	TaskTypeSemanticSimilarity TaskType = "SEMANTIC_SIMILARITY"
+	TaskTypeClassification     TaskType = "CLASSIFICATION"
+	TaskTypeClustering         TaskType = "CLUSTERING"
+	TaskTypeQuestionAnswering  TaskType = "QUESTION_ANSWERING"
+	TaskTypeFactVerification   TaskType = "FACT_VERIFICATION"
+	TaskTypeCodeRetrievalQuery TaskType = "CODE_RETRIEVAL_QUERY"
+)
+
+// EmbedRequest is the raw embedding request.
+type EmbedRequest struct {
+	// Contents to embed. Text-only models embed the text parts, while
+	// multimodal models embed the other parts, such as images, as well.
+	Contents []*genai.Content
+	// TaskType is the intended use of the embeddings. Optional.
+	TaskType TaskType
+	// Dimensionality is the size of the embeddings. Zero means the default
+	// size of the model.
+	Dimensionality int
+	// Title of the contents, only used with [TaskTypeRetrievalDocument].
+	// Optional.
+	Title string
+}
+
+// EmbedResponse is the raw embedding response.
+type EmbedResponse struct {
+	// Embeddings of the contents, in the order of the request.
+	Embeddings [][]float32
+}
+
+// EmbedTexts returns the embeddings of the texts.
+func EmbedTexts(ctx context.Context, e Embedder, taskType TaskType, texts ...string) ([][]float32, error) {
+	req := &EmbedRequest{TaskType: taskType}
+	for _, t := range texts {
+		req.Contents = append(req.Contents, genai.NewContentFromText(t, genai.RoleUser))
+	}
+	resp, err := e.Embed(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("embedder %q returned %d embeddings for %d texts", e.Name(), len(resp.Embeddings), len(texts))
+	}
+	return resp.Embeddings, nil
+}
@@ -0,0 +1,31 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// APIError is implemented by the errors of the model providers reporting a
+// failed API call. It lets the retries and the fallbacks of the models tell
+// the transient failures apart without depending on the providers.
+type APIError interface {
+	error
+	// HTTPStatusCode returns the HTTP status code of the failed call, or the
+	// code matching the failure reported in the middle of a stream. It's
+	// zero if the failure has no such code.
+	HTTPStatusCode() int
+	// RetryDelay returns the delay requested by the server before retrying
+	// the call, or zero if the server didn't request any.
+	RetryDelay() time.Duration
+}
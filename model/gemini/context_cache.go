@@ -0,0 +1,521 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+const (
+	defaultCacheTTL        = 30 * time.Minute
+	defaultCacheMinTokens  = 1024
+	defaultCacheMaxEntries = 16
+	// maxUncacheable bounds the number of prefixes remembered as
+	// uncacheable.
+	maxUncacheable = 1024
+	// cacheCharsPerToken is used to estimate the number of tokens of the
+	// prefix, without calling the model.
+	cacheCharsPerToken = 4
+)
+
+// CacheConfig configures the context caching of a [CachingModel].
+type CacheConfig struct {
+	// TTL is the time to live of the caches. A cache that is used when less
+	// than half of its TTL remains is extended. Defaults to 30 minutes.
+	TTL time.Duration
+	// MinTokens is the estimated number of tokens a prefix needs to be
+	// cached. The models reject caches that are too small. Defaults to 1024.
+	MinTokens int
+	// CacheHistoryAfter is the number of contents of a request that are not
+	// covered by its cache after which a new cache, covering the history of
+	// the conversation as well, is created. Zero means that only the system
+	// instruction and the tools are cached.
+	CacheHistoryAfter int
+	// MaxEntries is the maximum number of caches kept by the model. The
+	// least recently used cache is deleted to make room for a new one.
+	// Defaults to 16.
+	MaxEntries int
+}
+
+// CacheStats reports the use of the context caches.
+type CacheStats struct {
+	// Created is the number of caches created.
+	Created int
+	// Refreshed is the number of caches whose TTL was extended.
+	Refreshed int
+	// Deleted is the number of caches deleted, because they were evicted or
+	// didn't exist on the server anymore.
+	Deleted int
+	// DeleteErrors is the number of evicted caches that couldn't be deleted
+	// on the server. They're deleted by the server when they expire.
+	DeleteErrors int
+	// Hits is the number of model calls that used a cache.
+	Hits int
+	// Misses is the number of model calls that didn't use a cache.
+	Misses int
+	// CachedTokens is the number of prompt tokens read from the caches, as
+	// reported by the model. They are billed at a reduced rate.
+	CachedTokens int64
+}
+
+// cacheService is the subset of [genai.Caches] used by [CachingModel].
+type cacheService interface {
+	Create(ctx context.Context, model string, config *genai.CreateCachedContentConfig) (*genai.CachedContent, error)
+	Update(ctx context.Context, name string, config *genai.UpdateCachedContentConfig) (*genai.CachedContent, error)
+	Delete(ctx context.Context, name string, config *genai.DeleteCachedContentConfig) (*genai.DeleteCachedContentResponse, error)
+}
+
+// CachingModel is a [model.LLM] that caches the stable prefix of the
+// requests, their system instruction, tools and optionally the early history
+// of the conversation, in Gemini [genai.CachedContent], and reuses it across
+// the calls. The caching is transparent to the agents: the requests are sent
+// unchanged when no cache applies.
+//
+// See https://ai.google.dev/gemini-api/docs/caching.
+type CachingModel struct {
+	llm    model.LLM
+	caches cacheService
+	cfg    CacheConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	// creating holds the caches being created, by prefix.
+	creating map[string]*cacheCreation
+	// uncacheable holds the prefixes the server refused to cache.
+	uncacheable map[string]bool
+	stats       CacheStats
+}
+
+type cacheEntry struct {
+	name string
+	// head is the fingerprint of the system instruction and the tools.
+	head string
+	// prefix is the fingerprint of the head and the cached contents.
+	prefix string
+	// contents is the number of cached contents.
+	contents   int
+	expireTime time.Time
+	lastUsed   time.Time
+	// refreshing is set while the TTL of the cache is extended.
+	refreshing bool
+}
+
+// cacheCreation is a cache being created. Its entry, nil if the prefix
+// couldn't be cached, is set when done is closed.
+type cacheCreation struct {
+	done  chan struct{}
+	entry *cacheEntry
+}
+
+// NewCachingModel returns a [CachingModel] wrapping llm, which must be
+// created by [NewModel]. The caches are created with the client of llm.
+func NewCachingModel(llm model.LLM, cfg *CacheConfig) (*CachingModel, error) {
+	m, ok := llm.(*geminiModel)
+	if !ok {
+		return nil, fmt.Errorf("context caching requires a model created by gemini.NewModel, got %T", llm)
+	}
+	return newCachingModel(m, m.client.Caches, cfg), nil
+}
+
+func newCachingModel(llm model.LLM, caches cacheService, cfg *CacheConfig) *CachingModel {
+	if cfg == nil {
+		cfg = &CacheConfig{}
+	}
+	return &CachingModel{
+		llm:    llm,
+		caches: caches,
+		cfg: CacheConfig{
+			TTL:               cmp.Or(cfg.TTL, defaultCacheTTL),
+			MinTokens:         cmp.Or(cfg.MinTokens, defaultCacheMinTokens),
+			CacheHistoryAfter: cfg.CacheHistoryAfter,
+			MaxEntries:        cmp.Or(cfg.MaxEntries, defaultCacheMaxEntries),
+		},
+		now:         time.Now,
+		entries:     map[string]*cacheEntry{},
+		creating:    map[string]*cacheCreation{},
+		uncacheable: map[string]bool{},
+	}
+}
+
+func (m *CachingModel) Name() string {
+	return m.llm.Name()
+}
+
+// GenerateContent calls the underlying model, with the prefix of the request
+// replaced by a cache when possible.
+func (m *CachingModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		entry := m.cacheFor(ctx, req)
+		if entry == nil {
+			for resp, err := range m.llm.GenerateContent(ctx, req, stream) {
+				if !yield(resp, err) {
+					return
+				}
+			}
+			return
+		}
+
+		var cachedTokens int32
+		yielded := false
+		for resp, err := range m.llm.GenerateContent(ctx, cachedRequest(req, entry), stream) {
+			if err != nil && !yielded && isNotFound(err) {
+				// The cache expired or was deleted: send the full request.
+				m.drop(ctx, entry)
+				for resp, err := range m.llm.GenerateContent(ctx, req, stream) {
+					if !yield(resp, err) {
+						return
+					}
+				}
+				return
+			}
+			if resp != nil && resp.UsageMetadata != nil {
+				cachedTokens = resp.UsageMetadata.CachedContentTokenCount
+			}
+			yielded = true
+			if !yield(resp, err) {
+				break
+			}
+		}
+		m.mu.Lock()
+		m.stats.CachedTokens += int64(cachedTokens)
+		m.mu.Unlock()
+	}
+}
+
+// Stats returns the statistics of the context caches.
+func (m *CachingModel) Stats() CacheStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// Close deletes the caches created by the model.
+func (m *CachingModel) Close(ctx context.Context) error {
+	m.mu.Lock()
+	entries := m.entries
+	m.entries = map[string]*cacheEntry{}
+	m.stats.Deleted += len(entries)
+	m.mu.Unlock()
+
+	var errs []error
+	for _, e := range entries {
+		if _, err := m.caches.Delete(ctx, e.name, nil); err != nil && !isNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete cache %q: %w", e.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// cacheFor returns the cache to use for the request, creating or extending
+// it if needed, or nil if the request should be sent as is.
+//
+// The lock isn't held during the calls to the cache service, so that the
+// requests using other caches aren't delayed.
+func (m *CachingModel) cacheFor(ctx context.Context, req *model.LLMRequest) *cacheEntry {
+	if req.Config != nil && req.Config.CachedContent != "" {
+		// The caller manages the cache.
+		return nil
+	}
+	head := fingerprint(cacheHead(req))
+
+	m.mu.Lock()
+	now := m.now()
+	// Use the cache covering the longest prefix of the request.
+	var best *cacheEntry
+	for key, e := range m.entries {
+		if !now.Before(e.expireTime) {
+			delete(m.entries, key)
+			continue
+		}
+		if e.head != head || e.contents >= len(req.Contents) || (best != nil && e.contents <= best.contents) {
+			continue
+		}
+		if prefixFingerprint(head, req.Contents[:e.contents]) == e.prefix {
+			best = e
+		}
+	}
+	m.mu.Unlock()
+
+	if best == nil || (m.cfg.CacheHistoryAfter > 0 && len(req.Contents)-best.contents >= m.cfg.CacheHistoryAfter) {
+		if e := m.create(ctx, req, head); e != nil {
+			best = e
+		}
+	}
+
+	m.mu.Lock()
+	if best == nil {
+		m.stats.Misses++
+		m.mu.Unlock()
+		return nil
+	}
+	// A single request extends the cache.
+	refresh := !best.refreshing && best.expireTime.Sub(now) < m.cfg.TTL/2
+	best.refreshing = refresh
+	m.mu.Unlock()
+
+	if refresh && !m.refresh(ctx, best, now) {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	best.lastUsed = now
+	m.stats.Hits++
+	return best
+}
+
+// refresh extends the TTL of the cache. It returns false if the cache
+// doesn't exist on the server anymore.
+func (m *CachingModel) refresh(ctx context.Context, e *cacheEntry, now time.Time) bool {
+	cc, err := m.caches.Update(ctx, e.name, &genai.UpdateCachedContentConfig{TTL: m.cfg.TTL})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.refreshing = false
+	switch {
+	case err == nil:
+		e.expireTime = expireTime(cc, now, m.cfg.TTL)
+		m.stats.Refreshed++
+	case isNotFound(err):
+		if m.entries[e.prefix] == e {
+			delete(m.entries, e.prefix)
+			m.stats.Deleted++
+		}
+		m.stats.Misses++
+		return false
+	}
+	// On other errors, the cache is used until it expires.
+	return true
+}
+
+// create creates a cache for the prefix of the request: its system
+// instruction, tools and, when the history is cached, all its contents but
+// the last one. It returns nil if the prefix can't be cached.
+//
+// The concurrent requests with the same prefix wait for a single creation.
+func (m *CachingModel) create(ctx context.Context, req *model.LLMRequest, head string) *cacheEntry {
+	var contents []*genai.Content
+	if m.cfg.CacheHistoryAfter > 0 && len(req.Contents) > 1 {
+		contents = req.Contents[:len(req.Contents)-1]
+	}
+	prefix := prefixFingerprint(head, contents)
+
+	m.mu.Lock()
+	if m.uncacheable[prefix] {
+		m.mu.Unlock()
+		return nil
+	}
+	if e, ok := m.entries[prefix]; ok {
+		m.mu.Unlock()
+		return e
+	}
+	if c, ok := m.creating[prefix]; ok {
+		m.mu.Unlock()
+		select {
+		case <-c.done:
+			return c.entry
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	cfg := &genai.CreateCachedContentConfig{
+		TTL:      m.cfg.TTL,
+		Contents: contents,
+	}
+	if req.Config != nil {
+		cfg.SystemInstruction = req.Config.SystemInstruction
+		cfg.Tools = req.Config.Tools
+		cfg.ToolConfig = req.Config.ToolConfig
+	}
+	if estimateTokens(cfg) < m.cfg.MinTokens {
+		m.mu.Unlock()
+		return nil
+	}
+	c := &cacheCreation{done: make(chan struct{})}
+	m.creating[prefix] = c
+	m.mu.Unlock()
+
+	now := m.now()
+	cc, err := m.caches.Create(ctx, m.llm.Name(), cfg)
+
+	var evicted *cacheEntry
+	m.mu.Lock()
+	delete(m.creating, prefix)
+	switch {
+	case err == nil:
+		if len(m.entries) >= m.cfg.MaxEntries {
+			evicted = m.evict()
+		}
+		c.entry = &cacheEntry{
+			name:       cc.Name,
+			head:       head,
+			prefix:     prefix,
+			contents:   len(contents),
+			expireTime: expireTime(cc, now, m.cfg.TTL),
+		}
+		m.entries[prefix] = c.entry
+		m.stats.Created++
+	case ctx.Err() == nil:
+		// Most likely the prefix is too small or the model doesn't support
+		// caching: don't try again.
+		if len(m.uncacheable) >= maxUncacheable {
+			clear(m.uncacheable)
+		}
+		m.uncacheable[prefix] = true
+	}
+	m.mu.Unlock()
+	close(c.done)
+
+	if evicted != nil {
+		m.delete(ctx, evicted)
+	}
+	return c.entry
+}
+
+// evict forgets the least recently used cache, which must then be deleted.
+// The lock must be held.
+func (m *CachingModel) evict() *cacheEntry {
+	var oldest *cacheEntry
+	for _, e := range m.entries {
+		if oldest == nil || e.lastUsed.Before(oldest.lastUsed) {
+			oldest = e
+		}
+	}
+	if oldest != nil {
+		delete(m.entries, oldest.prefix)
+		m.stats.Deleted++
+	}
+	return oldest
+}
+
+// delete deletes an evicted cache on the server. The failures are counted
+// in the stats; the server deletes the cache when it expires.
+func (m *CachingModel) delete(ctx context.Context, e *cacheEntry) {
+	if _, err := m.caches.Delete(ctx, e.name, nil); err != nil && !isNotFound(err) {
+		m.mu.Lock()
+		m.stats.DeleteErrors++
+		m.mu.Unlock()
+	}
+}
+
+// drop forgets a cache that doesn't exist on the server anymore.
+func (m *CachingModel) drop(ctx context.Context, e *cacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[e.prefix]; ok {
+		delete(m.entries, e.prefix)
+		m.stats.Deleted++
+	}
+	m.stats.Hits--
+	m.stats.Misses++
+}
+
+// cachedRequest returns a copy of the request using the cache instead of
+// the prefix.
+func cachedRequest(req *model.LLMRequest, e *cacheEntry) *model.LLMRequest {
+	r := *req
+	cfg := genai.GenerateContentConfig{}
+	if req.Config != nil {
+		cfg = *req.Config
+	}
+	// The cached fields can't be set along with a cache.
+	cfg.SystemInstruction = nil
+	cfg.Tools = nil
+	cfg.ToolConfig = nil
+	cfg.CachedContent = e.name
+	if cfg.HTTPOptions != nil {
+		// The model sets its headers on the options.
+		opts := *cfg.HTTPOptions
+		opts.Headers = opts.Headers.Clone()
+		cfg.HTTPOptions = &opts
+	}
+	r.Config = &cfg
+	r.Contents = req.Contents[e.contents:]
+	return &r
+}
+
+// cacheHead returns the fields of the request that are always cached.
+func cacheHead(req *model.LLMRequest) any {
+	if req.Config == nil {
+		return nil
+	}
+	return []any{req.Config.SystemInstruction, req.Config.Tools, req.Config.ToolConfig}
+}
+
+// prefixFingerprint returns the fingerprint of a prefix made of the head and
+// the contents.
+func prefixFingerprint(head string, contents []*genai.Content) string {
+	if len(contents) == 0 {
+		return fingerprint(head)
+	}
+	return fingerprint(head, contents)
+}
+
+func fingerprint(vs ...any) string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, v := range vs {
+		if err := enc.Encode(v); err != nil {
+			// Unreachable for the genai types, but never share a cache in
+			// doubt.
+			fmt.Fprintf(h, "%p", v)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func estimateTokens(cfg *genai.CreateCachedContentConfig) int {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return 0
+	}
+	return len(b) / cacheCharsPerToken
+}
+
+func expireTime(cc *genai.CachedContent, now time.Time, ttl time.Duration) time.Time {
+	if cc != nil && !cc.ExpireTime.IsZero() {
+		return cc.ExpireTime
+	}
+	return now.Add(ttl)
+}
+
+func isNotFound(err error) bool {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusNotFound
+	}
+	var apiPtrErr *genai.APIError
+	if errors.As(err, &apiPtrErr) && apiPtrErr != nil {
+		return apiPtrErr.Code == http.StatusNotFound
+	}
+	return false
+}
+
+var _ model.LLM = (*CachingModel)(nil)
@@ -0,0 +1,248 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/modeltest"
+)
+
+func TestCachingModel(t *testing.T) {
+	ctx := t.Context()
+	instruction := genai.NewContentFromText(strings.Repeat("You are a helpful assistant. ", 20), genai.RoleUser)
+	tools := []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "get_weather"}}}}
+	request := func(instruction *genai.Content, texts ...string) *model.LLMRequest {
+		req := &model.LLMRequest{Config: &genai.GenerateContentConfig{SystemInstruction: instruction, Tools: tools}}
+		for i, text := range texts {
+			role := genai.RoleUser
+			if i%2 == 1 {
+				role = genai.RoleModel
+			}
+			req.Contents = append(req.Contents, genai.NewContentFromText(text, genai.Role(role)))
+		}
+		return req
+	}
+
+	caches := &fakeCaches{}
+	llm := modeltest.New("gemini-2.5-flash")
+	m := newCachingModel(llm, caches, &CacheConfig{TTL: 30 * time.Minute, MinTokens: 100, CacheHistoryAfter: 4})
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	call := func(req *model.LLMRequest, turn modeltest.Turn) *model.LLMRequest {
+		t.Helper()
+		llm.Append(turn)
+		for _, err := range m.GenerateContent(ctx, req, false) {
+			if err != nil {
+				t.Fatalf("GenerateContent() error = %v", err)
+			}
+		}
+		return llm.LastRequest()
+	}
+	withCachedTokens := func(n int32) modeltest.Turn {
+		turn := modeltest.Text("ok")
+		turn.Responses[0].UsageMetadata = &genai.GenerateContentResponseUsageMetadata{CachedContentTokenCount: n}
+		return turn
+	}
+
+	// The first call creates a cache for the instruction and the tools.
+	got := call(request(instruction, "Hi"), withCachedTokens(150))
+	if got.Config.CachedContent != "cachedContents/1" || got.Config.SystemInstruction != nil || got.Config.Tools != nil {
+		t.Errorf("request config = %+v, want the cache instead of the instruction and tools", got.Config)
+	}
+	if diff := cmp.Diff([]string{"user: Hi"}, modeltest.Contents(got)); diff != "" {
+		t.Errorf("request contents mismatch (-want +got):\n%s", diff)
+	}
+
+	// The next step reuses it.
+	got = call(request(instruction, "Hi", "Hello", "Weather?"), withCachedTokens(150))
+	if got.Config.CachedContent != "cachedContents/1" || len(got.Contents) != 3 {
+		t.Errorf("request = %+v, want cachedContents/1 with the 3 contents", got)
+	}
+
+	// Once enough contents aren't cached, the history is cached as well.
+	now = now.Add(20 * time.Minute)
+	got = call(request(instruction, "Hi", "Hello", "Weather?", "Sunny", "Thanks"), modeltest.Text("ok"))
+	if got.Config.CachedContent != "cachedContents/2" {
+		t.Errorf("request cache = %q, want cachedContents/2", got.Config.CachedContent)
+	}
+	if diff := cmp.Diff([]string{"user: Thanks"}, modeltest.Contents(got)); diff != "" {
+		t.Errorf("request contents mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"user: Hi", "model: Hello", "user: Weather?", "model: Sunny"}, modeltest.Contents(&model.LLMRequest{Contents: caches.created[1].Contents})); diff != "" {
+		t.Errorf("cached contents mismatch (-want +got):\n%s", diff)
+	}
+
+	// Another conversation uses the cache of the instruction and tools,
+	// which is extended since it's close to its expiration.
+	got = call(request(instruction, "Bonjour"), modeltest.Text("ok"))
+	if got.Config.CachedContent != "cachedContents/1" {
+		t.Errorf("request cache = %q, want cachedContents/1", got.Config.CachedContent)
+	}
+
+	// A new instruction invalidates the caches.
+	other := genai.NewContentFromText(strings.Repeat("You are a grumpy assistant. ", 20), genai.RoleUser)
+	got = call(request(other, "Hi"), modeltest.Text("ok"))
+	if got.Config.CachedContent != "cachedContents/3" {
+		t.Errorf("request cache = %q, want cachedContents/3", got.Config.CachedContent)
+	}
+
+	// A cache that doesn't exist on the server anymore is forgotten, and the
+	// request is sent in full.
+	llm.Append(modeltest.Error(genai.APIError{Code: http.StatusNotFound, Message: "CachedContent not found"}))
+	got = call(request(other, "Hi"), modeltest.Text("ok"))
+	if got.Config.CachedContent != "" || got.Config.SystemInstruction == nil {
+		t.Errorf("request config = %+v, want the full request", got.Config)
+	}
+
+	// A short prefix isn't cached.
+	got = call(request(genai.NewContentFromText("Be brief.", genai.RoleUser), "Hi"), modeltest.Text("ok"))
+	if got.Config.CachedContent != "" {
+		t.Errorf("request cache = %q, want no cache", got.Config.CachedContent)
+	}
+
+	if err := m.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	want := CacheStats{Created: 3, Refreshed: 1, Deleted: 3, Hits: 5, Misses: 2, CachedTokens: 300}
+	if diff := cmp.Diff(want, m.Stats()); diff != "" {
+		t.Errorf("Stats() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"cachedContents/1"}, caches.updated); diff != "" {
+		t.Errorf("updated caches mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCachingModel_Concurrency(t *testing.T) {
+	instruction := func(s string) *model.LLMRequest {
+		return &model.LLMRequest{
+			Config:   &genai.GenerateContentConfig{SystemInstruction: genai.NewContentFromText(strings.Repeat(s, 100), genai.RoleUser)},
+			Contents: []*genai.Content{genai.NewContentFromText("Hi", genai.RoleUser)},
+		}
+	}
+	slow, fast := instruction("Slow. "), instruction("Fast. ")
+
+	// The creation of the cache of the slow instruction blocks until
+	// released.
+	createStarted, release := make(chan struct{}), make(chan struct{})
+	caches := &fakeCaches{beforeCreate: func(config *genai.CreateCachedContentConfig) {
+		if config.SystemInstruction == slow.Config.SystemInstruction {
+			close(createStarted)
+			<-release
+		}
+	}}
+	m := newCachingModel(modeltest.New("gemini-2.5-flash"), caches, &CacheConfig{MinTokens: 100})
+
+	var wg sync.WaitGroup
+	var slowEntries [2]*cacheEntry
+	for i := range slowEntries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slowEntries[i] = m.cacheFor(t.Context(), slow)
+		}()
+	}
+	<-createStarted
+
+	// The requests with another prefix don't wait for the creation.
+	if e := m.cacheFor(t.Context(), fast); e == nil || e.name != "cachedContents/1" {
+		t.Errorf("cacheFor(fast) = %+v, want cachedContents/1", e)
+	}
+
+	close(release)
+	wg.Wait()
+	// The concurrent requests with the same prefix share a single cache.
+	for _, e := range slowEntries {
+		if e == nil || e.name != "cachedContents/2" {
+			t.Errorf("cacheFor(slow) = %+v, want cachedContents/2", e)
+		}
+	}
+	if got := m.Stats(); got.Created != 2 || got.Hits != 3 {
+		t.Errorf("Stats() = %+v, want 2 caches created and 3 hits", got)
+	}
+}
+
+func TestCachingModel_EvictionDeleteError(t *testing.T) {
+	caches := &fakeCaches{deleteErr: errors.New("unavailable")}
+	m := newCachingModel(modeltest.New("gemini-2.5-flash"), caches, &CacheConfig{MinTokens: 100, MaxEntries: 1})
+	for _, s := range []string{"First. ", "Second. "} {
+		req := &model.LLMRequest{
+			Config:   &genai.GenerateContentConfig{SystemInstruction: genai.NewContentFromText(strings.Repeat(s, 100), genai.RoleUser)},
+			Contents: []*genai.Content{genai.NewContentFromText("Hi", genai.RoleUser)},
+		}
+		if e := m.cacheFor(t.Context(), req); e == nil {
+			t.Fatalf("cacheFor(%q) = nil, want a cache", s)
+		}
+	}
+	if diff := cmp.Diff([]string{"cachedContents/1"}, caches.deleted); diff != "" {
+		t.Errorf("deleted caches mismatch (-want +got):\n%s", diff)
+	}
+	if got := m.Stats(); got.Deleted != 1 || got.DeleteErrors != 1 {
+		t.Errorf("Stats() = %+v, want 1 cache deleted with an error", got)
+	}
+}
+
+func TestNewCachingModel(t *testing.T) {
+	if _, err := NewCachingModel(modeltest.New("gemini-2.5-flash"), nil); err == nil {
+		t.Error("NewCachingModel() with a non-Gemini model succeeded, want error")
+	}
+}
+
+type fakeCaches struct {
+	// beforeCreate, if set, is called at the start of Create.
+	beforeCreate func(config *genai.CreateCachedContentConfig)
+	deleteErr    error
+
+	mu      sync.Mutex
+	created []*genai.CreateCachedContentConfig
+	updated []string
+	deleted []string
+}
+
+func (c *fakeCaches) Create(ctx context.Context, model string, config *genai.CreateCachedContentConfig) (*genai.CachedContent, error) {
+	if c.beforeCreate != nil {
+		c.beforeCreate(config)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.created = append(c.created, config)
+	return &genai.CachedContent{Name: fmt.Sprintf("cachedContents/%d", len(c.created)), Model: model}, nil
+}
+
+func (c *fakeCaches) Update(ctx context.Context, name string, config *genai.UpdateCachedContentConfig) (*genai.CachedContent, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.updated = append(c.updated, name)
+	return &genai.CachedContent{Name: name}, nil
+}
+
+func (c *fakeCaches) Delete(ctx context.Context, name string, config *genai.DeleteCachedContentConfig) (*genai.DeleteCachedContentResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deleted = append(c.deleted, name)
+	return &genai.DeleteCachedContentResponse{}, c.deleteErr
+}
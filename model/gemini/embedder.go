@@ -0,0 +1,91 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/version"
+	"google.golang.org/adk/model"
+)
+
+// maxEmbedBatchSize is the maximum number of contents per embedding call
+// accepted by the Gemini API.
+const maxEmbedBatchSize = 100
+
+type geminiEmbedder struct {
+	client             *genai.Client
+	name               string
+	versionHeaderValue string
+}
+
+// NewEmbedder returns [model.Embedder], backed by the Gemini API.
+//
+// The modelName specifies which embedding model to target
+// (e.g., "gemini-embedding-001"). Requests with more contents than the API
+// accepts in one call are split in several calls.
+func NewEmbedder(ctx context.Context, modelName string, cfg *genai.ClientConfig) (model.Embedder, error) {
+	client, err := genai.NewClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &geminiEmbedder{
+		name:   modelName,
+		client: client,
+		versionHeaderValue: fmt.Sprintf("google-adk/%s gl-go/%s", version.Version,
+			strings.TrimPrefix(runtime.Version(), "go")),
+	}, nil
+}
+
+func (e *geminiEmbedder) Name() string {
+	return e.name
+}
+
+// Embed calls the underlying embedding model.
+func (e *geminiEmbedder) Embed(ctx context.Context, req *model.EmbedRequest) (*model.EmbedResponse, error) {
+	headers := make(http.Header)
+	headers.Set("x-goog-api-client", e.versionHeaderValue)
+	headers.Set("user-agent", e.versionHeaderValue)
+	cfg := &genai.EmbedContentConfig{
+		TaskType:    string(req.TaskType),
+		Title:       req.Title,
+		HTTPOptions: &genai.HTTPOptions{Headers: headers},
+	}
+	if req.Dimensionality > 0 {
+		cfg.OutputDimensionality = genai.Ptr(int32(req.Dimensionality))
+	}
+
+	resp := &model.EmbedResponse{Embeddings: make([][]float32, 0, len(req.Contents))}
+	for start := 0; start < len(req.Contents); start += maxEmbedBatchSize {
+		batch := req.Contents[start:min(start+maxEmbedBatchSize, len(req.Contents))]
+		r, err := e.client.Models.EmbedContent(ctx, e.name, batch, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call embedding model: %w", err)
+		}
+		if len(r.Embeddings) != len(batch) {
+			return nil, fmt.Errorf("embedding model returned %d embeddings for %d contents", len(r.Embeddings), len(batch))
+		}
+		for _, emb := range r.Embeddings {
+			resp.Embeddings = append(resp.Embeddings, emb.Values)
+		}
+	}
+	return resp, nil
+}
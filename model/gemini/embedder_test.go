@@ -0,0 +1,99 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+func TestEmbedder_Embed(t *testing.T) {
+	type embedRequest struct {
+		Model                string         `json:"model"`
+		Content              *genai.Content `json:"content"`
+		TaskType             string         `json:"taskType"`
+		OutputDimensionality int            `json:"outputDimensionality"`
+	}
+	var batches [][]embedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Requests []embedRequest `json:"requests"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		batches = append(batches, body.Requests)
+		type embedding struct {
+			Values []float32 `json:"values"`
+		}
+		var resp struct {
+			Embeddings []embedding `json:"embeddings"`
+		}
+		for _, req := range body.Requests {
+			resp.Embeddings = append(resp.Embeddings, embedding{Values: []float32{float32(len(req.Content.Parts[0].Text))}})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	e, err := NewEmbedder(t.Context(), "gemini-embedding-001", &genai.ClientConfig{
+		APIKey:      "test-key",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// More texts than the API accepts in one call.
+	var texts []string
+	var want [][]float32
+	for i := range maxEmbedBatchSize + 5 {
+		text := strings.Repeat("a", i%7+1)
+		texts = append(texts, text)
+		want = append(want, []float32{float32(len(text))})
+	}
+	req := &model.EmbedRequest{TaskType: model.TaskTypeRetrievalQuery, Dimensionality: 16}
+	for _, text := range texts {
+		req.Contents = append(req.Contents, genai.NewContentFromText(text, genai.RoleUser))
+	}
+	resp, err := e.Embed(t.Context(), req)
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if diff := cmp.Diff(want, resp.Embeddings); diff != "" {
+		t.Errorf("Embed() mismatch (-want +got):\n%s", diff)
+	}
+
+	var sizes []int
+	for _, b := range batches {
+		sizes = append(sizes, len(b))
+	}
+	if diff := cmp.Diff([]int{maxEmbedBatchSize, 5}, sizes); diff != "" {
+		t.Fatalf("Embed() batch sizes mismatch (-want +got):\n%s", diff)
+	}
+	if got := batches[0][0]; got.TaskType != "RETRIEVAL_QUERY" || got.OutputDimensionality != 16 || got.Model != "models/gemini-embedding-001" {
+		t.Errorf("Embed() sent request %+v, want the task type, dimensionality and model", got)
+	}
+}
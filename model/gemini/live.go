@@ -0,0 +1,267 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// Connect opens a connection to the Gemini Live API.
+// It implements [model.LiveLLM].
+func (m *geminiModel) Connect(ctx context.Context, req *model.LLMRequest) (model.LiveConnection, error) {
+	session, err := m.client.Live.Connect(ctx, m.name, liveConnectConfig(req))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to model: %w", err)
+	}
+	conn := &liveConnection{session: session}
+
+	// Send the conversation history. The live API only accepts text turns.
+	// reference: adk-python src/google/adk/models/gemini_llm_connection.py GeminiLlmConnection.send_history
+	var history []*genai.Content
+	for _, c := range req.Contents {
+		if c != nil && len(c.Parts) > 0 && c.Parts[0].Text != "" {
+			history = append(history, c)
+		}
+	}
+	if len(history) > 0 {
+		err := session.SendClientContent(genai.LiveClientContentInput{
+			Turns:        history,
+			TurnComplete: genai.Ptr(history[len(history)-1].Role == genai.RoleUser),
+		})
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to send history: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// liveConnectConfig merges the generation config of the request into its
+// live connect config.
+func liveConnectConfig(req *model.LLMRequest) *genai.LiveConnectConfig {
+	cfg := &genai.LiveConnectConfig{}
+	if req.LiveConnectConfig != nil {
+		*cfg = *req.LiveConnectConfig
+	}
+	// The live API doesn't support HTTP options.
+	cfg.HTTPOptions = nil
+	if gc := req.Config; gc != nil {
+		cfg.SystemInstruction = gc.SystemInstruction
+		cfg.Tools = gc.Tools
+		cfg.Temperature = gc.Temperature
+		cfg.TopP = gc.TopP
+		cfg.TopK = gc.TopK
+		cfg.MaxOutputTokens = gc.MaxOutputTokens
+		cfg.Seed = gc.Seed
+		cfg.MediaResolution = gc.MediaResolution
+		cfg.ThinkingConfig = gc.ThinkingConfig
+		if cfg.SpeechConfig == nil {
+			cfg.SpeechConfig = gc.SpeechConfig
+		}
+	}
+	return cfg
+}
+
+type liveConnection struct {
+	session *genai.Session
+
+	// sendMu serializes the writes to the underlying websocket.
+	sendMu sync.Mutex
+	closed atomic.Bool
+}
+
+func (c *liveConnection) Send(ctx context.Context, req *model.LiveRequest) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	switch {
+	case req.Content != nil:
+		var fnResponses []*genai.FunctionResponse
+		for _, p := range req.Content.Parts {
+			if p.FunctionResponse != nil {
+				fnResponses = append(fnResponses, p.FunctionResponse)
+			}
+		}
+		if len(fnResponses) > 0 {
+			return c.session.SendToolResponse(genai.LiveToolResponseInput{FunctionResponses: fnResponses})
+		}
+		return c.session.SendClientContent(genai.LiveClientContentInput{
+			Turns:        []*genai.Content{req.Content},
+			TurnComplete: genai.Ptr(true),
+		})
+	case req.Blob != nil:
+		if strings.HasPrefix(req.Blob.MIMEType, "audio/") {
+			return c.session.SendRealtimeInput(genai.LiveRealtimeInput{Audio: req.Blob})
+		}
+		return c.session.SendRealtimeInput(genai.LiveRealtimeInput{Video: req.Blob})
+	case req.ActivityStart:
+		return c.session.SendRealtimeInput(genai.LiveRealtimeInput{ActivityStart: &genai.ActivityStart{}})
+	case req.ActivityEnd:
+		return c.session.SendRealtimeInput(genai.LiveRealtimeInput{ActivityEnd: &genai.ActivityEnd{}})
+	}
+	return fmt.Errorf("empty live request")
+}
+
+// Receive returns the responses of the model. Reading from the connection
+// doesn't support cancellation: the iteration ends when the connection is
+// closed.
+func (c *liveConnection) Receive(ctx context.Context) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		var a liveAggregator
+		for {
+			msg, err := c.session.Receive()
+			if err != nil {
+				if !c.closed.Load() && ctx.Err() == nil {
+					yield(nil, err)
+				}
+				return
+			}
+			for _, resp := range a.process(msg) {
+				if !yield(resp, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *liveConnection) Close() error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	return c.session.Close()
+}
+
+// liveAggregator converts the server messages of the live API to responses.
+// Text and transcriptions are yielded as partial responses while they are
+// generated, followed by a complete response.
+//
+// reference: adk-python src/google/adk/models/gemini_llm_connection.py GeminiLlmConnection.receive
+type liveAggregator struct {
+	text             strings.Builder
+	inputTranscript  strings.Builder
+	outputTranscript strings.Builder
+}
+
+func (a *liveAggregator) process(msg *genai.LiveServerMessage) []*model.LLMResponse {
+	var resps []*model.LLMResponse
+	if msg.UsageMetadata != nil {
+		resps = append(resps, &model.LLMResponse{UsageMetadata: usageMetadata(msg.UsageMetadata)})
+	}
+
+	if sc := msg.ServerContent; sc != nil {
+		if c := sc.ModelTurn; c != nil && len(c.Parts) > 0 {
+			if c.Parts[0].Text != "" {
+				a.text.WriteString(c.Parts[0].Text)
+				resps = append(resps, &model.LLMResponse{Content: c, Partial: true})
+			} else {
+				resps = a.flushText(resps)
+				// Audio is streamed, so it's a partial response as well.
+				resps = append(resps, &model.LLMResponse{Content: c, Partial: true})
+			}
+		}
+		if t := sc.InputTranscription; t != nil {
+			resps = flushTranscription(resps, &a.inputTranscript, t, func(r *model.LLMResponse, t *genai.Transcription) {
+				r.InputTranscription = t
+			})
+		}
+		if t := sc.OutputTranscription; t != nil {
+			resps = flushTranscription(resps, &a.outputTranscript, t, func(r *model.LLMResponse, t *genai.Transcription) {
+				r.OutputTranscription = t
+			})
+		}
+		if sc.TurnComplete {
+			resps = a.flushText(resps)
+			resps = a.flushTranscriptions(resps)
+			resps = append(resps, &model.LLMResponse{TurnComplete: true, Interrupted: sc.Interrupted})
+		} else if sc.Interrupted {
+			resps = a.flushText(resps)
+			resps = append(resps, &model.LLMResponse{Interrupted: true})
+		}
+	}
+
+	if tc := msg.ToolCall; tc != nil && len(tc.FunctionCalls) > 0 {
+		resps = a.flushText(resps)
+		parts := make([]*genai.Part, 0, len(tc.FunctionCalls))
+		for _, fc := range tc.FunctionCalls {
+			parts = append(parts, &genai.Part{FunctionCall: fc})
+		}
+		resps = append(resps, &model.LLMResponse{Content: &genai.Content{Role: genai.RoleModel, Parts: parts}})
+	}
+	return resps
+}
+
+// flushText appends the complete text generated so far, if any.
+func (a *liveAggregator) flushText(resps []*model.LLMResponse) []*model.LLMResponse {
+	if a.text.Len() == 0 {
+		return resps
+	}
+	resps = append(resps, &model.LLMResponse{Content: genai.NewContentFromText(a.text.String(), genai.RoleModel)})
+	a.text.Reset()
+	return resps
+}
+
+// flushTranscriptions appends the unfinished transcriptions as finished, when
+// the turn completes.
+func (a *liveAggregator) flushTranscriptions(resps []*model.LLMResponse) []*model.LLMResponse {
+	if a.inputTranscript.Len() > 0 {
+		resps = append(resps, &model.LLMResponse{InputTranscription: &genai.Transcription{Text: a.inputTranscript.String(), Finished: true}})
+		a.inputTranscript.Reset()
+	}
+	if a.outputTranscript.Len() > 0 {
+		resps = append(resps, &model.LLMResponse{OutputTranscription: &genai.Transcription{Text: a.outputTranscript.String(), Finished: true}})
+		a.outputTranscript.Reset()
+	}
+	return resps
+}
+
+// flushTranscription appends a partial response for a chunk of transcription,
+// and the complete transcription when it's finished.
+func flushTranscription(resps []*model.LLMResponse, buf *strings.Builder, t *genai.Transcription, set func(*model.LLMResponse, *genai.Transcription)) []*model.LLMResponse {
+	if t.Text != "" {
+		buf.WriteString(t.Text)
+		r := &model.LLMResponse{Partial: true}
+		set(r, &genai.Transcription{Text: t.Text})
+		resps = append(resps, r)
+	}
+	if t.Finished {
+		r := &model.LLMResponse{}
+		set(r, &genai.Transcription{Text: buf.String(), Finished: true})
+		resps = append(resps, r)
+		buf.Reset()
+	}
+	return resps
+}
+
+// usageMetadata converts the usage metadata of the live API.
+func usageMetadata(u *genai.UsageMetadata) *genai.GenerateContentResponseUsageMetadata {
+	return &genai.GenerateContentResponseUsageMetadata{
+		CachedContentTokenCount: u.CachedContentTokenCount,
+		PromptTokenCount:        u.PromptTokenCount,
+		CandidatesTokenCount:    u.ResponseTokenCount,
+		ToolUsePromptTokenCount: u.ToolUsePromptTokenCount,
+		ThoughtsTokenCount:      u.ThoughtsTokenCount,
+		TotalTokenCount:         u.TotalTokenCount,
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+func TestLiveAggregator(t *testing.T) {
+	audio := &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{InlineData: &genai.Blob{MIMEType: "audio/pcm", Data: []byte{1}}}}}
+	fnCall := &genai.FunctionCall{Name: "get_weather", Args: map[string]any{"city": "Paris"}}
+
+	tests := []struct {
+		name string
+		msgs []*genai.LiveServerMessage
+		want []*model.LLMResponse
+	}{
+		{
+			name: "text",
+			msgs: []*genai.LiveServerMessage{
+				{ServerContent: &genai.LiveServerContent{ModelTurn: genai.NewContentFromText("Hello", genai.RoleModel)}},
+				{ServerContent: &genai.LiveServerContent{ModelTurn: genai.NewContentFromText(" world", genai.RoleModel)}},
+				{ServerContent: &genai.LiveServerContent{TurnComplete: true}},
+			},
+			want: []*model.LLMResponse{
+				{Content: genai.NewContentFromText("Hello", genai.RoleModel), Partial: true},
+				{Content: genai.NewContentFromText(" world", genai.RoleModel), Partial: true},
+				{Content: genai.NewContentFromText("Hello world", genai.RoleModel)},
+				{TurnComplete: true},
+			},
+		},
+		{
+			name: "interrupted",
+			msgs: []*genai.LiveServerMessage{
+				{ServerContent: &genai.LiveServerContent{ModelTurn: genai.NewContentFromText("Hel", genai.RoleModel)}},
+				{ServerContent: &genai.LiveServerContent{Interrupted: true}},
+			},
+			want: []*model.LLMResponse{
+				{Content: genai.NewContentFromText("Hel", genai.RoleModel), Partial: true},
+				{Content: genai.NewContentFromText("Hel", genai.RoleModel)},
+				{Interrupted: true},
+			},
+		},
+		{
+			name: "audio with transcriptions",
+			msgs: []*genai.LiveServerMessage{
+				{ServerContent: &genai.LiveServerContent{InputTranscription: &genai.Transcription{Text: "Hi"}}},
+				{ServerContent: &genai.LiveServerContent{InputTranscription: &genai.Transcription{Text: " there", Finished: true}}},
+				{ServerContent: &genai.LiveServerContent{ModelTurn: audio, OutputTranscription: &genai.Transcription{Text: "Hello"}}},
+				{ServerContent: &genai.LiveServerContent{TurnComplete: true}, UsageMetadata: &genai.UsageMetadata{PromptTokenCount: 3, ResponseTokenCount: 5, TotalTokenCount: 8}},
+			},
+			want: []*model.LLMResponse{
+				{InputTranscription: &genai.Transcription{Text: "Hi"}, Partial: true},
+				{InputTranscription: &genai.Transcription{Text: " there"}, Partial: true},
+				{InputTranscription: &genai.Transcription{Text: "Hi there", Finished: true}},
+				{Content: audio, Partial: true},
+				{OutputTranscription: &genai.Transcription{Text: "Hello"}, Partial: true},
+				{UsageMetadata: &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 3, CandidatesTokenCount: 5, TotalTokenCount: 8}},
+				{OutputTranscription: &genai.Transcription{Text: "Hello", Finished: true}},
+				{TurnComplete: true},
+			},
+		},
+		{
+			name: "tool call",
+			msgs: []*genai.LiveServerMessage{
+				{ServerContent: &genai.LiveServerContent{ModelTurn: genai.NewContentFromText("Let me check.", genai.RoleModel)}},
+				{ToolCall: &genai.LiveServerToolCall{FunctionCalls: []*genai.FunctionCall{fnCall}}},
+			},
+			want: []*model.LLMResponse{
+				{Content: genai.NewContentFromText("Let me check.", genai.RoleModel), Partial: true},
+				{Content: genai.NewContentFromText("Let me check.", genai.RoleModel)},
+				{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{FunctionCall: fnCall}}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a liveAggregator
+			var got []*model.LLMResponse
+			for _, msg := range tt.msgs {
+				got = append(got, a.process(msg)...)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("process() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLiveConnectConfig(t *testing.T) {
+	req := &model.LLMRequest{
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("Be brief.", genai.RoleUser),
+			Temperature:       genai.Ptr[float32](0.5),
+			HTTPOptions:       &genai.HTTPOptions{APIVersion: "v1beta"},
+		},
+		LiveConnectConfig: &genai.LiveConnectConfig{
+			ResponseModalities:      []genai.Modality{genai.ModalityAudio},
+			InputAudioTranscription: &genai.AudioTranscriptionConfig{},
+		},
+	}
+	want := &genai.LiveConnectConfig{
+		ResponseModalities:      []genai.Modality{genai.ModalityAudio},
+		InputAudioTranscription: &genai.AudioTranscriptionConfig{},
+		SystemInstruction:       genai.NewContentFromText("Be brief.", genai.RoleUser),
+		Temperature:             genai.Ptr[float32](0.5),
+	}
+	if diff := cmp.Diff(want, liveConnectConfig(req)); diff != "" {
+		t.Errorf("liveConnectConfig() mismatch (-want +got):\n%s", diff)
+	}
+}
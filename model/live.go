@@ -0,0 +1,61 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"context"
+	"iter"
+
+	"google.golang.org/genai"
+)
+
+// LiveLLM is an [LLM] that supports bidirectional streaming, where the user
+// input (text, audio, video) and the model output are exchanged over a
+// long-lived connection.
+type LiveLLM interface {
+	LLM
+	// Connect opens a live connection to the model. The request provides the
+	// system instruction, the tools, the generation config and the
+	// conversation history.
+	Connect(ctx context.Context, req *LLMRequest) (LiveConnection, error)
+}
+
+// LiveConnection is a live connection to a model, returned by
+// [LiveLLM.Connect].
+type LiveConnection interface {
+	// Send sends the request to the model.
+	// It is safe to call Send concurrently with Receive and with itself.
+	Send(ctx context.Context, req *LiveRequest) error
+	// Receive returns the responses of the model as they arrive.
+	// The iteration ends when the connection is closed.
+	Receive(ctx context.Context) iter.Seq2[*LLMResponse, error]
+	// Close closes the connection.
+	Close() error
+}
+
+// LiveRequest is a user input sent over a [LiveConnection].
+// Exactly one of its fields is expected to be set.
+type LiveRequest struct {
+	// Content is sent in turn-by-turn mode, e.g. a text message or the
+	// function responses.
+	Content *genai.Content
+	// Blob is sent in realtime mode, e.g. an audio chunk or a video frame.
+	Blob *genai.Blob
+	// ActivityStart signals the start of the user activity, e.g. speech.
+	// Only needed when the automatic activity detection is disabled.
+	ActivityStart bool
+	// ActivityEnd signals the end of the user activity.
+	ActivityEnd bool
+}
@@ -33,6 +33,9 @@ type LLMRequest struct {
 	Model    string
 	Contents []*genai.Content
 	Config   *genai.GenerateContentConfig
+	// LiveConnectConfig holds the live-only settings, such as the response
+	// modalities and audio transcription, used by [LiveLLM.Connect].
+	LiveConnectConfig *genai.LiveConnectConfig
 
 	Tools map[string]any `json:"-"`
 }
@@ -59,4 +62,10 @@ type LLMResponse struct {
 	ErrorMessage string
 	FinishReason genai.FinishReason
 	AvgLogprobs  float64
+	// InputTranscription is the transcription of the user audio.
+	// Only used for bidi streaming mode.
+	InputTranscription *genai.Transcription
+	// OutputTranscription is the transcription of the model audio.
+	// Only used for bidi streaming mode.
+	OutputTranscription *genai.Transcription
 }
@@ -0,0 +1,138 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package database provides an [llmcache.Store] that keeps the cached model
+// responses in a relational database via the GORM library.
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/llmcache"
+)
+
+// storageEntry corresponds to the 'llm_cache_entries' table.
+type storageEntry struct {
+	Key       string `gorm:"primaryKey;size:64"`
+	Responses []byte
+	ExpiresAt *time.Time `gorm:"index;precision:6"`
+	CreatedAt time.Time  `gorm:"precision:6"`
+}
+
+// TableName explicitly sets the table name for the storageEntry struct.
+func (storageEntry) TableName() string {
+	return "llm_cache_entries"
+}
+
+type databaseStore struct {
+	db *gorm.DB
+}
+
+// NewStore creates a new [llmcache.Store] that uses a relational database
+// (e.g., PostgreSQL, Spanner, SQLite) via the GORM library.
+//
+// It requires a [gorm.Dialector] to specify the database connection and
+// accepts optional [gorm.Option] values for further GORM configuration.
+func NewStore(dialector gorm.Dialector, opts ...gorm.Option) (llmcache.Store, error) {
+	db, err := gorm.Open(dialector, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating database cache store: %w", err)
+	}
+	return &databaseStore{db: db}, nil
+}
+
+// AutoMigrate runs the GORM auto-migration tool to ensure the database schema
+// matches the storage model of the store.
+//
+// NOTE: This function relies on a type assertion to the concrete store
+// implementation. It will return an error if the provided store is a
+// different implementation.
+func AutoMigrate(store llmcache.Store) error {
+	s, ok := store.(*databaseStore)
+	if !ok {
+		return fmt.Errorf("invalid cache store type")
+	}
+	if err := s.db.AutoMigrate(&storageEntry{}); err != nil {
+		return fmt.Errorf("auto migrate failed: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired deletes the expired entries from the store.
+func DeleteExpired(ctx context.Context, store llmcache.Store) error {
+	s, ok := store.(*databaseStore)
+	if !ok {
+		return fmt.Errorf("invalid cache store type")
+	}
+	err := s.db.WithContext(ctx).
+		Where("expires_at IS NOT NULL AND expires_at <= ?", time.Now()).
+		Delete(&storageEntry{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete expired entries: %w", err)
+	}
+	return nil
+}
+
+func (s *databaseStore) Get(ctx context.Context, key string) (*llmcache.Entry, error) {
+	if key == "" {
+		return nil, llmcache.ErrNotFound
+	}
+	var se storageEntry
+	err := s.db.WithContext(ctx).Where(&storageEntry{Key: key}).First(&se).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, llmcache.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entry: %w", err)
+	}
+
+	entry := &llmcache.Entry{}
+	if se.ExpiresAt != nil {
+		entry.ExpiresAt = *se.ExpiresAt
+	}
+	var responses []*model.LLMResponse
+	if err := json.Unmarshal(se.Responses, &responses); err != nil {
+		return nil, fmt.Errorf("failed to decode entry: %w", err)
+	}
+	entry.Responses = responses
+	return entry, nil
+}
+
+func (s *databaseStore) Set(ctx context.Context, key string, entry *llmcache.Entry) error {
+	responses, err := json.Marshal(entry.Responses)
+	if err != nil {
+		return fmt.Errorf("failed to encode entry: %w", err)
+	}
+	se := &storageEntry{
+		Key:       key,
+		Responses: responses,
+		CreatedAt: time.Now(),
+	}
+	if !entry.ExpiresAt.IsZero() {
+		se.ExpiresAt = &entry.ExpiresAt
+	}
+	err = s.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(se).Error
+	if err != nil {
+		return fmt.Errorf("failed to set entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+	"gorm.io/gorm"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/llmcache"
+)
+
+func emptyStore(t *testing.T) llmcache.Store {
+	t.Helper()
+	store, err := NewStore(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to create cache store: %v", err)
+	}
+	if err := AutoMigrate(store); err != nil {
+		t.Fatalf("Failed to AutoMigrate db: %v", err)
+	}
+	return store
+}
+
+func TestStore(t *testing.T) {
+	ctx := t.Context()
+	store := emptyStore(t)
+
+	if _, err := store.Get(ctx, "missing"); !errors.Is(err, llmcache.ErrNotFound) {
+		t.Errorf("Get() error = %v, want %v", err, llmcache.ErrNotFound)
+	}
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Microsecond)
+	entry := &llmcache.Entry{
+		Responses: []*model.LLMResponse{
+			{Content: genai.NewContentFromText("Hel", genai.RoleModel), Partial: true},
+			{Content: genai.NewContentFromText("Hello", genai.RoleModel), FinishReason: genai.FinishReasonStop},
+		},
+		ExpiresAt: expiresAt,
+	}
+	if err := store.Set(ctx, "key", entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	got, err := store.Get(ctx, "key")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if diff := cmp.Diff(entry.Responses, got.Responses); diff != "" {
+		t.Errorf("Get() responses mismatch (-want +got):\n%s", diff)
+	}
+	if !got.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("Get() ExpiresAt = %v, want %v", got.ExpiresAt, expiresAt)
+	}
+
+	// Set replaces the existing entry.
+	replacement := &llmcache.Entry{Responses: []*model.LLMResponse{{Content: genai.NewContentFromText("Hi", genai.RoleModel)}}}
+	if err := store.Set(ctx, "key", replacement); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	got, err = store.Get(ctx, "key")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if diff := cmp.Diff(replacement, got); diff != "" {
+		t.Errorf("Get() after replacement mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDeleteExpired(t *testing.T) {
+	ctx := t.Context()
+	store := emptyStore(t)
+
+	entries := map[string]*llmcache.Entry{
+		"expired":   {ExpiresAt: time.Now().Add(-time.Minute)},
+		"valid":     {ExpiresAt: time.Now().Add(time.Hour)},
+		"no_expiry": {},
+	}
+	for key, entry := range entries {
+		if err := store.Set(ctx, key, entry); err != nil {
+			t.Fatalf("Set(%q) error = %v", key, err)
+		}
+	}
+	if err := DeleteExpired(ctx, store); err != nil {
+		t.Fatalf("DeleteExpired() error = %v", err)
+	}
+	for key, wantErr := range map[string]error{"expired": llmcache.ErrNotFound, "valid": nil, "no_expiry": nil} {
+		if _, err := store.Get(ctx, key); !errors.Is(err, wantErr) {
+			t.Errorf("Get(%q) error = %v, want %v", key, err, wantErr)
+		}
+	}
+}
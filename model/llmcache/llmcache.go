@@ -0,0 +1,221 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package llmcache provides a [model.LLM] wrapper that caches the responses
+// of the model, so that identical requests, e.g. issued by repeated evaluation
+// runs, are served without calling the model again.
+//
+// Requests are identified by a hash of their canonical form, see [Key]. The
+// cached responses are kept in a [Store]: in memory ([NewMemoryStore]), in a
+// directory ([NewDirStore]) or in a database (package
+// google.golang.org/adk/model/llmcache/database).
+package llmcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"log"
+	"slices"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+const defaultMaxEntries = 1000
+
+// Config configures the cache.
+type Config struct {
+	// Store keeps the cached responses. Defaults to an in-memory store of up
+	// to 1000 entries.
+	Store Store
+	// TTL is how long the responses are served from the cache. Zero means
+	// the entries don't expire.
+	TTL time.Duration
+}
+
+type cachedModel struct {
+	llm   model.LLM
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// New returns [model.LLM] that serves the responses of llm from the cache
+// when an identical request was made before.
+//
+// Streaming and non-streaming requests are cached separately, and the whole
+// sequence of responses is replayed, including the partial ones. Results are
+// only cached when the model call completed without error, the caller
+// consumed all the responses and none of them reports an error code or an
+// interruption, so that transient failures are not replayed.
+//
+// Failures of the store are logged and the model is called as if the cache
+// was empty.
+func New(llm model.LLM, cfg *Config) (model.LLM, error) {
+	if llm == nil {
+		return nil, fmt.Errorf("model is required")
+	}
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if cfg.TTL < 0 {
+		return nil, fmt.Errorf("invalid TTL: %v", cfg.TTL)
+	}
+	store := cfg.Store
+	if store == nil {
+		store = NewMemoryStore(defaultMaxEntries)
+	}
+	return &cachedModel{llm: llm, store: store, ttl: cfg.TTL, now: time.Now}, nil
+}
+
+func (m *cachedModel) Name() string {
+	return m.llm.Name()
+}
+
+// GenerateContent returns the cached responses of the request, or calls the
+// underlying model and caches its responses.
+func (m *cachedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		key, err := Key(m.llm.Name(), req, stream)
+		if err != nil {
+			// Requests that can't be hashed are not cached.
+			log.Printf("llmcache: failed to compute the cache key: %v", err)
+			for resp, err := range m.llm.GenerateContent(ctx, req, stream) {
+				if !yield(resp, err) {
+					return
+				}
+			}
+			return
+		}
+
+		entry, err := m.store.Get(ctx, key)
+		switch {
+		case err == nil && (entry.ExpiresAt.IsZero() || m.now().Before(entry.ExpiresAt)):
+			for _, resp := range entry.Responses {
+				if !yield(resp, nil) {
+					return
+				}
+			}
+			return
+		case err != nil && !errors.Is(err, ErrNotFound):
+			log.Printf("llmcache: failed to read entry %s: %v", key, err)
+		}
+
+		var resps []*model.LLMResponse
+		cacheable := true
+		for resp, err := range m.llm.GenerateContent(ctx, req, stream) {
+			if err != nil || resp == nil || resp.ErrorCode != "" || resp.Interrupted {
+				cacheable = false
+			}
+			if cacheable {
+				// The caller may modify the response, e.g. to set function call
+				// IDs, so cache a copy of it.
+				c, err := cloneResponse(resp)
+				if err != nil {
+					log.Printf("llmcache: failed to copy response: %v", err)
+					cacheable = false
+				}
+				resps = append(resps, c)
+			}
+			if !yield(resp, err) {
+				return
+			}
+		}
+		if !cacheable || len(resps) == 0 {
+			return
+		}
+		entry = &Entry{Responses: resps}
+		if m.ttl > 0 {
+			entry.ExpiresAt = m.now().Add(m.ttl)
+		}
+		if err := m.store.Set(ctx, key, entry); err != nil {
+			log.Printf("llmcache: failed to write entry %s: %v", key, err)
+		}
+	}
+}
+
+// canonicalRequest is the part of the request that identifies it.
+type canonicalRequest struct {
+	Model        string                       `json:"model"`
+	RequestModel string                       `json:"requestModel,omitempty"`
+	Stream       bool                         `json:"stream"`
+	Contents     []*genai.Content             `json:"contents"`
+	Config       *genai.GenerateContentConfig `json:"config,omitempty"`
+	Tools        []*genai.FunctionDeclaration `json:"tools,omitempty"`
+}
+
+// Key returns the cache key of a request sent to the named model in the given
+// streaming mode. It is the hex-encoded SHA-256 hash of the JSON encoding of
+// the model name, the contents, the config and the tool declarations, which
+// is stable since maps are encoded with sorted keys. Transport options of the
+// config (HTTPOptions) are ignored.
+func Key(modelName string, req *model.LLMRequest, stream bool) (string, error) {
+	if req == nil {
+		req = &model.LLMRequest{}
+	}
+	c := canonicalRequest{
+		Model:        modelName,
+		RequestModel: req.Model,
+		Stream:       stream,
+		Contents:     req.Contents,
+		Config:       req.Config,
+	}
+	if c.Config != nil && c.Config.HTTPOptions != nil {
+		cfg := *c.Config
+		cfg.HTTPOptions = nil
+		c.Config = &cfg
+	}
+	// Tools are usually declared in the config as well, but custom tools may
+	// only be packed into req.Tools.
+	names := make([]string, 0, len(req.Tools))
+	for name := range req.Tools {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if t, ok := req.Tools[name].(interface {
+			Declaration() *genai.FunctionDeclaration
+		}); ok {
+			c.Tools = append(c.Tools, t.Declaration())
+		} else {
+			c.Tools = append(c.Tools, &genai.FunctionDeclaration{Name: name})
+		}
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func cloneResponse(resp *model.LLMResponse) (*model.LLMResponse, error) {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	var c model.LLMResponse
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmcache
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// countingModel returns a text response, preceded by a partial one when
+// streaming, and counts its calls.
+type countingModel struct {
+	calls int
+	err   error
+}
+
+func (m *countingModel) Name() string {
+	return "counting"
+}
+
+func (m *countingModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	m.calls++
+	return func(yield func(*model.LLMResponse, error) bool) {
+		if m.err != nil {
+			yield(nil, m.err)
+			return
+		}
+		if stream && !yield(&model.LLMResponse{Content: genai.NewContentFromText("Hel", genai.RoleModel), Partial: true}, nil) {
+			return
+		}
+		yield(&model.LLMResponse{
+			Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+				{Text: "Hello"},
+				{FunctionCall: &genai.FunctionCall{Name: "greet", Args: map[string]any{"n": 1.0}}},
+			}},
+			FinishReason: genai.FinishReasonStop,
+		}, nil)
+	}
+}
+
+func collect(t *testing.T, llm model.LLM, req *model.LLMRequest, stream bool) []*model.LLMResponse {
+	t.Helper()
+	var got []*model.LLMResponse
+	for resp, err := range llm.GenerateContent(t.Context(), req, stream) {
+		if err != nil {
+			t.Fatalf("GenerateContent() error = %v", err)
+		}
+		got = append(got, resp)
+	}
+	return got
+}
+
+func TestModel_GenerateContent(t *testing.T) {
+	dirStore, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := map[string]Store{
+		"memory": NewMemoryStore(10),
+		"dir":    dirStore,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			llm := &countingModel{}
+			m, err := New(llm, &Config{Store: store})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &model.LLMRequest{Contents: genai.Text("Hi")}
+
+			for _, stream := range []bool{false, true} {
+				want := collect(t, m, req, stream)
+				// Callers may modify the responses, e.g. to set function call IDs.
+				want[len(want)-1].Content.Parts[1].FunctionCall.ID = "adk-1"
+				got := collect(t, m, req, stream)
+				want[len(want)-1].Content.Parts[1].FunctionCall.ID = ""
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("cached responses (stream=%v) mismatch (-want +got):\n%s", stream, diff)
+				}
+			}
+			// Streaming and non-streaming requests are cached separately.
+			if llm.calls != 2 {
+				t.Errorf("model called %d times, want 2", llm.calls)
+			}
+
+			collect(t, m, &model.LLMRequest{Contents: genai.Text("Bye")}, false)
+			if llm.calls != 3 {
+				t.Errorf("model called %d times for a different request, want 3", llm.calls)
+			}
+		})
+	}
+}
+
+func TestModel_TTL(t *testing.T) {
+	llm := &countingModel{}
+	m, err := New(llm, &Config{TTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	m.(*cachedModel).now = func() time.Time { return now }
+	req := &model.LLMRequest{Contents: genai.Text("Hi")}
+
+	collect(t, m, req, false)
+	now = now.Add(30 * time.Second)
+	collect(t, m, req, false)
+	if llm.calls != 1 {
+		t.Errorf("model called %d times before expiration, want 1", llm.calls)
+	}
+	now = now.Add(time.Minute)
+	collect(t, m, req, false)
+	if llm.calls != 2 {
+		t.Errorf("model called %d times after expiration, want 2", llm.calls)
+	}
+}
+
+func TestModel_NotCached(t *testing.T) {
+	errModel := errors.New("unavailable")
+	llm := &countingModel{err: errModel}
+	m, err := New(llm, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &model.LLMRequest{Contents: genai.Text("Hi")}
+
+	for range 2 {
+		for _, err := range m.GenerateContent(t.Context(), req, false) {
+			if !errors.Is(err, errModel) {
+				t.Errorf("GenerateContent() error = %v, want %v", err, errModel)
+			}
+		}
+	}
+	if llm.calls != 2 {
+		t.Errorf("model called %d times, want 2: errors must not be cached", llm.calls)
+	}
+
+	// A stream abandoned by the caller is incomplete.
+	llm.err = nil
+	for range m.GenerateContent(t.Context(), req, true) {
+		break
+	}
+	collect(t, m, req, true)
+	if llm.calls != 4 {
+		t.Errorf("model called %d times, want 4: incomplete streams must not be cached", llm.calls)
+	}
+}
+
+func TestKey(t *testing.T) {
+	base := func() *model.LLMRequest {
+		return &model.LLMRequest{
+			Contents: genai.Text("Hi"),
+			Config: &genai.GenerateContentConfig{
+				Temperature: genai.Ptr[float32](0),
+				Labels:      map[string]string{"b": "2", "a": "1"},
+			},
+		}
+	}
+	key := func(modelName string, req *model.LLMRequest, stream bool) string {
+		t.Helper()
+		k, err := Key(modelName, req, stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	want := key("m", base(), false)
+
+	withHTTPOptions := base()
+	withHTTPOptions.Config.HTTPOptions = &genai.HTTPOptions{Timeout: genai.Ptr(time.Second)}
+	if got := key("m", withHTTPOptions, false); got != want {
+		t.Errorf("Key() changed with HTTP options")
+	}
+
+	withTemperature := base()
+	withTemperature.Config.Temperature = genai.Ptr[float32](1)
+	for name, got := range map[string]string{
+		"model":       key("other", base(), false),
+		"stream":      key("m", base(), true),
+		"temperature": key("m", withTemperature, false),
+		"contents":    key("m", &model.LLMRequest{Contents: genai.Text("Hello"), Config: base().Config}, false),
+	} {
+		if got == want {
+			t.Errorf("Key() didn't change with a different %s", name)
+		}
+	}
+}
+
+func TestMemoryStore_Eviction(t *testing.T) {
+	ctx := t.Context()
+	s := NewMemoryStore(2)
+	for _, key := range []string{"a", "b"} {
+		if err := s.Set(ctx, key, &Entry{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Use "a", so that "b" is the least recently used entry.
+	if _, err := s.Get(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(ctx, "c", &Entry{}); err != nil {
+		t.Fatal(err)
+	}
+	for key, wantErr := range map[string]error{"a": nil, "b": ErrNotFound, "c": nil} {
+		if _, err := s.Get(ctx, key); !errors.Is(err, wantErr) {
+			t.Errorf("Get(%q) error = %v, want %v", key, err, wantErr)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmcache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/model"
+)
+
+// ErrNotFound is returned by [Store.Get] when there is no entry for the key.
+var ErrNotFound = errors.New("cache entry not found")
+
+// Entry holds the cached responses of a request.
+type Entry struct {
+	// Responses are the responses returned by the model, in order.
+	Responses []*model.LLMResponse `json:"responses"`
+	// ExpiresAt is the time after which the entry must not be served.
+	// The zero value means the entry doesn't expire.
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+}
+
+// Store persists the cache entries.
+//
+// Implementations must be safe for concurrent use, and Get must return an
+// entry that the caller can modify without affecting the stored one.
+// Stores may drop expired entries, but don't have to: the cache ignores them.
+type Store interface {
+	// Get returns the entry stored for the key, or [ErrNotFound].
+	Get(ctx context.Context, key string) (*Entry, error)
+	// Set stores the entry for the key, replacing the existing one.
+	Set(ctx context.Context, key string, entry *Entry) error
+}
+
+type memoryStore struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	order   *list.List // of *memoryEntry, the most recently used first
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key       string
+	data      []byte
+	expiresAt time.Time
+}
+
+// NewMemoryStore returns a [Store] that keeps up to maxEntries entries in
+// memory, evicting the least recently used ones. A non-positive maxEntries
+// means no limit.
+func NewMemoryStore(maxEntries int) Store {
+	return &memoryStore{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string) (*Entry, error) {
+	s.mu.Lock()
+	el, ok := s.entries[key]
+	if !ok {
+		s.mu.Unlock()
+		return nil, ErrNotFound
+	}
+	e := el.Value.(*memoryEntry)
+	if !e.expiresAt.IsZero() && !time.Now().Before(e.expiresAt) {
+		s.order.Remove(el)
+		delete(s.entries, key)
+		s.mu.Unlock()
+		return nil, ErrNotFound
+	}
+	s.order.MoveToFront(el)
+	data := e.data
+	s.mu.Unlock()
+
+	// Entries are kept encoded, so that every Get returns a fresh copy.
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode entry: %w", err)
+	}
+	return &entry, nil
+}
+
+func (s *memoryStore) Set(ctx context.Context, key string, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode entry: %w", err)
+	}
+	e := &memoryEntry{key: key, data: data, expiresAt: entry.ExpiresAt}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok {
+		el.Value = e
+		s.order.MoveToFront(el)
+		return nil
+	}
+	s.entries[key] = s.order.PushFront(e)
+	if s.maxEntries > 0 && s.order.Len() > s.maxEntries {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+type dirStore struct {
+	dir string
+}
+
+// NewDirStore returns a [Store] that keeps every entry in a JSON file of the
+// given directory, creating the directory if needed. The files can be
+// committed to version control to replay model responses in CI.
+func NewDirStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &dirStore{dir: dir}, nil
+}
+
+func (s *dirStore) path(key string) (string, error) {
+	if key == "" || filepath.Base(key) != key || key == "." || key == ".." {
+		return "", fmt.Errorf("invalid cache key %q", key)
+	}
+	return filepath.Join(s.dir, key+".json"), nil
+}
+
+func (s *dirStore) Get(ctx context.Context, key string) (*Entry, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return &entry, nil
+}
+
+func (s *dirStore) Set(ctx context.Context, key string, entry *Entry) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode entry: %w", err)
+	}
+	// Write to a temporary file first, so that concurrent readers never see
+	// a partially written entry.
+	f, err := os.CreateTemp(s.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
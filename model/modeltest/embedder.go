@@ -0,0 +1,130 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modeltest
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// defaultDimensionality is the size of the embeddings of an [Embedder]
+// created without a dimensionality.
+const defaultDimensionality = 64
+
+// Embedder is a deterministic [model.Embedder] that doesn't call any model.
+//
+// The words of the text parts, and the data of the other parts, are hashed
+// into a vector normalized to unit length. Contents sharing words have close
+// embeddings, which is enough to test semantic search and retrieval. It's
+// safe for concurrent use.
+type Embedder struct {
+	dimensionality int
+
+	mu       sync.Mutex
+	requests []*model.EmbedRequest
+}
+
+// NewEmbedder returns an embedder computing embeddings of the given size,
+// unless the request sets its own. A non-positive dimensionality means 64.
+func NewEmbedder(dimensionality int) *Embedder {
+	if dimensionality <= 0 {
+		dimensionality = defaultDimensionality
+	}
+	return &Embedder{dimensionality: dimensionality}
+}
+
+// Name implements [model.Embedder].
+func (e *Embedder) Name() string {
+	return "modeltest-embedder"
+}
+
+// Embed implements [model.Embedder].
+func (e *Embedder) Embed(ctx context.Context, req *model.EmbedRequest) (*model.EmbedResponse, error) {
+	e.mu.Lock()
+	e.requests = append(e.requests, req)
+	e.mu.Unlock()
+
+	dim := e.dimensionality
+	if req.Dimensionality > 0 {
+		dim = req.Dimensionality
+	}
+	resp := &model.EmbedResponse{}
+	for _, c := range req.Contents {
+		resp.Embeddings = append(resp.Embeddings, embed(c, dim))
+	}
+	return resp, nil
+}
+
+// Requests returns the requests received so far.
+func (e *Embedder) Requests() []*model.EmbedRequest {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.requests)
+}
+
+var _ model.Embedder = (*Embedder)(nil)
+
+func embed(c *genai.Content, dim int) []float32 {
+	v := make([]float32, dim)
+	add := func(feature []byte) {
+		h := fnv.New64a()
+		h.Write(feature)
+		sum := h.Sum64()
+		// The sign bit spreads the features in both directions, so that
+		// unrelated contents are close to orthogonal.
+		if sum>>63 == 0 {
+			v[sum%uint64(dim)]++
+		} else {
+			v[sum%uint64(dim)]--
+		}
+	}
+	if c != nil {
+		for _, p := range c.Parts {
+			for _, w := range strings.FieldsFunc(strings.ToLower(p.Text), func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+			}) {
+				add([]byte(w))
+			}
+			if p.InlineData != nil {
+				add(append([]byte(p.InlineData.MIMEType+":"), p.InlineData.Data...))
+			}
+			if p.FileData != nil {
+				add([]byte(p.FileData.MIMEType + ":" + p.FileData.FileURI))
+			}
+		}
+	}
+
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return v
+	}
+	norm = math.Sqrt(norm)
+	for i := range v {
+		v[i] = float32(float64(v[i]) / norm)
+	}
+	return v
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modeltest_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/modeltest"
+)
+
+func TestEmbedder(t *testing.T) {
+	ctx := t.Context()
+	e := modeltest.NewEmbedder(0)
+
+	texts := []string{
+		"The weather in Paris is sunny.",
+		"Is it sunny in Paris?",
+		"Quarterly revenue grew by ten percent.",
+		"",
+	}
+	embs, err := model.EmbedTexts(ctx, e, model.TaskTypeSemanticSimilarity, texts...)
+	if err != nil {
+		t.Fatalf("EmbedTexts() error = %v", err)
+	}
+	for i, emb := range embs[:3] {
+		if len(emb) != 64 {
+			t.Errorf("embedding %d has size %d, want 64", i, len(emb))
+		}
+		if n := dot(emb, emb); math.Abs(n-1) > 1e-6 {
+			t.Errorf("embedding %d has squared norm %v, want 1", i, n)
+		}
+	}
+	if similar, unrelated := dot(embs[0], embs[1]), dot(embs[0], embs[2]); similar <= unrelated {
+		t.Errorf("similarity of related texts = %v, want more than unrelated texts %v", similar, unrelated)
+	}
+	if n := dot(embs[3], embs[3]); n != 0 {
+		t.Errorf("embedding of an empty text has squared norm %v, want 0", n)
+	}
+
+	// The embeddings are deterministic.
+	again, err := model.EmbedTexts(ctx, modeltest.NewEmbedder(0), model.TaskTypeSemanticSimilarity, texts...)
+	if err != nil {
+		t.Fatalf("EmbedTexts() error = %v", err)
+	}
+	if diff := cmp.Diff(embs, again); diff != "" {
+		t.Errorf("EmbedTexts() is not deterministic (-first +second):\n%s", diff)
+	}
+
+	resp, err := e.Embed(ctx, &model.EmbedRequest{
+		Contents: []*genai.Content{{Parts: []*genai.Part{
+			genai.NewPartFromBytes([]byte{1, 2, 3}, "image/png"),
+		}}},
+		Dimensionality: 8,
+	})
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if got := len(resp.Embeddings[0]); got != 8 {
+		t.Errorf("Embed() embedding size = %d, want 8", got)
+	}
+	if got := len(e.Requests()); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+}
+
+func dot(a, b []float32) float64 {
+	var s float64
+	for i := range a {
+		s += float64(a[i]) * float64(b[i])
+	}
+	return s
+}
@@ -0,0 +1,187 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modeltest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+
+	"google.golang.org/adk/model"
+)
+
+// LiveModel is a scripted [model.LiveLLM]. Its connections answer each live
+// request with the next scripted [Turn], returning all its responses,
+// including the partial ones. The Check of the turns isn't called: the live
+// requests are recorded instead. It's safe for concurrent use.
+type LiveModel struct {
+	name string
+
+	mu           sync.Mutex
+	turns        []Turn
+	requests     []*model.LLMRequest
+	liveRequests []*model.LiveRequest
+}
+
+// NewLive returns a live model answering the live requests with the turns,
+// in order.
+func NewLive(name string, turns ...Turn) *LiveModel {
+	return &LiveModel{name: name, turns: turns}
+}
+
+// Name implements [model.LLM].
+func (m *LiveModel) Name() string {
+	return m.name
+}
+
+// GenerateContent implements [model.LLM]. It always fails: the model only
+// supports the live connections.
+func (m *LiveModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		yield(nil, fmt.Errorf("modeltest: model %q only supports live connections", m.name))
+	}
+}
+
+// Connect implements [model.LiveLLM].
+func (m *LiveModel) Connect(ctx context.Context, req *model.LLMRequest) (model.LiveConnection, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, req)
+	return &liveConnection{
+		model: m,
+		ready: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}, nil
+}
+
+// nextTurn records the live request and returns the turn answering it.
+func (m *LiveModel) nextTurn(req *model.LiveRequest) (Turn, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.liveRequests = append(m.liveRequests, req)
+	if len(m.turns) == 0 {
+		return Turn{}, fmt.Errorf("modeltest: unexpected live request #%d to model %q: no more turns", len(m.liveRequests), m.name)
+	}
+	turn := m.turns[0]
+	m.turns = m.turns[1:]
+	return turn, nil
+}
+
+// Append adds turns to the script.
+func (m *LiveModel) Append(turns ...Turn) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.turns = append(m.turns, turns...)
+}
+
+// Requests returns the requests of the connections opened so far.
+func (m *LiveModel) Requests() []*model.LLMRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.requests)
+}
+
+// LiveRequests returns the live requests received so far.
+func (m *LiveModel) LiveRequests() []*model.LiveRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.liveRequests)
+}
+
+// Done returns an error if some turns weren't used.
+func (m *LiveModel) Done() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.turns) > 0 {
+		return fmt.Errorf("modeltest: model %q has %d unused turn(s) after %d live request(s)", m.name, len(m.turns), len(m.liveRequests))
+	}
+	return nil
+}
+
+var _ model.LiveLLM = (*LiveModel)(nil)
+
+// liveConnection queues the responses of the turns until they're received.
+type liveConnection struct {
+	model *LiveModel
+	// ready is signaled when responses are queued.
+	ready     chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu      sync.Mutex
+	pending []liveResponse
+}
+
+type liveResponse struct {
+	resp *model.LLMResponse
+	err  error
+}
+
+func (c *liveConnection) Send(ctx context.Context, req *model.LiveRequest) error {
+	select {
+	case <-c.done:
+		return errors.New("modeltest: connection closed")
+	default:
+	}
+	turn, err := c.model.nextTurn(req)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	for _, resp := range turn.Responses {
+		c.pending = append(c.pending, liveResponse{resp: resp})
+	}
+	if turn.Err != nil {
+		c.pending = append(c.pending, liveResponse{err: turn.Err})
+	}
+	c.mu.Unlock()
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (c *liveConnection) Receive(ctx context.Context) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for {
+			c.mu.Lock()
+			pending := c.pending
+			c.pending = nil
+			c.mu.Unlock()
+			for _, r := range pending {
+				if !yield(r.resp, r.err) || r.err != nil {
+					return
+				}
+			}
+			select {
+			case <-c.ready:
+			case <-c.done:
+				return
+			case <-ctx.Done():
+				yield(nil, ctx.Err())
+				return
+			}
+		}
+	}
+}
+
+func (c *liveConnection) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	return nil
+}
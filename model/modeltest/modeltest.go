@@ -0,0 +1,269 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package modeltest provides a scripted [model.LLM] to test agents without
+// network access.
+//
+// A [Model] answers each call with the next scripted [Turn], and records the
+// requests it receives so that tests can assert on the instructions, tools
+// and contents sent by the agents:
+//
+//	llm := modeltest.New("test-model",
+//		modeltest.FunctionCall("get_weather", map[string]any{"city": "Paris"}),
+//		modeltest.Text("It's sunny in Paris."),
+//	)
+//	a, _ := llmagent.New(llmagent.Config{Name: "weather_agent", Model: llm, Tools: tools})
+//	// Run the agent with a runner...
+//	if err := llm.Done(); err != nil {
+//		t.Error(err)
+//	}
+//
+// The package also provides a scripted [LiveModel] for the bidi streaming
+// mode and a deterministic [Embedder].
+package modeltest
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// Turn is the scripted answer to one model call.
+type Turn struct {
+	// Responses are returned in order. Partial responses are only returned
+	// in the streaming mode, like the real models do.
+	Responses []*model.LLMResponse
+	// Err, if set, is returned after the responses.
+	Err error
+	// Check, if set, is called with the request before responding. An error
+	// fails the call with this error.
+	Check func(req *model.LLMRequest) error
+}
+
+// Text returns a turn answering with a text.
+func Text(text string) Turn {
+	return Turn{Responses: []*model.LLMResponse{{
+		Content:      genai.NewContentFromText(text, genai.RoleModel),
+		TurnComplete: true,
+	}}}
+}
+
+// FunctionCall returns a turn answering with a function call.
+func FunctionCall(name string, args map[string]any) Turn {
+	return Turn{Responses: []*model.LLMResponse{{
+		Content:      genai.NewContentFromFunctionCall(name, args, genai.RoleModel),
+		TurnComplete: true,
+	}}}
+}
+
+// Stream returns a turn answering with a text streamed in chunks. In the
+// streaming mode, each chunk is returned as a partial response before the
+// complete text.
+func Stream(chunks ...string) Turn {
+	var resps []*model.LLMResponse
+	for _, c := range chunks {
+		resps = append(resps, &model.LLMResponse{
+			Content: genai.NewContentFromText(c, genai.RoleModel),
+			Partial: true,
+		})
+	}
+	resps = append(resps, &model.LLMResponse{
+		Content:      genai.NewContentFromText(strings.Join(chunks, ""), genai.RoleModel),
+		TurnComplete: true,
+	})
+	return Turn{Responses: resps}
+}
+
+// WithUsage returns a copy of the turn whose last response reports the
+// token counts.
+func (t Turn) WithUsage(promptTokens, candidatesTokens int32) Turn {
+	t.Responses = slices.Clone(t.Responses)
+	if n := len(t.Responses); n > 0 {
+		last := *t.Responses[n-1]
+		last.UsageMetadata = &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:     promptTokens,
+			CandidatesTokenCount: candidatesTokens,
+			TotalTokenCount:      promptTokens + candidatesTokens,
+		}
+		t.Responses[n-1] = &last
+	}
+	return t
+}
+
+// Error returns a turn failing with the error.
+func Error(err error) Turn {
+	return Turn{Err: err}
+}
+
+// Model is a scripted model. It's safe for concurrent use.
+type Model struct {
+	name string
+
+	mu       sync.Mutex
+	turns    []Turn
+	requests []*model.LLMRequest
+}
+
+// New returns a model answering the calls with the turns, in order.
+func New(name string, turns ...Turn) *Model {
+	return &Model{name: name, turns: turns}
+}
+
+// Name implements [model.LLM].
+func (m *Model) Name() string {
+	return m.name
+}
+
+// GenerateContent implements [model.LLM]. It fails when there are no more
+// turns.
+func (m *Model) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.mu.Lock()
+		m.requests = append(m.requests, req)
+		if len(m.turns) == 0 {
+			m.mu.Unlock()
+			yield(nil, fmt.Errorf("modeltest: unexpected call #%d to model %q: no more turns", len(m.requests), m.name))
+			return
+		}
+		turn := m.turns[0]
+		m.turns = m.turns[1:]
+		m.mu.Unlock()
+
+		if turn.Check != nil {
+			if err := turn.Check(req); err != nil {
+				yield(nil, fmt.Errorf("modeltest: unexpected request to model %q: %w", m.name, err))
+				return
+			}
+		}
+		for _, resp := range turn.Responses {
+			if resp.Partial && !stream {
+				continue
+			}
+			if !yield(resp, nil) {
+				return
+			}
+		}
+		if turn.Err != nil {
+			yield(nil, turn.Err)
+		}
+	}
+}
+
+// Append adds turns to the script.
+func (m *Model) Append(turns ...Turn) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.turns = append(m.turns, turns...)
+}
+
+// Requests returns the requests received so far.
+func (m *Model) Requests() []*model.LLMRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.requests)
+}
+
+// LastRequest returns the last request received, or nil.
+func (m *Model) LastRequest() *model.LLMRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.requests) == 0 {
+		return nil
+	}
+	return m.requests[len(m.requests)-1]
+}
+
+// Done returns an error if some turns weren't used.
+func (m *Model) Done() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.turns) > 0 {
+		return fmt.Errorf("modeltest: model %q has %d unused turn(s) after %d call(s)", m.name, len(m.turns), len(m.requests))
+	}
+	return nil
+}
+
+var _ model.LLM = (*Model)(nil)
+
+// SystemInstruction returns the text of the system instruction of the
+// request, with the parts separated by newlines.
+func SystemInstruction(req *model.LLMRequest) string {
+	if req.Config == nil {
+		return ""
+	}
+	return text(req.Config.SystemInstruction)
+}
+
+// ToolNames returns the names of the function declarations of the request,
+// in order.
+func ToolNames(req *model.LLMRequest) []string {
+	if req.Config == nil {
+		return nil
+	}
+	var names []string
+	for _, t := range req.Config.Tools {
+		if t == nil {
+			continue
+		}
+		for _, fd := range t.FunctionDeclarations {
+			names = append(names, fd.Name)
+		}
+	}
+	return names
+}
+
+// Contents returns a short description of each content of the request, in
+// the form "role: text". Function calls and responses are described as
+// "call name" and "response name".
+func Contents(req *model.LLMRequest) []string {
+	var descs []string
+	for _, c := range req.Contents {
+		if c == nil {
+			continue
+		}
+		var parts []string
+		for _, p := range c.Parts {
+			switch {
+			case p.FunctionCall != nil:
+				parts = append(parts, "call "+p.FunctionCall.Name)
+			case p.FunctionResponse != nil:
+				parts = append(parts, "response "+p.FunctionResponse.Name)
+			case p.Text != "":
+				parts = append(parts, p.Text)
+			}
+		}
+		descs = append(descs, c.Role+": "+strings.Join(parts, ", "))
+	}
+	return descs
+}
+
+func text(c *genai.Content) string {
+	if c == nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range c.Parts {
+		if p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
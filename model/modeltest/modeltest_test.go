@@ -0,0 +1,237 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modeltest_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/modeltest"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+func TestModel(t *testing.T) {
+	tests := []struct {
+		mode       agent.StreamingMode
+		wantEvents []string
+	}{
+		{
+			mode:       agent.StreamingModeNone,
+			wantEvents: []string{"call get_weather", "response get_weather", "It's sunny."},
+		},
+		{
+			mode:       agent.StreamingModeSSE,
+			wantEvents: []string{"call get_weather", "response get_weather", "It's ", "sunny.", "It's sunny."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			checkInstruction := func(req *model.LLMRequest) error {
+				if got := modeltest.SystemInstruction(req); !strings.Contains(got, "Answer about the weather.") {
+					return errors.New("missing instruction")
+				}
+				return nil
+			}
+			call := modeltest.FunctionCall("get_weather", map[string]any{"city": "Paris"})
+			call.Check = checkInstruction
+			llm := modeltest.New("test-model", call, modeltest.Stream("It's ", "sunny."))
+
+			got := runWeatherAgent(t, llm, tt.mode)
+			if diff := cmp.Diff(tt.wantEvents, got); diff != "" {
+				t.Errorf("events mismatch (-want +got):\n%s", diff)
+			}
+			if err := llm.Done(); err != nil {
+				t.Error(err)
+			}
+
+			reqs := llm.Requests()
+			if len(reqs) != 2 {
+				t.Fatalf("got %d requests, want 2", len(reqs))
+			}
+			if diff := cmp.Diff([]string{"get_weather"}, modeltest.ToolNames(reqs[0])); diff != "" {
+				t.Errorf("ToolNames() mismatch (-want +got):\n%s", diff)
+			}
+			wantContents := []string{"user: Weather in Paris?", "model: call get_weather", "user: response get_weather"}
+			if diff := cmp.Diff(wantContents, modeltest.Contents(llm.LastRequest())); diff != "" {
+				t.Errorf("Contents() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLiveModel(t *testing.T) {
+	ctx := t.Context()
+	errModel := errors.New("model failure")
+	llm := modeltest.NewLive("live-model", modeltest.Stream("It's ", "sunny."), modeltest.Error(errModel))
+	conn, err := llm.Connect(ctx, &model.LLMRequest{Model: "live-model"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"Weather?", "Again?"} {
+		if err := conn.Send(ctx, &model.LiveRequest{Content: genai.NewContentFromText(text, genai.RoleUser)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// No more turns.
+	if err := conn.Send(ctx, &model.LiveRequest{ActivityEnd: true}); err == nil {
+		t.Error("Send() without turns succeeded, want error")
+	}
+
+	var got []string
+	var gotErr error
+	for resp, err := range conn.Receive(ctx) {
+		if err != nil {
+			gotErr = err
+			break
+		}
+		got = append(got, resp.Content.Parts[0].Text)
+	}
+	// The partial responses are returned too.
+	if diff := cmp.Diff([]string{"It's ", "sunny.", "It's sunny."}, got); diff != "" {
+		t.Errorf("Receive() texts mismatch (-want +got):\n%s", diff)
+	}
+	if !errors.Is(gotErr, errModel) {
+		t.Errorf("Receive() error = %v, want %v", gotErr, errModel)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(llm.Requests()) != 1 || len(llm.LiveRequests()) != 3 {
+		t.Errorf("got %d connections and %d live requests, want 1 and 3", len(llm.Requests()), len(llm.LiveRequests()))
+	}
+	if err := llm.Done(); err != nil {
+		t.Errorf("Done() error = %v", err)
+	}
+}
+
+func TestModel_Errors(t *testing.T) {
+	errModel := errors.New("model failure")
+
+	llm := modeltest.New("test-model", modeltest.Error(errModel))
+	if _, err := collect(t, llm); !errors.Is(err, errModel) {
+		t.Errorf("GenerateContent() error = %v, want %v", err, errModel)
+	}
+	// No more turns.
+	if _, err := collect(t, llm); err == nil {
+		t.Error("GenerateContent() without turns succeeded, want error")
+	}
+
+	llm = modeltest.New("test-model", modeltest.Turn{
+		Responses: modeltest.Text("Hi").Responses,
+		Check: func(req *model.LLMRequest) error {
+			return errors.New("unexpected")
+		},
+	}, modeltest.Text("Bye"))
+	if _, err := collect(t, llm); err == nil || !strings.Contains(err.Error(), "unexpected") {
+		t.Errorf("GenerateContent() error = %v, want the check error", err)
+	}
+	if err := llm.Done(); err == nil {
+		t.Error("Done() with an unused turn succeeded, want error")
+	}
+	llm.Append(modeltest.Text("Again"))
+	for _, want := range []string{"Bye", "Again"} {
+		resps, err := collect(t, llm)
+		if err != nil {
+			t.Fatalf("GenerateContent() error = %v", err)
+		}
+		if got := resps[0].Content.Parts[0].Text; got != want {
+			t.Errorf("GenerateContent() = %q, want %q", got, want)
+		}
+	}
+	if err := llm.Done(); err != nil {
+		t.Errorf("Done() error = %v", err)
+	}
+	if got := len(llm.Requests()); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+}
+
+func collect(t *testing.T, llm model.LLM) ([]*model.LLMResponse, error) {
+	var resps []*model.LLMResponse
+	for resp, err := range llm.GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+		if err != nil {
+			return resps, err
+		}
+		resps = append(resps, resp)
+	}
+	return resps, nil
+}
+
+// runWeatherAgent runs an agent with a weather tool and returns the
+// description of the events.
+func runWeatherAgent(t *testing.T, llm model.LLM, mode agent.StreamingMode) []string {
+	t.Helper()
+	ctx := t.Context()
+
+	type Args struct {
+		City string `json:"city"`
+	}
+	weather, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather in a city",
+	}, func(_ tool.Context, args Args) (map[string]any, error) {
+		return map[string]any{"weather": "sunny"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:        "weather_agent",
+		Model:       llm,
+		Instruction: "Answer about the weather.",
+		Tools:       []tool.Tool{weather},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	msg := genai.NewContentFromText("Weather in Paris?", genai.RoleUser)
+	for ev, err := range r.Run(ctx, "user", "session", msg, agent.RunConfig{StreamingMode: mode}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		for _, p := range ev.Content.Parts {
+			switch {
+			case p.FunctionCall != nil:
+				got = append(got, "call "+p.FunctionCall.Name)
+			case p.FunctionResponse != nil:
+				got = append(got, "response "+p.FunctionResponse.Name)
+			default:
+				got = append(got, p.Text)
+			}
+		}
+	}
+	return got
+}
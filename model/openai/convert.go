@@ -0,0 +1,607 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/llminternal/converters"
+	"google.golang.org/adk/model"
+)
+
+// Wire types of the chat completions API. Only the fields used by the
+// converters below are declared.
+
+type chatRequest struct {
+	Model            string          `json:"model"`
+	Messages         []*chatMessage  `json:"messages"`
+	Tools            []*chatTool     `json:"tools,omitempty"`
+	ToolChoice       any             `json:"tool_choice,omitempty"`
+	Temperature      *float32        `json:"temperature,omitempty"`
+	TopP             *float32        `json:"top_p,omitempty"`
+	MaxTokens        int32           `json:"max_tokens,omitempty"`
+	Stop             []string        `json:"stop,omitempty"`
+	Seed             *int32          `json:"seed,omitempty"`
+	PresencePenalty  *float32        `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float32        `json:"frequency_penalty,omitempty"`
+	ResponseFormat   *responseFormat `json:"response_format,omitempty"`
+	Stream           bool            `json:"stream,omitempty"`
+	StreamOptions    *streamOptions  `json:"stream_options,omitempty"`
+}
+
+type chatMessage struct {
+	Role       string      `json:"role"`
+	Content    any         `json:"content"` // string, []*contentPart or nil.
+	ToolCalls  []*toolCall `json:"tool_calls,omitempty"`
+	ToolCallID string      `json:"tool_call_id,omitempty"`
+}
+
+type contentPart struct {
+	Type       string      `json:"type"`
+	Text       string      `json:"text,omitempty"`
+	ImageURL   *imageURL   `json:"image_url,omitempty"`
+	InputAudio *inputAudio `json:"input_audio,omitempty"`
+}
+
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+type inputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"`
+}
+
+type chatTool struct {
+	Type     string        `json:"type"`
+	Function *chatFunction `json:"function"`
+}
+
+type chatFunction struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+type toolCall struct {
+	Index    int              `json:"index,omitempty"`
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Function toolCallFunction `json:"function"`
+}
+
+type toolCallFunction struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+type responseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *jsonSchema `json:"json_schema,omitempty"`
+}
+
+type jsonSchema struct {
+	Name   string `json:"name"`
+	Schema any    `json:"schema"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// chatResponse is used for both the complete response and streaming chunks.
+type chatResponse struct {
+	ID      string        `json:"id"`
+	Model   string        `json:"model"`
+	Choices []*chatChoice `json:"choices"`
+	Usage   *chatUsage    `json:"usage"`
+	Error   *APIError     `json:"error"`
+}
+
+type chatChoice struct {
+	Index        int              `json:"index"`
+	Message      *responseMessage `json:"message"`
+	Delta        *responseMessage `json:"delta"`
+	FinishReason string           `json:"finish_reason"`
+}
+
+type responseMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// ReasoningContent is returned by reasoning models served through vLLM,
+	// DeepSeek and similar endpoints.
+	ReasoningContent string      `json:"reasoning_content"`
+	Refusal          string      `json:"refusal"`
+	ToolCalls        []*toolCall `json:"tool_calls"`
+}
+
+type chatUsage struct {
+	PromptTokens        int32 `json:"prompt_tokens"`
+	CompletionTokens    int32 `json:"completion_tokens"`
+	TotalTokens         int32 `json:"total_tokens"`
+	PromptTokensDetails *struct {
+		CachedTokens int32 `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+	CompletionTokensDetails *struct {
+		ReasoningTokens int32 `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
+}
+
+// toChatRequest converts the LLMRequest into a chat completions request.
+func toChatRequest(modelName string, req *model.LLMRequest) (*chatRequest, error) {
+	chatReq := &chatRequest{Model: modelName}
+
+	cfg := req.Config
+	if cfg == nil {
+		cfg = &genai.GenerateContentConfig{}
+	}
+
+	if sys := contentText(cfg.SystemInstruction); sys != "" {
+		chatReq.Messages = append(chatReq.Messages, &chatMessage{Role: "system", Content: sys})
+	}
+
+	ids := &toolCallIDs{}
+	for _, c := range req.Contents {
+		if c == nil {
+			continue
+		}
+		msgs, err := toChatMessages(c, ids)
+		if err != nil {
+			return nil, err
+		}
+		chatReq.Messages = append(chatReq.Messages, msgs...)
+	}
+
+	tools, err := toChatTools(req)
+	if err != nil {
+		return nil, err
+	}
+	chatReq.Tools = tools
+	if len(tools) > 0 && cfg.ToolConfig != nil {
+		chatReq.ToolChoice = toToolChoice(cfg.ToolConfig.FunctionCallingConfig)
+	}
+
+	chatReq.Temperature = cfg.Temperature
+	chatReq.TopP = cfg.TopP
+	chatReq.MaxTokens = cfg.MaxOutputTokens
+	chatReq.Stop = cfg.StopSequences
+	chatReq.Seed = cfg.Seed
+	chatReq.PresencePenalty = cfg.PresencePenalty
+	chatReq.FrequencyPenalty = cfg.FrequencyPenalty
+
+	if cfg.ResponseMIMEType == "application/json" {
+		switch {
+		case cfg.ResponseJsonSchema != nil:
+			chatReq.ResponseFormat = &responseFormat{Type: "json_schema", JSONSchema: &jsonSchema{Name: "response", Schema: cfg.ResponseJsonSchema}}
+		case cfg.ResponseSchema != nil:
+			chatReq.ResponseFormat = &responseFormat{Type: "json_schema", JSONSchema: &jsonSchema{Name: "response", Schema: converters.Schema2JSONSchema(cfg.ResponseSchema)}}
+		default:
+			chatReq.ResponseFormat = &responseFormat{Type: "json_object"}
+		}
+	}
+	return chatReq, nil
+}
+
+// toolCallIDs keeps function call and function response IDs consistent.
+//
+// The chat completions API requires every tool message to reference the ID of
+// a preceding tool call, but genai function calls may have no ID (ADK strips
+// the IDs it generated itself before calling the model). Missing IDs are
+// synthesized and matched with the responses by function name, in order.
+type toolCallIDs struct {
+	n       int
+	pending map[string][]string
+}
+
+func (t *toolCallIDs) call(fc *genai.FunctionCall) string {
+	if fc.ID != "" {
+		return fc.ID
+	}
+	t.n++
+	id := fmt.Sprintf("call_%d", t.n)
+	if t.pending == nil {
+		t.pending = make(map[string][]string)
+	}
+	t.pending[fc.Name] = append(t.pending[fc.Name], id)
+	return id
+}
+
+func (t *toolCallIDs) response(fr *genai.FunctionResponse) string {
+	if fr.ID != "" {
+		return fr.ID
+	}
+	if ids := t.pending[fr.Name]; len(ids) > 0 {
+		t.pending[fr.Name] = ids[1:]
+		return ids[0]
+	}
+	t.n++
+	return fmt.Sprintf("call_%d", t.n)
+}
+
+// toChatMessages converts a genai content into one or more chat messages.
+// Function responses become separate "tool" messages.
+func toChatMessages(c *genai.Content, ids *toolCallIDs) ([]*chatMessage, error) {
+	if c.Role == genai.RoleModel {
+		msg := &chatMessage{Role: "assistant"}
+		var text strings.Builder
+		for _, p := range c.Parts {
+			switch {
+			case p.Thought:
+				// Reasoning is not replayed to the model.
+			case p.FunctionCall != nil:
+				args, err := json.Marshal(p.FunctionCall.Args)
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal arguments of function call %q: %w", p.FunctionCall.Name, err)
+				}
+				if p.FunctionCall.Args == nil {
+					args = []byte("{}")
+				}
+				msg.ToolCalls = append(msg.ToolCalls, &toolCall{
+					ID:       ids.call(p.FunctionCall),
+					Type:     "function",
+					Function: toolCallFunction{Name: p.FunctionCall.Name, Arguments: string(args)},
+				})
+			default:
+				text.WriteString(partText(p))
+			}
+		}
+		if text.Len() > 0 {
+			msg.Content = text.String()
+		}
+		if msg.Content == nil && len(msg.ToolCalls) == 0 {
+			return nil, nil
+		}
+		return []*chatMessage{msg}, nil
+	}
+
+	var msgs []*chatMessage
+	var parts []*contentPart
+	for _, p := range c.Parts {
+		switch {
+		case p.FunctionResponse != nil:
+			resp, err := json.Marshal(p.FunctionResponse.Response)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal response of function %q: %w", p.FunctionResponse.Name, err)
+			}
+			msgs = append(msgs, &chatMessage{
+				Role:       "tool",
+				ToolCallID: ids.response(p.FunctionResponse),
+				Content:    string(resp),
+			})
+		case p.InlineData != nil:
+			part, err := blobPart(p.InlineData)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
+		case p.FileData != nil:
+			if !strings.HasPrefix(p.FileData.MIMEType, "image/") {
+				return nil, fmt.Errorf("unsupported file data MIME type %q", p.FileData.MIMEType)
+			}
+			parts = append(parts, &contentPart{Type: "image_url", ImageURL: &imageURL{URL: p.FileData.FileURI}})
+		default:
+			if text := partText(p); text != "" {
+				parts = append(parts, &contentPart{Type: "text", Text: text})
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return msgs, nil
+	}
+	// Plain text messages are sent as a string, which is understood by all
+	// OpenAI-compatible servers.
+	msg := &chatMessage{Role: "user", Content: parts}
+	if !slices.ContainsFunc(parts, func(p *contentPart) bool { return p.Type != "text" }) {
+		var texts []string
+		for _, p := range parts {
+			texts = append(texts, p.Text)
+		}
+		msg.Content = strings.Join(texts, "\n")
+	}
+	return append(msgs, msg), nil
+}
+
+// partText returns the textual representation of the part, if any.
+func partText(p *genai.Part) string {
+	switch {
+	case p.Text != "":
+		return p.Text
+	case p.ExecutableCode != nil:
+		return fmt.Sprintf("```%s\n%s\n```", strings.ToLower(string(p.ExecutableCode.Language)), p.ExecutableCode.Code)
+	case p.CodeExecutionResult != nil:
+		return fmt.Sprintf("Code execution result:\n%s", p.CodeExecutionResult.Output)
+	}
+	return ""
+}
+
+func blobPart(b *genai.Blob) (*contentPart, error) {
+	data := base64.StdEncoding.EncodeToString(b.Data)
+	switch {
+	case strings.HasPrefix(b.MIMEType, "image/"):
+		return &contentPart{Type: "image_url", ImageURL: &imageURL{URL: fmt.Sprintf("data:%s;base64,%s", b.MIMEType, data)}}, nil
+	case b.MIMEType == "audio/wav" || b.MIMEType == "audio/x-wav":
+		return &contentPart{Type: "input_audio", InputAudio: &inputAudio{Data: data, Format: "wav"}}, nil
+	case b.MIMEType == "audio/mpeg" || b.MIMEType == "audio/mp3":
+		return &contentPart{Type: "input_audio", InputAudio: &inputAudio{Data: data, Format: "mp3"}}, nil
+	}
+	return nil, fmt.Errorf("unsupported inline data MIME type %q", b.MIMEType)
+}
+
+// contentText concatenates the text parts of the content.
+func contentText(c *genai.Content) string {
+	if c == nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range c.Parts {
+		if p != nil && p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// toChatTools collects the function declarations of the request.
+func toChatTools(req *model.LLMRequest) ([]*chatTool, error) {
+	var tools []*chatTool
+	seen := make(map[string]bool)
+	add := func(decl *genai.FunctionDeclaration) {
+		if decl == nil || seen[decl.Name] {
+			return
+		}
+		seen[decl.Name] = true
+		tools = append(tools, &chatTool{
+			Type: "function",
+			Function: &chatFunction{
+				Name:        decl.Name,
+				Description: decl.Description,
+				Parameters:  converters.FunctionParameters(decl),
+			},
+		})
+	}
+	if req.Config != nil {
+		for _, t := range req.Config.Tools {
+			if t == nil {
+				continue
+			}
+			if t.FunctionDeclarations == nil {
+				return nil, fmt.Errorf("only function declaration tools are supported by OpenAI-compatible models")
+			}
+			for _, decl := range t.FunctionDeclarations {
+				add(decl)
+			}
+		}
+	}
+	// Tools packed only into req.Tools (e.g. by custom tool implementations)
+	// are declared as well. Sort the names to keep the request stable.
+	names := make([]string, 0, len(req.Tools))
+	for name := range req.Tools {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if t, ok := req.Tools[name].(interface {
+			Declaration() *genai.FunctionDeclaration
+		}); ok {
+			add(t.Declaration())
+		}
+	}
+	return tools, nil
+}
+
+func toToolChoice(cfg *genai.FunctionCallingConfig) any {
+	if cfg == nil {
+		return nil
+	}
+	switch cfg.Mode {
+	case genai.FunctionCallingConfigModeNone:
+		return "none"
+	case genai.FunctionCallingConfigModeAny:
+		if len(cfg.AllowedFunctionNames) == 1 {
+			return map[string]any{
+				"type":     "function",
+				"function": map[string]any{"name": cfg.AllowedFunctionNames[0]},
+			}
+		}
+		return "required"
+	case genai.FunctionCallingConfigModeAuto:
+		return "auto"
+	}
+	return nil
+}
+
+// toGenaiResponse converts a complete chat completions response into its
+// genai counterpart, so that it can share the conversion to LLMResponse with
+// the Gemini model.
+func toGenaiResponse(resp *chatResponse) (*genai.GenerateContentResponse, error) {
+	choice := resp.Choices[0]
+	candidate := &genai.Candidate{
+		FinishReason: toFinishReason(choice.FinishReason),
+	}
+	if msg := choice.Message; msg != nil {
+		var parts []*genai.Part
+		if msg.ReasoningContent != "" {
+			parts = append(parts, &genai.Part{Text: msg.ReasoningContent, Thought: true})
+		}
+		if msg.Content != "" {
+			parts = append(parts, &genai.Part{Text: msg.Content})
+		}
+		for _, tc := range msg.ToolCalls {
+			fc, err := toFunctionCall(tc)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, &genai.Part{FunctionCall: fc})
+		}
+		if msg.Refusal != "" {
+			candidate.FinishReason = genai.FinishReasonSafety
+			candidate.FinishMessage = msg.Refusal
+		}
+		if len(parts) > 0 {
+			candidate.Content = &genai.Content{Role: genai.RoleModel, Parts: parts}
+		}
+	}
+	return &genai.GenerateContentResponse{
+		Candidates:    []*genai.Candidate{candidate},
+		ModelVersion:  resp.Model,
+		ResponseID:    resp.ID,
+		UsageMetadata: toUsageMetadata(resp.Usage),
+	}, nil
+}
+
+func toFunctionCall(tc *toolCall) (*genai.FunctionCall, error) {
+	args := make(map[string]any)
+	if strings.TrimSpace(tc.Function.Arguments) != "" {
+		if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments of tool call %q: %w", tc.Function.Name, err)
+		}
+	}
+	return &genai.FunctionCall{ID: tc.ID, Name: tc.Function.Name, Args: args}, nil
+}
+
+func toFinishReason(reason string) genai.FinishReason {
+	switch reason {
+	case "":
+		return ""
+	case "stop", "tool_calls", "function_call":
+		return genai.FinishReasonStop
+	case "length":
+		return genai.FinishReasonMaxTokens
+	case "content_filter":
+		return genai.FinishReasonSafety
+	}
+	return genai.FinishReasonOther
+}
+
+func toUsageMetadata(u *chatUsage) *genai.GenerateContentResponseUsageMetadata {
+	if u == nil {
+		return nil
+	}
+	md := &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     u.PromptTokens,
+		CandidatesTokenCount: u.CompletionTokens,
+		TotalTokenCount:      u.TotalTokens,
+	}
+	if u.PromptTokensDetails != nil {
+		md.CachedContentTokenCount = u.PromptTokensDetails.CachedTokens
+	}
+	if u.CompletionTokensDetails != nil {
+		// Gemini reports thoughts separately from the candidates.
+		md.ThoughtsTokenCount = u.CompletionTokensDetails.ReasoningTokens
+		md.CandidatesTokenCount -= md.ThoughtsTokenCount
+	}
+	return md
+}
+
+// streamAccumulator turns chat completion chunks into genai responses suitable
+// for the streaming response aggregator.
+type streamAccumulator struct {
+	// pending is the last text response, held back until it's known whether
+	// it is the final one.
+	pending      *genai.GenerateContentResponse
+	toolCalls    []*toolCall
+	finishReason string
+	usage        *chatUsage
+	model        string
+}
+
+// add processes the chunk and returns the responses ready to be emitted.
+func (a *streamAccumulator) add(chunk *chatResponse) []*genai.GenerateContentResponse {
+	if chunk.Usage != nil {
+		a.usage = chunk.Usage
+	}
+	if chunk.Model != "" {
+		a.model = chunk.Model
+	}
+	var ready []*genai.GenerateContentResponse
+	for _, choice := range chunk.Choices {
+		if choice.Index != 0 {
+			continue
+		}
+		if choice.FinishReason != "" {
+			a.finishReason = choice.FinishReason
+		}
+		delta := choice.Delta
+		if delta == nil {
+			continue
+		}
+		for _, p := range []*genai.Part{
+			{Text: delta.ReasoningContent, Thought: true},
+			{Text: delta.Content},
+		} {
+			if p.Text == "" {
+				continue
+			}
+			if a.pending != nil {
+				ready = append(ready, a.pending)
+			}
+			a.pending = a.newResponse(&genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{p}})
+		}
+		for _, tc := range delta.ToolCalls {
+			for len(a.toolCalls) <= tc.Index {
+				a.toolCalls = append(a.toolCalls, &toolCall{})
+			}
+			acc := a.toolCalls[tc.Index]
+			if tc.ID != "" {
+				acc.ID = tc.ID
+			}
+			if tc.Function.Name != "" {
+				acc.Function.Name = tc.Function.Name
+			}
+			acc.Function.Arguments += tc.Function.Arguments
+		}
+	}
+	return ready
+}
+
+// close returns the remaining responses once the stream is over. The last one
+// carries the finish reason and usage metadata.
+func (a *streamAccumulator) close() ([]*genai.GenerateContentResponse, error) {
+	var ready []*genai.GenerateContentResponse
+	last := a.pending
+	if len(a.toolCalls) > 0 {
+		if a.pending != nil {
+			ready = append(ready, a.pending)
+		}
+		content := &genai.Content{Role: genai.RoleModel}
+		for _, tc := range a.toolCalls {
+			fc, err := toFunctionCall(tc)
+			if err != nil {
+				return nil, err
+			}
+			content.Parts = append(content.Parts, &genai.Part{FunctionCall: fc})
+		}
+		last = a.newResponse(content)
+	}
+	if last == nil {
+		last = a.newResponse(nil)
+	}
+	last.Candidates[0].FinishReason = toFinishReason(a.finishReason)
+	last.UsageMetadata = toUsageMetadata(a.usage)
+	return append(ready, last), nil
+}
+
+func (a *streamAccumulator) newResponse(c *genai.Content) *genai.GenerateContentResponse {
+	return &genai.GenerateContentResponse{
+		Candidates:   []*genai.Candidate{{Content: c}},
+		ModelVersion: a.model,
+	}
+}
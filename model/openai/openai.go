@@ -0,0 +1,357 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openai implements the [model.LLM] interface for OpenAI-compatible
+// chat completions endpoints (OpenAI, vLLM, llama.cpp server, etc).
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/internal/llminternal/converters"
+	"google.golang.org/adk/internal/version"
+	"google.golang.org/adk/model"
+)
+
+const defaultBaseURL = "https://api.openai.com/v1"
+
+// ClientConfig configures the connection to an OpenAI-compatible endpoint.
+type ClientConfig struct {
+	// BaseURL is the API root, e.g. "http://localhost:8000/v1".
+	// If empty, OPENAI_BASE_URL is used, falling back to the OpenAI API.
+	BaseURL string
+	// APIKey is sent as a bearer token. If empty, OPENAI_API_KEY is used.
+	// Endpoints that don't require authentication can leave both unset.
+	APIKey string
+	// HTTPClient is used to send the requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Header contains additional headers sent with every request.
+	Header http.Header
+}
+
+type openaiModel struct {
+	name               string
+	baseURL            string
+	apiKey             string
+	httpClient         *http.Client
+	header             http.Header
+	versionHeaderValue string
+}
+
+// NewModel returns [model.LLM], backed by an OpenAI-compatible chat
+// completions API.
+//
+// The modelName is sent as is in the "model" field of the request, so it must
+// match a model served by the endpoint (e.g., "gpt-4o-mini").
+func NewModel(ctx context.Context, modelName string, cfg *ClientConfig) (model.LLM, error) {
+	if modelName == "" {
+		return nil, fmt.Errorf("model name is required")
+	}
+	if cfg == nil {
+		cfg = &ClientConfig{}
+	}
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = os.Getenv("OPENAI_BASE_URL")
+	}
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	apiKey := cfg.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	headerValue := fmt.Sprintf("google-adk/%s gl-go/%s", version.Version,
+		strings.TrimPrefix(runtime.Version(), "go"))
+
+	return &openaiModel{
+		name:               modelName,
+		baseURL:            strings.TrimSuffix(baseURL, "/"),
+		apiKey:             apiKey,
+		httpClient:         httpClient,
+		header:             cfg.Header.Clone(),
+		versionHeaderValue: headerValue,
+	}, nil
+}
+
+func (m *openaiModel) Name() string {
+	return m.name
+}
+
+// GenerateContent calls the underlying model.
+func (m *openaiModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	maybeAppendUserContent(req)
+
+	if stream {
+		return m.generateStream(ctx, req)
+	}
+
+	return func(yield func(*model.LLMResponse, error) bool) {
+		resp, err := m.generate(ctx, req)
+		yield(resp, err)
+	}
+}
+
+// generate calls the model synchronously returning result from the first choice.
+func (m *openaiModel) generate(ctx context.Context, req *model.LLMRequest) (*model.LLMResponse, error) {
+	chatReq, err := toChatRequest(m.name, req)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := m.do(ctx, chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call model: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	var resp chatResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("empty response")
+	}
+	genResp, err := toGenaiResponse(&resp)
+	if err != nil {
+		return nil, err
+	}
+	return converters.Genai2LLMResponse(genResp), nil
+}
+
+// generateStream returns a stream of responses from the model.
+//
+// Text deltas are yielded as partial responses, followed by an aggregated
+// response once the stream is complete. Tool calls are only yielded when they
+// are complete, since their arguments arrive in fragments.
+func (m *openaiModel) generateStream(ctx context.Context, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
+	aggregator := llminternal.NewStreamingResponseAggregator()
+
+	return func(yield func(*model.LLMResponse, error) bool) {
+		chatReq, err := toChatRequest(m.name, req)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		chatReq.Stream = true
+		chatReq.StreamOptions = &streamOptions{IncludeUsage: true}
+
+		httpResp, err := m.do(ctx, chatReq)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to call model: %w", err))
+			return
+		}
+		defer httpResp.Body.Close()
+
+		acc := &streamAccumulator{}
+		for chunk, err := range readEvents(httpResp.Body) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			// Hold back every text delta by one chunk, so that the last one
+			// can carry the finish reason and usage.
+			for _, genResp := range acc.add(chunk) {
+				for llmResponse, err := range aggregator.ProcessResponse(ctx, genResp) {
+					if !yield(llmResponse, err) {
+						return // Consumer stopped
+					}
+				}
+			}
+		}
+		genResps, err := acc.close()
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for _, genResp := range genResps {
+			for llmResponse, err := range aggregator.ProcessResponse(ctx, genResp) {
+				if !yield(llmResponse, err) {
+					return // Consumer stopped
+				}
+			}
+		}
+		if closeResult := aggregator.Close(); closeResult != nil {
+			yield(closeResult, nil)
+		}
+	}
+}
+
+// do sends the chat completions request and returns the response if the
+// endpoint replied with a successful status code.
+func (m *openaiModel) do(ctx context.Context, chatReq *chatRequest) (*http.Response, error) {
+	body, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range m.header {
+		httpReq.Header[k] = v
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", m.versionHeaderValue)
+	if chatReq.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	if m.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+
+	httpResp, err := m.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		defer httpResp.Body.Close()
+		return nil, newAPIError(httpResp)
+	}
+	return httpResp, nil
+}
+
+// readEvents parses the server-sent events stream of chat completion chunks.
+func readEvents(r io.Reader) iter.Seq2[*chatResponse, error] {
+	return func(yield func(*chatResponse, error) bool) {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			data, ok := strings.CutPrefix(line, "data:")
+			if !ok {
+				// Comments, event names and blank separators carry no data.
+				continue
+			}
+			data = strings.TrimSpace(data)
+			if data == "[DONE]" {
+				return
+			}
+			var chunk chatResponse
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				yield(nil, fmt.Errorf("failed to decode stream chunk: %w", err))
+				return
+			}
+			if chunk.Error != nil {
+				yield(nil, chunk.Error)
+				return
+			}
+			if !yield(&chunk, nil) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			yield(nil, fmt.Errorf("failed to read stream: %w", err))
+		}
+	}
+}
+
+// maybeAppendUserContent appends a user content, so that model can continue to output.
+func maybeAppendUserContent(req *model.LLMRequest) {
+	if len(req.Contents) == 0 {
+		req.Contents = append(req.Contents, genai.NewContentFromText("Handle the requests as specified in the System Instruction.", "user"))
+	}
+
+	if last := req.Contents[len(req.Contents)-1]; last != nil && last.Role != "user" {
+		req.Contents = append(req.Contents, genai.NewContentFromText("Continue processing previous requests as instructed. Exit or provide a summary if no more outputs are needed.", "user"))
+	}
+}
+
+// APIError is returned when the endpoint responds with a non-2xx status code.
+type APIError struct {
+	// StatusCode is the HTTP response status code.
+	StatusCode int `json:"-"`
+	// Message is the error message reported by the server.
+	Message string `json:"message"`
+	// Type is the error type reported by the server, e.g. "invalid_request_error".
+	Type string `json:"type"`
+	// Code is the error code reported by the server, if any.
+	Code any `json:"code"`
+	// RetryAfter is the delay requested by the Retry-After response header.
+	// It is zero if the header is absent.
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("openai: status %d, type %s: %s", e.StatusCode, e.Type, e.Message)
+	}
+	return fmt.Sprintf("openai: status %d: %s", e.StatusCode, e.Message)
+}
+
+// HTTPStatusCode implements [model.APIError].
+func (e *APIError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+// RetryDelay implements [model.APIError].
+func (e *APIError) RetryDelay() time.Duration {
+	return e.RetryAfter
+}
+
+var _ model.APIError = (*APIError)(nil)
+
+func newAPIError(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read error response (status %d): %w", resp.StatusCode, err)
+	}
+	var wrapper struct {
+		Error *APIError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &wrapper); err == nil && wrapper.Error != nil {
+		wrapper.Error.StatusCode = resp.StatusCode
+		wrapper.Error.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		return wrapper.Error
+	}
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, given either in
+// seconds or as an HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
@@ -0,0 +1,280 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// newTestServer starts a chat completions stand-in that records the decoded
+// request body and replies with the given handler.
+func newTestServer(t *testing.T, gotBody *map[string]any, handler func(w http.ResponseWriter)) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path: %q", r.URL.Path)
+		}
+		if got, want := r.Header.Get("Authorization"), "Bearer test-key"; got != want {
+			t.Errorf("Authorization header = %q, want %q", got, want)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotBody != nil {
+			if err := json.Unmarshal(body, gotBody); err != nil {
+				t.Fatal(err)
+			}
+		}
+		handler(w)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestModel(t *testing.T, srv *httptest.Server) model.LLM {
+	t.Helper()
+	m, err := NewModel(t.Context(), "test-model", &ClientConfig{
+		BaseURL:    srv.URL + "/v1",
+		APIKey:     "test-key",
+		HTTPClient: srv.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestModel_Generate(t *testing.T) {
+	var gotBody map[string]any
+	srv := newTestServer(t, &gotBody, func(w http.ResponseWriter) {
+		fmt.Fprint(w, `{
+			"id": "chatcmpl-1",
+			"model": "test-model",
+			"choices": [{
+				"index": 0,
+				"message": {
+					"role": "assistant",
+					"content": "Let me check.",
+					"tool_calls": [{"id": "call_abc", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}]
+				},
+				"finish_reason": "tool_calls"
+			}],
+			"usage": {"prompt_tokens": 20, "completion_tokens": 7, "total_tokens": 27, "prompt_tokens_details": {"cached_tokens": 4}}
+		}`)
+	})
+
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{
+			genai.NewContentFromText("What's the weather in Rome?", genai.RoleUser),
+			{Role: genai.RoleModel, Parts: []*genai.Part{
+				{Text: "thinking...", Thought: true},
+				{FunctionCall: &genai.FunctionCall{Name: "get_weather", Args: map[string]any{"city": "Rome"}}},
+			}},
+			{Role: genai.RoleUser, Parts: []*genai.Part{
+				{FunctionResponse: &genai.FunctionResponse{Name: "get_weather", Response: map[string]any{"temp": 21.0}}},
+			}},
+			{Role: genai.RoleUser, Parts: []*genai.Part{
+				{Text: "And Paris?"},
+				{InlineData: &genai.Blob{MIMEType: "image/png", Data: []byte("png")}},
+			}},
+		},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("You are a weather bot.", genai.RoleUser),
+			Temperature:       genai.Ptr[float32](0.5),
+			MaxOutputTokens:   128,
+			Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{
+				Name:        "get_weather",
+				Description: "Returns the weather.",
+				Parameters: &genai.Schema{
+					Type:       genai.TypeObject,
+					Properties: map[string]*genai.Schema{"city": {Type: genai.TypeString}},
+					Required:   []string{"city"},
+				},
+			}}}},
+		},
+	}
+
+	var got []*model.LLMResponse
+	for resp, err := range newTestModel(t, srv).GenerateContent(t.Context(), req, false) {
+		if err != nil {
+			t.Fatalf("GenerateContent() error = %v", err)
+		}
+		got = append(got, resp)
+	}
+
+	wantBody := map[string]any{
+		"model":       "test-model",
+		"temperature": 0.5,
+		"max_tokens":  128.0,
+		"messages": []any{
+			map[string]any{"role": "system", "content": "You are a weather bot."},
+			map[string]any{"role": "user", "content": "What's the weather in Rome?"},
+			map[string]any{"role": "assistant", "content": nil, "tool_calls": []any{
+				map[string]any{"id": "call_1", "type": "function", "function": map[string]any{"name": "get_weather", "arguments": `{"city":"Rome"}`}},
+			}},
+			map[string]any{"role": "tool", "tool_call_id": "call_1", "content": `{"temp":21}`},
+			map[string]any{"role": "user", "content": []any{
+				map[string]any{"type": "text", "text": "And Paris?"},
+				map[string]any{"type": "image_url", "image_url": map[string]any{"url": "data:image/png;base64,cG5n"}},
+			}},
+		},
+		"tools": []any{map[string]any{
+			"type": "function",
+			"function": map[string]any{
+				"name":        "get_weather",
+				"description": "Returns the weather.",
+				"parameters": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"city": map[string]any{"type": "string"}},
+					"required":   []any{"city"},
+				},
+			},
+		}},
+	}
+	if diff := cmp.Diff(wantBody, gotBody); diff != "" {
+		t.Errorf("request body mismatch (-want +got):\n%s", diff)
+	}
+
+	want := []*model.LLMResponse{{
+		Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+			{Text: "Let me check."},
+			{FunctionCall: &genai.FunctionCall{ID: "call_abc", Name: "get_weather", Args: map[string]any{"city": "Paris"}}},
+		}},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:        20,
+			CandidatesTokenCount:    7,
+			TotalTokenCount:         27,
+			CachedContentTokenCount: 4,
+		},
+		FinishReason: genai.FinishReasonStop,
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GenerateContent() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_GenerateStream(t *testing.T) {
+	chunks := []string{
+		`{"model":"test-model","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`{"model":"test-model","choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+		`{"model":"test-model","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"roll","arguments":""}}]}}]}`,
+		`{"model":"test-model","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"sides\":"}}]}}]}`,
+		`{"model":"test-model","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"6}"}}]}}]}`,
+		`{"model":"test-model","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`{"model":"test-model","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}`,
+	}
+	var gotBody map[string]any
+	srv := newTestServer(t, &gotBody, func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, c := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", c)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	var got []*model.LLMResponse
+	for resp, err := range newTestModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{Contents: genai.Text("Hi")}, true) {
+		if err != nil {
+			t.Fatalf("GenerateContent() error = %v", err)
+		}
+		got = append(got, resp)
+	}
+
+	if gotBody["stream"] != true {
+		t.Errorf("request stream = %v, want true", gotBody["stream"])
+	}
+	usage := &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 5, CandidatesTokenCount: 3, TotalTokenCount: 8}
+	want := []*model.LLMResponse{
+		{Content: genai.NewContentFromText("Hel", genai.RoleModel), Partial: true},
+		{Content: genai.NewContentFromText("lo", genai.RoleModel), Partial: true},
+		{Content: genai.NewContentFromText("Hello", genai.RoleModel), UsageMetadata: usage, FinishReason: genai.FinishReasonStop},
+		{
+			Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+				{FunctionCall: &genai.FunctionCall{ID: "call_1", Name: "roll", Args: map[string]any{"sides": 6.0}}},
+			}},
+			UsageMetadata: usage,
+			FinishReason:  genai.FinishReasonStop,
+			TurnComplete:  true,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GenerateContent() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_GenerateStream_Text(t *testing.T) {
+	srv := newTestServer(t, nil, func(w http.ResponseWriter) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Paris\"}}]}\n\n")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"length\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	var got []*model.LLMResponse
+	for resp, err := range newTestModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{Contents: genai.Text("Capital of France?")}, true) {
+		if err != nil {
+			t.Fatalf("GenerateContent() error = %v", err)
+		}
+		got = append(got, resp)
+	}
+
+	want := []*model.LLMResponse{
+		{Content: genai.NewContentFromText("Paris", genai.RoleModel), Partial: true, TurnComplete: true, FinishReason: genai.FinishReasonMaxTokens},
+		{Content: genai.NewContentFromText("Paris", genai.RoleModel), FinishReason: genai.FinishReasonMaxTokens},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GenerateContent() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_Generate_APIError(t *testing.T) {
+	srv := newTestServer(t, nil, func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"message":"Rate limit reached","type":"rate_limit_error","code":"rate_limit_exceeded"}}`)
+	})
+
+	for _, stream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stream=%v", stream), func(t *testing.T) {
+			var gotErr error
+			for _, err := range newTestModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{Contents: genai.Text("Hi")}, stream) {
+				gotErr = err
+			}
+			var apiErr *APIError
+			if !errors.As(gotErr, &apiErr) {
+				t.Fatalf("GenerateContent() error = %v, want *APIError", gotErr)
+			}
+			if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Type != "rate_limit_error" {
+				t.Errorf("GenerateContent() error = %+v, want status 429 and type rate_limit_error", apiErr)
+			}
+			if got, want := apiErr.RetryAfter, 2*time.Second; got != want {
+				t.Errorf("RetryAfter = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package retry provides a [model.LLM] wrapper that retries failed model
+// calls with exponential backoff and limits the rate and concurrency of the
+// calls on the client side.
+package retry
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/routing"
+)
+
+const (
+	defaultMaxAttempts    = 3
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = 30 * time.Second
+	defaultMultiplier     = 2
+)
+
+// Config configures the retries and the limits of a model.
+type Config struct {
+	// MaxAttempts is the maximum number of calls of a request, including the
+	// first one. Defaults to 3. Set to 1 to disable the retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. Defaults to 1s.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts, including the delays
+	// requested by the server. Defaults to 30s.
+	MaxBackoff time.Duration
+	// Multiplier is the factor by which the delay grows after every retry.
+	// Defaults to 2.
+	Multiplier float64
+	// Retryable reports whether a failed call should be retried. Defaults to
+	// retrying the quota, server and timeout errors, as classified by
+	// [routing.ClassifyError].
+	Retryable func(error) bool
+	// OnRetry, if set, is called before waiting for the next attempt.
+	OnRetry func(ctx context.Context, attempt int, err error, delay time.Duration)
+
+	// RequestsPerSecond limits the rate of the calls sent to the model, using
+	// a token bucket holding up to Burst calls. Zero means no limit.
+	RequestsPerSecond float64
+	// Burst is the size of the token bucket. Defaults to 1.
+	Burst int
+	// MaxConcurrent limits the number of calls in progress. Calls over the
+	// limit wait for a slot. Zero means no limit.
+	MaxConcurrent int
+}
+
+type retryModel struct {
+	llm            model.LLM
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	multiplier     float64
+	retryable      func(error) bool
+	onRetry        func(ctx context.Context, attempt int, err error, delay time.Duration)
+	limiter        *rate.Limiter
+	sem            chan struct{}
+}
+
+// New returns [model.LLM] that calls llm with the retries and limits
+// described by cfg. The limits apply to all the calls made through the
+// returned model, so it should be shared by the agents using the same model.
+//
+// Streaming calls are only retried if they failed before yielding any
+// response: once partial output was returned to the caller, a retry would
+// duplicate it, so the error is returned instead.
+func New(llm model.LLM, cfg *Config) (model.LLM, error) {
+	if llm == nil {
+		return nil, fmt.Errorf("model is required")
+	}
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if cfg.MaxAttempts < 0 || cfg.InitialBackoff < 0 || cfg.MaxBackoff < 0 || cfg.RequestsPerSecond < 0 || cfg.MaxConcurrent < 0 {
+		return nil, fmt.Errorf("invalid config: negative values are not allowed")
+	}
+	if cfg.Multiplier != 0 && cfg.Multiplier < 1 {
+		return nil, fmt.Errorf("invalid config: multiplier must be at least 1, got %v", cfg.Multiplier)
+	}
+
+	m := &retryModel{
+		llm:            llm,
+		maxAttempts:    cmp.Or(cfg.MaxAttempts, defaultMaxAttempts),
+		initialBackoff: cmp.Or(cfg.InitialBackoff, defaultInitialBackoff),
+		maxBackoff:     cmp.Or(cfg.MaxBackoff, defaultMaxBackoff),
+		multiplier:     cmp.Or(cfg.Multiplier, defaultMultiplier),
+		retryable:      cfg.Retryable,
+		onRetry:        cfg.OnRetry,
+	}
+	if m.retryable == nil {
+		m.retryable = isTransient
+	}
+	if cfg.RequestsPerSecond > 0 {
+		m.limiter = rate.NewLimiter(rate.Limit(cfg.RequestsPerSecond), cmp.Or(cfg.Burst, 1))
+	}
+	if cfg.MaxConcurrent > 0 {
+		m.sem = make(chan struct{}, cfg.MaxConcurrent)
+	}
+	return m, nil
+}
+
+func (m *retryModel) Name() string {
+	return m.llm.Name()
+}
+
+// GenerateContent calls the underlying model, retrying the transient failures.
+func (m *retryModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		var errs []error
+		for attempt := 1; ; attempt++ {
+			err := m.attempt(ctx, req, stream, yield)
+			if err == nil {
+				return
+			}
+			errs = append(errs, err)
+			if attempt >= m.maxAttempts {
+				if attempt == 1 {
+					yield(nil, err)
+					return
+				}
+				yield(nil, fmt.Errorf("model %q failed after %d attempts: %w", m.llm.Name(), attempt, errors.Join(errs...)))
+				return
+			}
+			delay := m.delay(attempt, err)
+			if m.onRetry != nil {
+				m.onRetry(ctx, attempt, err, delay)
+			}
+			if err := sleep(ctx, delay); err != nil {
+				yield(nil, errors.Join(append(errs, err)...))
+				return
+			}
+		}
+	}
+}
+
+// attempt calls the model once. It returns the error to retry on, if the call
+// failed before yielding anything with an error that can be retried.
+// Otherwise the results are passed to yield and attempt returns nil.
+func (m *retryModel) attempt(ctx context.Context, req *model.LLMRequest, stream bool, yield func(*model.LLMResponse, error) bool) error {
+	if m.limiter != nil {
+		if err := m.limiter.Wait(ctx); err != nil {
+			yield(nil, fmt.Errorf("rate limit: %w", err))
+			return nil
+		}
+	}
+	if m.sem != nil {
+		select {
+		case m.sem <- struct{}{}:
+			defer func() { <-m.sem }()
+		case <-ctx.Done():
+			yield(nil, ctx.Err())
+			return nil
+		}
+	}
+
+	yielded := false
+	for resp, err := range m.llm.GenerateContent(ctx, cloneRequest(req), stream) {
+		if err != nil && !yielded && ctx.Err() == nil && m.retryable(err) {
+			return err
+		}
+		yielded = true
+		if !yield(resp, err) || err != nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+// delay returns the wait time before the next attempt. The server-requested
+// delay takes precedence over the exponential backoff, which uses equal
+// jitter to spread the retries of concurrent callers. Both are capped by the
+// max backoff.
+func (m *retryModel) delay(attempt int, err error) time.Duration {
+	if d := RetryAfter(err); d > 0 {
+		return min(d, m.maxBackoff)
+	}
+	backoff := float64(m.initialBackoff)
+	for range attempt - 1 {
+		backoff *= m.multiplier
+		if backoff >= float64(m.maxBackoff) {
+			break
+		}
+	}
+	backoff = min(backoff, float64(m.maxBackoff))
+	return time.Duration(backoff/2 + rand.Float64()*backoff/2)
+}
+
+// RetryAfter returns the delay requested by the server before retrying the
+// failed call, or zero if the error doesn't carry one. It reads the delay of
+// the errors implementing [model.APIError] and the RetryInfo details of the
+// Gemini API errors.
+func RetryAfter(err error) time.Duration {
+	var (
+		genaiErr    genai.APIError
+		genaiPtrErr *genai.APIError
+		apiErr      model.APIError
+	)
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.RetryDelay()
+	case errors.As(err, &genaiErr):
+		return retryDelay(genaiErr.Details)
+	case errors.As(err, &genaiPtrErr) && genaiPtrErr != nil:
+		return retryDelay(genaiPtrErr.Details)
+	}
+	return 0
+}
+
+// retryDelay returns the delay of the RetryInfo in the details of a Gemini
+// API error, if any.
+func retryDelay(details []map[string]any) time.Duration {
+	for _, d := range details {
+		if d["@type"] != "type.googleapis.com/google.rpc.RetryInfo" {
+			continue
+		}
+		if s, ok := d["retryDelay"].(string); ok {
+			if delay, err := time.ParseDuration(s); err == nil {
+				return delay
+			}
+		}
+	}
+	return 0
+}
+
+func isTransient(err error) bool {
+	class := routing.ClassifyError(err)
+	return class == routing.ErrorClassQuota || class == routing.ErrorClassServer || class == routing.ErrorClassTimeout
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// cloneRequest returns a copy of the request that the model can modify
+// without affecting the following attempts.
+func cloneRequest(req *model.LLMRequest) *model.LLMRequest {
+	if req == nil {
+		return nil
+	}
+	r := *req
+	r.Contents = slices.Clone(req.Contents)
+	return &r
+}
@@ -0,0 +1,358 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/anthropic"
+	"google.golang.org/adk/model/openai"
+)
+
+// fakeModel fails the first len(errs) calls, optionally after yielding
+// partial responses, and succeeds afterwards.
+type fakeModel struct {
+	mu      sync.Mutex
+	errs    []error
+	partial *model.LLMResponse
+	calls   int
+}
+
+func (m *fakeModel) Name() string {
+	return "fake"
+}
+
+func (m *fakeModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.mu.Lock()
+		call := m.calls
+		m.calls++
+		m.mu.Unlock()
+
+		if m.partial != nil && !yield(m.partial, nil) {
+			return
+		}
+		if call < len(m.errs) {
+			yield(nil, m.errs[call])
+			return
+		}
+		yield(&model.LLMResponse{Content: genai.NewContentFromText("ok", genai.RoleModel)}, nil)
+	}
+}
+
+func TestModel_GenerateContent(t *testing.T) {
+	unavailable := genai.APIError{Code: http.StatusServiceUnavailable, Status: "UNAVAILABLE"}
+	rateLimited := &openai.APIError{StatusCode: http.StatusTooManyRequests}
+	badRequest := &anthropic.APIError{StatusCode: http.StatusBadRequest, Type: "invalid_request_error"}
+	partial := &model.LLMResponse{Content: genai.NewContentFromText("par", genai.RoleModel), Partial: true}
+
+	tests := []struct {
+		name      string
+		llm       *fakeModel
+		wantText  bool
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "no errors",
+			llm:       &fakeModel{},
+			wantText:  true,
+			wantCalls: 1,
+		},
+		{
+			name:      "transient errors",
+			llm:       &fakeModel{errs: []error{unavailable, rateLimited}},
+			wantText:  true,
+			wantCalls: 3,
+		},
+		{
+			name:      "attempts exhausted",
+			llm:       &fakeModel{errs: []error{unavailable, unavailable, rateLimited}},
+			wantErr:   rateLimited,
+			wantCalls: 3,
+		},
+		{
+			name:      "not retryable",
+			llm:       &fakeModel{errs: []error{badRequest}},
+			wantErr:   badRequest,
+			wantCalls: 1,
+		},
+		{
+			name:      "error after partial output",
+			llm:       &fakeModel{errs: []error{rateLimited}, partial: partial},
+			wantErr:   rateLimited,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var retries []int
+			m, err := New(tt.llm, &Config{
+				InitialBackoff: time.Millisecond,
+				OnRetry: func(ctx context.Context, attempt int, err error, delay time.Duration) {
+					retries = append(retries, attempt)
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotText := false
+			var gotErr error
+			for resp, err := range m.GenerateContent(t.Context(), &model.LLMRequest{}, true) {
+				if err != nil {
+					gotErr = err
+					continue
+				}
+				if !resp.Partial && resp.Content.Parts[0].Text == "ok" {
+					gotText = true
+				}
+			}
+			if gotText != tt.wantText {
+				t.Errorf("got final response = %v, want %v", gotText, tt.wantText)
+			}
+			if tt.wantErr == nil && gotErr != nil || tt.wantErr != nil && !errors.Is(gotErr, tt.wantErr) {
+				t.Errorf("GenerateContent() error = %v, want %v", gotErr, tt.wantErr)
+			}
+			if tt.llm.calls != tt.wantCalls {
+				t.Errorf("model called %d times, want %d", tt.llm.calls, tt.wantCalls)
+			}
+			if len(retries) != max(tt.wantCalls-1, 0) {
+				t.Errorf("OnRetry called for attempts %v, want %d calls", retries, tt.wantCalls-1)
+			}
+		})
+	}
+}
+
+func TestModel_RetryAfter(t *testing.T) {
+	const retryAfter = 50 * time.Millisecond
+	llm := &fakeModel{errs: []error{&anthropic.APIError{StatusCode: 429, Type: "rate_limit_error", RetryAfter: retryAfter}}}
+	var gotDelay time.Duration
+	m, err := New(llm, &Config{
+		InitialBackoff: time.Millisecond,
+		OnRetry: func(ctx context.Context, attempt int, err error, delay time.Duration) {
+			gotDelay = delay
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for _, err := range m.GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+		if err != nil {
+			t.Fatalf("GenerateContent() error = %v", err)
+		}
+	}
+	if gotDelay != retryAfter {
+		t.Errorf("retry delay = %v, want %v", gotDelay, retryAfter)
+	}
+	if elapsed := time.Since(start); elapsed < retryAfter {
+		t.Errorf("retried after %v, want at least %v", elapsed, retryAfter)
+	}
+}
+
+func TestModel_RetryAfterCapped(t *testing.T) {
+	const maxBackoff = 20 * time.Millisecond
+	llm := &fakeModel{errs: []error{&openai.APIError{StatusCode: 429, RetryAfter: time.Hour}}}
+	var gotDelay time.Duration
+	m, err := New(llm, &Config{
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     maxBackoff,
+		OnRetry: func(ctx context.Context, attempt int, err error, delay time.Duration) {
+			gotDelay = delay
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, err := range m.GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+		if err != nil {
+			t.Fatalf("GenerateContent() error = %v", err)
+		}
+	}
+	if gotDelay != maxBackoff {
+		t.Errorf("retry delay = %v, want the max backoff %v", gotDelay, maxBackoff)
+	}
+}
+
+func TestModel_ContextCanceled(t *testing.T) {
+	llm := &fakeModel{errs: []error{&openai.APIError{StatusCode: 503}}}
+	m, err := New(llm, &Config{InitialBackoff: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	var gotErr error
+	for _, err := range m.GenerateContent(ctx, &model.LLMRequest{}, false) {
+		gotErr = err
+	}
+	if !errors.Is(gotErr, context.DeadlineExceeded) {
+		t.Errorf("GenerateContent() error = %v, want %v", gotErr, context.DeadlineExceeded)
+	}
+	if llm.calls != 1 {
+		t.Errorf("model called %d times, want 1", llm.calls)
+	}
+}
+
+func TestModel_RateLimit(t *testing.T) {
+	m, err := New(&fakeModel{}, &Config{RequestsPerSecond: 20, Burst: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for range 3 {
+		for _, err := range m.GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+			if err != nil {
+				t.Fatalf("GenerateContent() error = %v", err)
+			}
+		}
+	}
+	// The first call uses the burst, the next two wait 50ms each.
+	if elapsed, want := time.Since(start), 90*time.Millisecond; elapsed < want {
+		t.Errorf("3 calls took %v, want at least %v", elapsed, want)
+	}
+}
+
+// blockingModel records the number of calls in progress.
+type blockingModel struct {
+	inFlight, maxInFlight atomic.Int32
+	release               chan struct{}
+}
+
+func (m *blockingModel) Name() string {
+	return "blocking"
+}
+
+func (m *blockingModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		n := m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+		for {
+			cur := m.maxInFlight.Load()
+			if n <= cur || m.maxInFlight.CompareAndSwap(cur, n) {
+				break
+			}
+		}
+		<-m.release
+		yield(&model.LLMResponse{}, nil)
+	}
+}
+
+func TestModel_MaxConcurrent(t *testing.T) {
+	llm := &blockingModel{release: make(chan struct{})}
+	m, err := New(llm, &Config{MaxConcurrent: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range m.GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+			}
+		}()
+	}
+	for i := range 5 {
+		// Wait for the calls to fill the free slots before releasing one.
+		for llm.inFlight.Load() < int32(min(2, 5-i)) {
+			time.Sleep(time.Millisecond)
+		}
+		llm.release <- struct{}{}
+	}
+	wg.Wait()
+
+	if got := llm.maxInFlight.Load(); got != 2 {
+		t.Errorf("max calls in progress = %d, want 2", got)
+	}
+}
+
+// providerError is the API error of a model provider unknown to the package.
+type providerError struct {
+	retryDelay time.Duration
+}
+
+func (e *providerError) Error() string             { return "provider: rate limited" }
+func (e *providerError) HTTPStatusCode() int       { return 429 }
+func (e *providerError) RetryDelay() time.Duration { return e.retryDelay }
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want time.Duration
+	}{
+		{
+			name: "genai retry info",
+			err: genai.APIError{Code: 429, Details: []map[string]any{
+				{"@type": "type.googleapis.com/google.rpc.QuotaFailure"},
+				{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "36s"},
+			}},
+			want: 36 * time.Second,
+		},
+		{
+			name: "wrapped genai pointer retry info",
+			err: fmt.Errorf("call failed: %w", &genai.APIError{Code: 429, Details: []map[string]any{
+				{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "1.5s"},
+			}}),
+			want: 1500 * time.Millisecond,
+		},
+		{
+			name: "openai header",
+			err:  &openai.APIError{StatusCode: 429, RetryAfter: 2 * time.Second},
+			want: 2 * time.Second,
+		},
+		{
+			name: "anthropic header",
+			err:  &anthropic.APIError{StatusCode: 529, Type: "overloaded_error", RetryAfter: 3 * time.Second},
+			want: 3 * time.Second,
+		},
+		{
+			name: "other provider",
+			err:  fmt.Errorf("call failed: %w", &providerError{retryDelay: 4 * time.Second}),
+			want: 4 * time.Second,
+		},
+		{
+			name: "none",
+			err:  errors.New("boom"),
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, RetryAfter(tt.err)); diff != "" {
+				t.Errorf("RetryAfter() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package routing provides composite [model.LLM] implementations that spread
+// requests over several models.
+//
+// [NewFallback] fails over to the next model of an ordered list when a model
+// is rate limited, unavailable, times out or blocks the response, so that a
+// single provider outage doesn't take down the agents using it.
+// [NewRouter] picks the model for each request based on its properties, such
+// as the estimated prompt size, image inputs or the name of the calling agent.
+//
+// Both can be combined, e.g. a route can send the requests with images to a
+// fallback model over two multimodal models.
+package routing
@@ -0,0 +1,150 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// ErrorClass is a set of failure categories of a model call.
+type ErrorClass uint
+
+const (
+	// ErrorClassQuota covers rate limiting and exhausted quota, e.g. HTTP 429.
+	ErrorClassQuota ErrorClass = 1 << iota
+	// ErrorClassServer covers server-side failures, e.g. HTTP 5xx.
+	ErrorClassServer
+	// ErrorClassTimeout covers requests that timed out.
+	ErrorClassTimeout
+	// ErrorClassSafety covers responses blocked by the model's safety
+	// filters. Such responses are not errors; the model returns them as a
+	// response with a blocking finish or block reason.
+	ErrorClassSafety
+
+	// ErrorClassNone is returned for failures that don't belong to any class.
+	ErrorClassNone ErrorClass = 0
+	// ErrorClassAll contains all the classes above.
+	ErrorClassAll = ErrorClassQuota | ErrorClassServer | ErrorClassTimeout | ErrorClassSafety
+)
+
+// Has reports whether c contains all the classes of other.
+func (c ErrorClass) Has(other ErrorClass) bool {
+	return other != ErrorClassNone && c&other == other
+}
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorClassNone:
+		return "none"
+	case ErrorClassQuota:
+		return "quota"
+	case ErrorClassServer:
+		return "server"
+	case ErrorClassTimeout:
+		return "timeout"
+	case ErrorClassSafety:
+		return "safety"
+	}
+	return "mixed"
+}
+
+// ClassifyError returns the class of an error returned by a model.
+//
+// It recognizes the API errors of the genai models and the errors
+// implementing [model.APIError], as well as network and context timeouts.
+// Other errors are classified as [ErrorClassNone].
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassNone
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorClassTimeout
+	}
+
+	var (
+		genaiErr    genai.APIError
+		genaiPtrErr *genai.APIError
+		apiErr      model.APIError
+	)
+	switch {
+	case errors.As(err, &genaiErr):
+		return classifyStatus(genaiErr.Code, genaiErr.Status)
+	case errors.As(err, &genaiPtrErr) && genaiPtrErr != nil:
+		return classifyStatus(genaiPtrErr.Code, genaiPtrErr.Status)
+	case errors.As(err, &apiErr):
+		return classifyStatus(apiErr.HTTPStatusCode(), "")
+	}
+	return ErrorClassNone
+}
+
+// classifyStatus classifies an HTTP status code and, for Google APIs, the
+// canonical status name.
+func classifyStatus(code int, status string) ErrorClass {
+	switch status {
+	case "RESOURCE_EXHAUSTED":
+		return ErrorClassQuota
+	case "DEADLINE_EXCEEDED":
+		return ErrorClassTimeout
+	case "UNAVAILABLE", "INTERNAL":
+		return ErrorClassServer
+	}
+	switch {
+	case code == http.StatusTooManyRequests:
+		return ErrorClassQuota
+	case code == http.StatusRequestTimeout || code == http.StatusGatewayTimeout:
+		return ErrorClassTimeout
+	case code >= 500:
+		return ErrorClassServer
+	}
+	return ErrorClassNone
+}
+
+// ClassifyResponse returns [ErrorClassSafety] if the response was blocked by
+// safety filters, and [ErrorClassNone] otherwise.
+func ClassifyResponse(resp *model.LLMResponse) ErrorClass {
+	if resp == nil {
+		return ErrorClassNone
+	}
+	if isSafetyReason(string(resp.FinishReason)) || isSafetyReason(resp.ErrorCode) {
+		return ErrorClassSafety
+	}
+	return ErrorClassNone
+}
+
+func isSafetyReason(reason string) bool {
+	switch reason {
+	case string(genai.FinishReasonSafety),
+		string(genai.FinishReasonBlocklist),
+		string(genai.FinishReasonProhibitedContent),
+		string(genai.FinishReasonSPII),
+		string(genai.FinishReasonImageSafety),
+		string(genai.FinishReasonImageProhibitedContent),
+		string(genai.BlockedReasonJailbreak),
+		string(genai.BlockedReasonModelArmor):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+
+	"google.golang.org/adk/model"
+)
+
+// FallbackConfig configures a model that fails over between models.
+type FallbackConfig struct {
+	// Name is returned by the Name method of the model. Defaults to the name
+	// of the first model.
+	Name string
+	// Models are tried in order until one of them succeeds.
+	Models []model.LLM
+	// FailOn is the set of error classes that make the model fail over to
+	// the next one. Defaults to [ErrorClassAll].
+	FailOn ErrorClass
+	// Classify returns the class of an error returned by a model.
+	// Defaults to [ClassifyError].
+	Classify func(error) ErrorClass
+	// OnFallback, if set, is called before failing over from the failed model
+	// to the next one. err is either the error returned by the failed model or
+	// a [*BlockedError].
+	OnFallback func(ctx context.Context, failed, next model.LLM, err error)
+}
+
+// BlockedError reports a response blocked by the model's safety filters.
+type BlockedError struct {
+	// Response is the blocked response.
+	Response *model.LLMResponse
+}
+
+func (e *BlockedError) Error() string {
+	reason := e.Response.ErrorCode
+	if reason == "" {
+		reason = string(e.Response.FinishReason)
+	}
+	return fmt.Sprintf("response blocked: %s", reason)
+}
+
+type fallbackModel struct {
+	name       string
+	models     []model.LLM
+	failOn     ErrorClass
+	classify   func(error) ErrorClass
+	onFallback func(ctx context.Context, failed, next model.LLM, err error)
+}
+
+// NewFallback returns [model.LLM] that sends the request to the first of the
+// configured models and fails over to the next one when the call fails with
+// an error of one of the FailOn classes.
+//
+// A model only fails over before anything was yielded to the caller: once a
+// streaming model produced partial output, its errors are returned as is.
+// The model also doesn't fail over when the context is done. If all the models
+// fail, the result of the last one is returned.
+func NewFallback(cfg *FallbackConfig) (model.LLM, error) {
+	if cfg == nil || len(cfg.Models) == 0 {
+		return nil, fmt.Errorf("at least one model is required")
+	}
+	for i, m := range cfg.Models {
+		if m == nil {
+			return nil, fmt.Errorf("model %d is nil", i)
+		}
+	}
+	f := &fallbackModel{
+		name:       cfg.Name,
+		models:     slices.Clone(cfg.Models),
+		failOn:     cfg.FailOn,
+		classify:   cfg.Classify,
+		onFallback: cfg.OnFallback,
+	}
+	if f.name == "" {
+		f.name = f.models[0].Name()
+	}
+	if f.failOn == ErrorClassNone {
+		f.failOn = ErrorClassAll
+	}
+	if f.classify == nil {
+		f.classify = ClassifyError
+	}
+	return f, nil
+}
+
+func (f *fallbackModel) Name() string {
+	return f.name
+}
+
+// GenerateContent calls the models in order until one of them succeeds.
+func (f *fallbackModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		var errs []error
+		for i, m := range f.models {
+			last := i == len(f.models)-1
+			var failure error
+			yielded := false
+			for resp, err := range m.GenerateContent(ctx, cloneRequest(req), stream) {
+				if !yielded && !last && ctx.Err() == nil {
+					if failure = f.failure(resp, err); failure != nil {
+						break
+					}
+				}
+				yielded = true
+				if last && err != nil && len(errs) > 0 {
+					err = fmt.Errorf("all models failed: %w", errors.Join(append(errs, err)...))
+				}
+				if !yield(resp, err) || err != nil {
+					return
+				}
+			}
+			if failure == nil {
+				return
+			}
+			errs = append(errs, fmt.Errorf("model %q: %w", m.Name(), failure))
+			if f.onFallback != nil {
+				f.onFallback(ctx, m, f.models[i+1], failure)
+			}
+		}
+	}
+}
+
+// failure returns the error to fail over on, or nil if the result of the
+// model should be returned to the caller.
+func (f *fallbackModel) failure(resp *model.LLMResponse, err error) error {
+	if err != nil {
+		if f.failOn.Has(f.classify(err)) {
+			return err
+		}
+		return nil
+	}
+	if f.failOn.Has(ErrorClassSafety) && ClassifyResponse(resp) == ErrorClassSafety {
+		return &BlockedError{Response: resp}
+	}
+	return nil
+}
+
+// cloneRequest returns a copy of the request that the models can modify
+// without affecting the following attempts.
+func cloneRequest(req *model.LLMRequest) *model.LLMRequest {
+	if req == nil {
+		return nil
+	}
+	r := *req
+	r.Contents = slices.Clone(req.Contents)
+	return &r
+}
@@ -0,0 +1,267 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/anthropic"
+	"google.golang.org/adk/model/openai"
+)
+
+// fakeModel yields its responses followed by its error, if any.
+type fakeModel struct {
+	name  string
+	resps []*model.LLMResponse
+	err   error
+	calls int
+}
+
+func (m *fakeModel) Name() string {
+	return m.name
+}
+
+func (m *fakeModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	m.calls++
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for _, resp := range m.resps {
+			if !yield(resp, nil) {
+				return
+			}
+		}
+		if m.err != nil {
+			yield(nil, m.err)
+		}
+	}
+}
+
+func textResponse(text string) *model.LLMResponse {
+	return &model.LLMResponse{Content: genai.NewContentFromText(text, genai.RoleModel)}
+}
+
+func TestFallback_GenerateContent(t *testing.T) {
+	ok := textResponse("ok")
+	blocked := &model.LLMResponse{ErrorCode: string(genai.FinishReasonSafety), FinishReason: genai.FinishReasonSafety}
+	partial := &model.LLMResponse{Content: genai.NewContentFromText("par", genai.RoleModel), Partial: true}
+	quotaErr := genai.APIError{Code: http.StatusTooManyRequests, Status: "RESOURCE_EXHAUSTED"}
+	badRequestErr := genai.APIError{Code: http.StatusBadRequest, Status: "INVALID_ARGUMENT"}
+
+	tests := []struct {
+		name      string
+		first     *fakeModel
+		failOn    ErrorClass
+		want      []*model.LLMResponse
+		wantErr   bool
+		wantCalls int // calls of the second model
+	}{
+		{
+			name:      "first succeeds",
+			first:     &fakeModel{resps: []*model.LLMResponse{ok}},
+			want:      []*model.LLMResponse{ok},
+			wantCalls: 0,
+		},
+		{
+			name:      "quota",
+			first:     &fakeModel{err: fmt.Errorf("failed to call model: %w", quotaErr)},
+			want:      []*model.LLMResponse{ok},
+			wantCalls: 1,
+		},
+		{
+			name:      "server error",
+			first:     &fakeModel{err: &openai.APIError{StatusCode: http.StatusServiceUnavailable}},
+			want:      []*model.LLMResponse{ok},
+			wantCalls: 1,
+		},
+		{
+			name:      "overloaded in stream",
+			first:     &fakeModel{err: &anthropic.APIError{Type: "overloaded_error"}},
+			want:      []*model.LLMResponse{ok},
+			wantCalls: 1,
+		},
+		{
+			name:      "timeout",
+			first:     &fakeModel{err: fmt.Errorf("failed to call model: %w", context.DeadlineExceeded)},
+			want:      []*model.LLMResponse{ok},
+			wantCalls: 1,
+		},
+		{
+			name:      "safety",
+			first:     &fakeModel{resps: []*model.LLMResponse{blocked}},
+			want:      []*model.LLMResponse{ok},
+			wantCalls: 1,
+		},
+		{
+			name:      "safety not enabled",
+			first:     &fakeModel{resps: []*model.LLMResponse{blocked}},
+			failOn:    ErrorClassQuota | ErrorClassServer,
+			want:      []*model.LLMResponse{blocked},
+			wantCalls: 0,
+		},
+		{
+			name:      "unclassified error",
+			first:     &fakeModel{err: badRequestErr},
+			want:      []*model.LLMResponse{nil},
+			wantErr:   true,
+			wantCalls: 0,
+		},
+		{
+			name:      "class not enabled",
+			first:     &fakeModel{err: &openai.APIError{StatusCode: http.StatusInternalServerError}},
+			failOn:    ErrorClassQuota,
+			want:      []*model.LLMResponse{nil},
+			wantErr:   true,
+			wantCalls: 0,
+		},
+		{
+			name:      "error after partial output",
+			first:     &fakeModel{resps: []*model.LLMResponse{partial}, err: quotaErr},
+			want:      []*model.LLMResponse{partial, nil},
+			wantErr:   true,
+			wantCalls: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.first.name = "first"
+			second := &fakeModel{name: "second", resps: []*model.LLMResponse{ok}}
+			m, err := NewFallback(&FallbackConfig{Models: []model.LLM{tt.first, second}, FailOn: tt.failOn})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := m.Name(), "first"; got != want {
+				t.Errorf("Name() = %q, want %q", got, want)
+			}
+
+			var got []*model.LLMResponse
+			var gotErr error
+			for resp, err := range m.GenerateContent(t.Context(), &model.LLMRequest{}, true) {
+				got = append(got, resp)
+				if err != nil {
+					gotErr = err
+				}
+			}
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("GenerateContent() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("GenerateContent() mismatch (-want +got):\n%s", diff)
+			}
+			if second.calls != tt.wantCalls {
+				t.Errorf("second model called %d times, want %d", second.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestFallback_AllFail(t *testing.T) {
+	first := &fakeModel{name: "first", err: genai.APIError{Code: http.StatusServiceUnavailable, Status: "UNAVAILABLE"}}
+	second := &fakeModel{name: "second", err: &openai.APIError{StatusCode: http.StatusTooManyRequests}}
+	var fallbacks []string
+	m, err := NewFallback(&FallbackConfig{
+		Name:   "composite",
+		Models: []model.LLM{first, second},
+		OnFallback: func(ctx context.Context, failed, next model.LLM, err error) {
+			fallbacks = append(fallbacks, failed.Name()+"->"+next.Name())
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Name(), "composite"; got != want {
+		t.Errorf("Name() = %q, want %q", got, want)
+	}
+
+	var gotErr error
+	for _, err := range m.GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+		gotErr = err
+	}
+	var openaiErr *openai.APIError
+	if !errors.As(gotErr, &openaiErr) {
+		t.Errorf("GenerateContent() error = %v, want the error of the last model", gotErr)
+	}
+	var genaiErr genai.APIError
+	if !errors.As(gotErr, &genaiErr) {
+		t.Errorf("GenerateContent() error = %v, want the error of the first model", gotErr)
+	}
+	if diff := cmp.Diff([]string{"first->second"}, fallbacks); diff != "" {
+		t.Errorf("OnFallback calls mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFallback_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	first := &fakeModel{name: "first", err: ctx.Err()}
+	second := &fakeModel{name: "second", resps: []*model.LLMResponse{textResponse("ok")}}
+	m, err := NewFallback(&FallbackConfig{Models: []model.LLM{first, second}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range m.GenerateContent(ctx, &model.LLMRequest{}, false) {
+	}
+	if second.calls != 0 {
+		t.Errorf("second model called %d times after the context was canceled", second.calls)
+	}
+}
+
+// providerError is the API error of a model provider unknown to the package.
+type providerError struct {
+	statusCode int
+	retryDelay time.Duration
+}
+
+func (e *providerError) Error() string             { return fmt.Sprintf("provider: status %d", e.statusCode) }
+func (e *providerError) HTTPStatusCode() int       { return e.statusCode }
+func (e *providerError) RetryDelay() time.Duration { return e.retryDelay }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"nil", nil, ErrorClassNone},
+		{"genai quota", genai.APIError{Code: 429}, ErrorClassQuota},
+		{"genai unavailable", fmt.Errorf("wrapped: %w", genai.APIError{Code: 503, Status: "UNAVAILABLE"}), ErrorClassServer},
+		{"genai deadline", genai.APIError{Code: 504, Status: "DEADLINE_EXCEEDED"}, ErrorClassTimeout},
+		{"genai bad request", genai.APIError{Code: 400, Status: "INVALID_ARGUMENT"}, ErrorClassNone},
+		{"openai quota", &openai.APIError{StatusCode: 429}, ErrorClassQuota},
+		{"openai server", &openai.APIError{StatusCode: 502}, ErrorClassServer},
+		{"openai unauthorized", &openai.APIError{StatusCode: 401}, ErrorClassNone},
+		{"anthropic overloaded", &anthropic.APIError{StatusCode: 529, Type: "overloaded_error"}, ErrorClassServer},
+		{"anthropic rate limit", &anthropic.APIError{Type: "rate_limit_error"}, ErrorClassQuota},
+		{"anthropic request timeout", &anthropic.APIError{StatusCode: 408, Type: "timeout_error"}, ErrorClassTimeout},
+		{"other provider server", fmt.Errorf("wrapped: %w", &providerError{statusCode: 503}), ErrorClassServer},
+		{"deadline", context.DeadlineExceeded, ErrorClassTimeout},
+		{"other", errors.New("boom"), ErrorClassNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+)
+
+// Request describes a request being routed.
+type Request struct {
+	// LLMRequest is the request that will be sent to the model.
+	LLMRequest *model.LLMRequest
+	// AgentName is the name of the agent sending the request. It is empty if
+	// the model is called outside of an agent invocation.
+	AgentName string
+	// EstimatedTokens is a rough estimate of the prompt size, see
+	// [EstimateTokens].
+	EstimatedTokens int
+	// HasImages reports whether the contents include image inputs.
+	HasImages bool
+}
+
+// Matcher reports whether a route applies to the request.
+type Matcher func(*Request) bool
+
+// Route sends the requests matched by Match to Model.
+type Route struct {
+	Match Matcher
+	Model model.LLM
+}
+
+// RouterConfig configures a model that routes requests between models.
+type RouterConfig struct {
+	// Name is returned by the Name method of the model. Defaults to the name
+	// of the default model.
+	Name string
+	// Routes are evaluated in order, the first matching route is used.
+	Routes []Route
+	// Default handles the requests not matched by any route.
+	Default model.LLM
+}
+
+type routerModel struct {
+	name         string
+	routes       []Route
+	defaultModel model.LLM
+}
+
+// NewRouter returns [model.LLM] that sends each request to the model of the
+// first matching route, or to the default model if no route matches.
+//
+// Routes can point to any model, including the ones returned by [NewFallback].
+func NewRouter(cfg *RouterConfig) (model.LLM, error) {
+	if cfg == nil || cfg.Default == nil {
+		return nil, fmt.Errorf("default model is required")
+	}
+	for i, r := range cfg.Routes {
+		if r.Match == nil || r.Model == nil {
+			return nil, fmt.Errorf("route %d: matcher and model are required", i)
+		}
+	}
+	name := cfg.Name
+	if name == "" {
+		name = cfg.Default.Name()
+	}
+	return &routerModel{
+		name:         name,
+		routes:       slices.Clone(cfg.Routes),
+		defaultModel: cfg.Default,
+	}, nil
+}
+
+func (r *routerModel) Name() string {
+	return r.name
+}
+
+// GenerateContent calls the model selected for the request.
+func (r *routerModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return r.route(ctx, req).GenerateContent(ctx, req, stream)
+}
+
+func (r *routerModel) route(ctx context.Context, req *model.LLMRequest) model.LLM {
+	if len(r.routes) == 0 {
+		return r.defaultModel
+	}
+	rr := &Request{
+		LLMRequest:      req,
+		EstimatedTokens: EstimateTokens(req),
+		HasImages:       hasImages(req),
+	}
+	// Models are called with the invocation context when run by an agent.
+	if ictx, ok := ctx.(agent.InvocationContext); ok && ictx.Agent() != nil {
+		rr.AgentName = ictx.Agent().Name()
+	}
+	for _, route := range r.routes {
+		if route.Match(rr) {
+			return route.Model
+		}
+	}
+	return r.defaultModel
+}
+
+// MinTokens matches requests estimated to have at least n prompt tokens.
+func MinTokens(n int) Matcher {
+	return func(r *Request) bool {
+		return r.EstimatedTokens >= n
+	}
+}
+
+// MaxTokens matches requests estimated to have at most n prompt tokens.
+func MaxTokens(n int) Matcher {
+	return func(r *Request) bool {
+		return r.EstimatedTokens <= n
+	}
+}
+
+// HasImages matches requests with image inputs.
+func HasImages() Matcher {
+	return func(r *Request) bool {
+		return r.HasImages
+	}
+}
+
+// AgentNames matches requests sent by any of the named agents.
+func AgentNames(names ...string) Matcher {
+	return func(r *Request) bool {
+		return r.AgentName != "" && slices.Contains(names, r.AgentName)
+	}
+}
+
+// All matches requests matched by all the given matchers.
+func All(matchers ...Matcher) Matcher {
+	return func(r *Request) bool {
+		for _, m := range matchers {
+			if !m(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any matches requests matched by at least one of the given matchers.
+func Any(matchers ...Matcher) Matcher {
+	return func(r *Request) bool {
+		for _, m := range matchers {
+			if m(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+const (
+	// charsPerToken approximates the number of characters in a token of
+	// English text.
+	charsPerToken = 4
+	// tokensPerMedia approximates the tokens used by an image or a file input.
+	tokensPerMedia = 258
+)
+
+// EstimateTokens returns a rough estimate of the number of prompt tokens of
+// the request, without calling a model. It counts about four characters per
+// token of text, including the system instruction and the function calls and
+// responses, and a fixed amount per media input.
+func EstimateTokens(req *model.LLMRequest) int {
+	if req == nil {
+		return 0
+	}
+	chars, media := 0, 0
+	count := func(c *genai.Content) {
+		if c == nil {
+			return
+		}
+		for _, p := range c.Parts {
+			if p == nil {
+				continue
+			}
+			chars += len(p.Text)
+			if p.FunctionCall != nil {
+				chars += len(p.FunctionCall.Name) + jsonLen(p.FunctionCall.Args)
+			}
+			if p.FunctionResponse != nil {
+				chars += len(p.FunctionResponse.Name) + jsonLen(p.FunctionResponse.Response)
+			}
+			if p.InlineData != nil || p.FileData != nil {
+				media++
+			}
+		}
+	}
+	if req.Config != nil {
+		count(req.Config.SystemInstruction)
+	}
+	for _, c := range req.Contents {
+		count(c)
+	}
+	return (chars+charsPerToken-1)/charsPerToken + media*tokensPerMedia
+}
+
+func jsonLen(v map[string]any) int {
+	if len(v) == 0 {
+		return 0
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
+
+func hasImages(req *model.LLMRequest) bool {
+	if req == nil {
+		return false
+	}
+	for _, c := range req.Contents {
+		if c == nil {
+			continue
+		}
+		for _, p := range c.Parts {
+			switch {
+			case p == nil:
+			case p.InlineData != nil && strings.HasPrefix(p.InlineData.MIMEType, "image/"):
+				return true
+			case p.FileData != nil && strings.HasPrefix(p.FileData.MIMEType, "image/"):
+				return true
+			}
+		}
+	}
+	return false
+}
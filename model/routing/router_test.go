@@ -0,0 +1,130 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+)
+
+func TestRouter_GenerateContent(t *testing.T) {
+	image := &genai.Part{InlineData: &genai.Blob{MIMEType: "image/jpeg", Data: []byte("jpeg")}}
+
+	tests := []struct {
+		name     string
+		contents []*genai.Content
+		want     string
+	}{
+		{
+			name:     "default",
+			contents: genai.Text("Hi"),
+			want:     "small",
+		},
+		{
+			name:     "image",
+			contents: []*genai.Content{{Role: genai.RoleUser, Parts: []*genai.Part{{Text: "What's this?"}, image}}},
+			want:     "vision",
+		},
+		{
+			name:     "long prompt",
+			contents: genai.Text(strings.Repeat("a", 400)),
+			want:     "large",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			models := map[string]*fakeModel{}
+			for _, name := range []string{"small", "vision", "large"} {
+				models[name] = &fakeModel{name: name, resps: []*model.LLMResponse{textResponse(name)}}
+			}
+			m, err := NewRouter(&RouterConfig{
+				Routes: []Route{
+					{Match: HasImages(), Model: models["vision"]},
+					{Match: MinTokens(100), Model: models["large"]},
+				},
+				Default: models["small"],
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := m.Name(), "small"; got != want {
+				t.Errorf("Name() = %q, want %q", got, want)
+			}
+
+			for _, err := range m.GenerateContent(t.Context(), &model.LLMRequest{Contents: tt.contents}, false) {
+				if err != nil {
+					t.Fatalf("GenerateContent() error = %v", err)
+				}
+			}
+			for name, fm := range models {
+				wantCalls := 0
+				if name == tt.want {
+					wantCalls = 1
+				}
+				if fm.calls != wantCalls {
+					t.Errorf("model %q called %d times, want %d", name, fm.calls, wantCalls)
+				}
+			}
+		})
+	}
+}
+
+func TestRouter_AgentName(t *testing.T) {
+	triage := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("from triage", genai.RoleModel)}}
+	fallback := &fakeModel{name: "default"}
+	m, err := NewRouter(&RouterConfig{
+		Routes:  []Route{{Match: AgentNames("triage_agent"), Model: triage}},
+		Default: fallback,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{Name: "triage_agent", Model: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	texts, err := testutil.CollectTextParts(testutil.NewTestAgentRunner(t, a).Run(t, "session", "Hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(texts) != 1 || texts[0] != "from triage" {
+		t.Errorf("got texts %q, want the response of the routed model", texts)
+	}
+	if fallback.calls != 0 {
+		t.Errorf("default model called %d times, want 0", fallback.calls)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{
+			genai.NewContentFromText(strings.Repeat("a", 10), genai.RoleUser),
+			{Role: genai.RoleUser, Parts: []*genai.Part{{InlineData: &genai.Blob{MIMEType: "image/png"}}}},
+		},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText(strings.Repeat("b", 6), genai.RoleUser),
+		},
+	}
+	if got, want := EstimateTokens(req), 4+tokensPerMedia; got != want {
+		t.Errorf("EstimateTokens() = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/planner"
+)
+
+func TestPlanReAct_ProcessPlanningResponse(t *testing.T) {
+	fnCall := func(name string) *genai.Part {
+		return &genai.Part{FunctionCall: &genai.FunctionCall{Name: name}}
+	}
+	tests := []struct {
+		name  string
+		parts []*genai.Part
+		want  []*genai.Part
+	}{
+		{
+			name:  "no parts",
+			parts: nil,
+			want:  nil,
+		},
+		{
+			name: "planning and function calls",
+			parts: []*genai.Part{
+				{Text: "/*PLANNING*/ 1. Get the weather."},
+				{Text: "/*ACTION*/"},
+				fnCall("get_weather"),
+				fnCall("get_time"),
+				{Text: "after the calls"},
+				fnCall("ignored"),
+			},
+			want: []*genai.Part{
+				{Text: "/*PLANNING*/ 1. Get the weather.", Thought: true},
+				{Text: "/*ACTION*/", Thought: true},
+				fnCall("get_weather"),
+				fnCall("get_time"),
+			},
+		},
+		{
+			name: "function call without name",
+			parts: []*genai.Part{
+				fnCall(""),
+				fnCall("get_weather"),
+			},
+			want: []*genai.Part{
+				fnCall("get_weather"),
+			},
+		},
+		{
+			name: "final answer",
+			parts: []*genai.Part{
+				{Text: "/*REASONING*/ It's sunny. /*FINAL_ANSWER*/ Sunny."},
+			},
+			want: []*genai.Part{
+				{Text: "/*REASONING*/ It's sunny. /*FINAL_ANSWER*/", Thought: true},
+				{Text: " Sunny."},
+			},
+		},
+		{
+			name: "untagged text",
+			parts: []*genai.Part{
+				{Text: "Just an answer."},
+			},
+			want: []*genai.Part{
+				{Text: "Just an answer."},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := planner.NewPlanReAct().ProcessPlanningResponse(nil, tt.parts)
+			if err != nil {
+				t.Fatalf("ProcessPlanningResponse() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ProcessPlanningResponse() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPlanReAct_BuildPlanningInstruction(t *testing.T) {
+	got, err := planner.NewPlanReAct().BuildPlanningInstruction(nil, &model.LLMRequest{})
+	if err != nil {
+		t.Fatalf("BuildPlanningInstruction() error = %v", err)
+	}
+	for _, tag := range []string{planner.PlanningTag, planner.ReplanningTag, planner.ReasoningTag, planner.ActionTag, planner.FinalAnswerTag} {
+		if !strings.Contains(got, tag) {
+			t.Errorf("BuildPlanningInstruction() doesn't mention %s", tag)
+		}
+	}
+}
+
+func TestBuiltIn_ApplyThinkingConfig(t *testing.T) {
+	cfg := &genai.ThinkingConfig{IncludeThoughts: true, ThinkingBudget: genai.Ptr[int32](1024)}
+	req := &model.LLMRequest{}
+	planner.NewBuiltIn(cfg).ApplyThinkingConfig(req)
+	if diff := cmp.Diff(cfg, req.Config.ThinkingConfig); diff != "" {
+		t.Errorf("ApplyThinkingConfig() mismatch (-want +got):\n%s", diff)
+	}
+}
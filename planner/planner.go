@@ -0,0 +1,78 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package planner provides planners that make LLM agents plan before they
+// act.
+//
+// A planner is set on an agent with llmagent.Config.Planner. [BuiltIn] uses
+// the built-in thinking of the model, while [PlanReAct] instructs the model
+// to write a plan and reasoning in natural language, which are marked as
+// thoughts.
+package planner
+
+import (
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+)
+
+// Planner guides the model to plan and processes the planning in its
+// responses.
+type Planner interface {
+	// BuildPlanningInstruction returns the instruction appended to the system
+	// instruction of the request, or "" for none.
+	BuildPlanningInstruction(ctx agent.ReadonlyContext, req *model.LLMRequest) (string, error)
+	// ProcessPlanningResponse processes the parts of a model response. It
+	// returns the parts replacing them, or nil to keep them unchanged.
+	ProcessPlanningResponse(ctx agent.CallbackContext, parts []*genai.Part) ([]*genai.Part, error)
+}
+
+// BuiltIn is a [Planner] that uses the built-in thinking features of the
+// model. The model must support thinking.
+type BuiltIn struct {
+	// ThinkingConfig is set on the requests, overriding the thinking config
+	// of the agent's GenerateContentConfig.
+	ThinkingConfig *genai.ThinkingConfig
+}
+
+// NewBuiltIn returns a [BuiltIn] planner using the thinking config.
+func NewBuiltIn(cfg *genai.ThinkingConfig) *BuiltIn {
+	return &BuiltIn{ThinkingConfig: cfg}
+}
+
+// ApplyThinkingConfig sets the thinking config on the request.
+func (p *BuiltIn) ApplyThinkingConfig(req *model.LLMRequest) {
+	if p.ThinkingConfig == nil {
+		return
+	}
+	if req.Config == nil {
+		req.Config = &genai.GenerateContentConfig{}
+	}
+	req.Config.ThinkingConfig = p.ThinkingConfig
+}
+
+// BuildPlanningInstruction implements [Planner]. The built-in planner
+// doesn't need an instruction.
+func (p *BuiltIn) BuildPlanningInstruction(ctx agent.ReadonlyContext, req *model.LLMRequest) (string, error) {
+	return "", nil
+}
+
+// ProcessPlanningResponse implements [Planner]. The thoughts of the model
+// are already marked by the model.
+func (p *BuiltIn) ProcessPlanningResponse(ctx agent.CallbackContext, parts []*genai.Part) ([]*genai.Part, error) {
+	return nil, nil
+}
+
+var _ Planner = (*BuiltIn)(nil)
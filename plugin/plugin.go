@@ -0,0 +1,119 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugin provides the plugins of the runner, which hook into
+// every stage of the runs of all the agents of an app.
+//
+// Unlike the callbacks configured on the agents, the plugins are registered
+// once on the runner, which suits cross-cutting concerns like logging, policy
+// enforcement or caching. The hooks of the plugins run in the order the
+// plugins are registered, ahead of the callbacks of the agents. The first
+// hook returning a non-nil result short-circuits the stage: the remaining
+// plugins and the callbacks of the agent are skipped.
+package plugin
+
+import (
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+// Plugin hooks into the stages of the runs of the agents. Embed [Base] to
+// implement only some of the hooks.
+type Plugin interface {
+	// Name returns the name of the plugin, unique within the runner.
+	Name() string
+
+	// OnUserMessage is called with the message of the user before it is
+	// added to the session. A non-nil content replaces the message.
+	OnUserMessage(ctx agent.InvocationContext, msg *genai.Content) (*genai.Content, error)
+	// BeforeRun is called before the agent runs. A non-nil content is
+	// returned as the response of the agent, which doesn't run.
+	BeforeRun(ctx agent.InvocationContext) (*genai.Content, error)
+	// AfterRun is called when the run ends.
+	AfterRun(ctx agent.InvocationContext)
+	// OnEvent is called with each event before it is yielded by the runner
+	// and, unless partial, added to the session. A non-nil event replaces it.
+	OnEvent(ctx agent.InvocationContext, event *session.Event) (*session.Event, error)
+	// OnError is called with the errors of the run.
+	OnError(ctx agent.InvocationContext, err error)
+
+	// BeforeAgent is called before each agent runs. A non-nil content is
+	// returned as the response of the agent, which doesn't run.
+	BeforeAgent(ctx agent.CallbackContext) (*genai.Content, error)
+	// AfterAgent is called after each agent runs. A non-nil content is
+	// returned as an additional response of the agent.
+	AfterAgent(ctx agent.CallbackContext) (*genai.Content, error)
+
+	// BeforeModel is called before each model call. A non-nil response or
+	// an error is used instead of calling the model.
+	BeforeModel(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error)
+	// AfterModel is called with each model response or error. A non-nil
+	// response or an error replaces them.
+	AfterModel(ctx agent.CallbackContext, resp *model.LLMResponse, respErr error) (*model.LLMResponse, error)
+
+	// BeforeTool is called before each tool call. A non-nil result or an
+	// error is used instead of calling the tool.
+	BeforeTool(ctx tool.Context, t tool.Tool, args map[string]any) (map[string]any, error)
+	// AfterTool is called with the result or the error of each tool call. A
+	// non-nil result or an error replaces them.
+	AfterTool(ctx tool.Context, t tool.Tool, args, result map[string]any, toolErr error) (map[string]any, error)
+}
+
+// Base implements all the hooks of [Plugin] as no-ops. It is meant to be
+// embedded in the plugins, which then implement Name and the hooks they need.
+type Base struct{}
+
+func (Base) OnUserMessage(agent.InvocationContext, *genai.Content) (*genai.Content, error) {
+	return nil, nil
+}
+
+func (Base) BeforeRun(agent.InvocationContext) (*genai.Content, error) {
+	return nil, nil
+}
+
+func (Base) AfterRun(agent.InvocationContext) {}
+
+func (Base) OnEvent(agent.InvocationContext, *session.Event) (*session.Event, error) {
+	return nil, nil
+}
+
+func (Base) OnError(agent.InvocationContext, error) {}
+
+func (Base) BeforeAgent(agent.CallbackContext) (*genai.Content, error) {
+	return nil, nil
+}
+
+func (Base) AfterAgent(agent.CallbackContext) (*genai.Content, error) {
+	return nil, nil
+}
+
+func (Base) BeforeModel(agent.CallbackContext, *model.LLMRequest) (*model.LLMResponse, error) {
+	return nil, nil
+}
+
+func (Base) AfterModel(agent.CallbackContext, *model.LLMResponse, error) (*model.LLMResponse, error) {
+	return nil, nil
+}
+
+func (Base) BeforeTool(tool.Context, tool.Tool, map[string]any) (map[string]any, error) {
+	return nil, nil
+}
+
+func (Base) AfterTool(tool.Context, tool.Tool, map[string]any, map[string]any, error) (map[string]any, error) {
+	return nil, nil
+}
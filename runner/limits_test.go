@@ -0,0 +1,187 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/agent/workflowagents/loopagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/modeltest"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+func TestRunner_Limits(t *testing.T) {
+	ping := func() *modeltest.Model {
+		return modeltest.New("test-model",
+			modeltest.FunctionCall("ping", map[string]any{}),
+			modeltest.FunctionCall("ping", map[string]any{}),
+			modeltest.FunctionCall("ping", map[string]any{}))
+	}
+	transfer := func(agentName string) modeltest.Turn {
+		return modeltest.FunctionCall("transfer_to_agent", map[string]any{"agent_name": agentName})
+	}
+
+	tests := []struct {
+		name       string
+		agent      func() agent.Agent
+		cfg        agent.RunConfig
+		wantEvents []string
+	}{
+		{
+			name:  "LLMCalls",
+			agent: func() agent.Agent { return newPingAgent(t, "agent", ping()) },
+			cfg:   agent.RunConfig{MaxLLMCalls: 2},
+			wantEvents: []string{
+				"agent: call ping, turn complete", "agent: response ping",
+				"agent: call ping, turn complete", "agent: response ping",
+				"agent: error MAX_LLM_CALLS_EXCEEDED: invocation exceeded the maximum of 2 LLM calls, turn complete",
+			},
+		},
+		{
+			name:  "ToolCalls",
+			agent: func() agent.Agent { return newPingAgent(t, "agent", ping()) },
+			cfg:   agent.RunConfig{MaxToolCalls: 1},
+			wantEvents: []string{
+				"agent: call ping, turn complete", "agent: response ping",
+				"agent: call ping, turn complete",
+				"agent: error MAX_TOOL_CALLS_EXCEEDED: invocation exceeded the maximum of 1 tool calls, turn complete",
+			},
+		},
+		{
+			name: "AgentTransfers",
+			agent: func() agent.Agent {
+				return must(llmagent.New(llmagent.Config{
+					Name:  "a",
+					Model: modeltest.New("test-model", transfer("b")),
+					SubAgents: []agent.Agent{must(llmagent.New(llmagent.Config{
+						Name:  "b",
+						Model: modeltest.New("test-model", transfer("a")),
+					}))},
+				}))
+			},
+			cfg: agent.RunConfig{MaxAgentTransfers: 1},
+			wantEvents: []string{
+				"a: call transfer_to_agent, turn complete", "a: response transfer_to_agent",
+				"b: call transfer_to_agent, turn complete", "b: response transfer_to_agent",
+				"a: error MAX_AGENT_TRANSFERS_EXCEEDED: invocation exceeded the maximum of 1 agent transfers, turn complete",
+			},
+		},
+		{
+			name: "NoLimits",
+			agent: func() agent.Agent {
+				return newPingAgent(t, "agent", modeltest.New("test-model", modeltest.Text("Hi!")))
+			},
+			wantEvents: []string{"agent: Hi!, turn complete"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, sessionService := newLimitsTestRunner(t, tc.agent())
+
+			var gotEvents []string
+			for event, err := range r.Run(t.Context(), "user", "session", genai.NewContentFromText("Hello", genai.RoleUser), tc.cfg) {
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+				gotEvents = append(gotEvents, describeEvent(event))
+			}
+			if diff := cmp.Diff(tc.wantEvents, gotEvents); diff != "" {
+				t.Errorf("Run() events mismatch (-want +got):\n%s", diff)
+			}
+
+			// The event ending the invocation is stored.
+			resp, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "session"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			events := resp.Session.Events()
+			if got, want := describeEvent(events.At(events.Len()-1)), tc.wantEvents[len(tc.wantEvents)-1]; got != want {
+				t.Errorf("last session event = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestRunner_LimitsTimeout(t *testing.T) {
+	// The sub-agent of the loop runs forever without checking the context.
+	tick := must(agent.New(agent.Config{
+		Name: "tick",
+		Run: func(agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				time.Sleep(time.Millisecond)
+			}
+		},
+	}))
+	loop := must(loopagent.New(loopagent.Config{
+		AgentConfig: agent.Config{Name: "loop", SubAgents: []agent.Agent{tick}},
+	}))
+	r, _ := newLimitsTestRunner(t, loop)
+
+	var lastEvent *session.Event
+	for event, err := range r.Run(t.Context(), "user", "session", genai.NewContentFromText("Hello", genai.RoleUser), agent.RunConfig{Timeout: 20 * time.Millisecond}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		lastEvent = event
+	}
+	if lastEvent == nil || lastEvent.ErrorCode != string(agent.LimitTimeout) {
+		t.Errorf("Run() last event = %+v, want an event with error code %q", lastEvent, agent.LimitTimeout)
+	}
+}
+
+func newPingAgent(t *testing.T, name string, llm model.LLM) agent.Agent {
+	t.Helper()
+	ping, err := functiontool.New(functiontool.Config{
+		Name:        "ping",
+		Description: "pings",
+	}, func(tool.Context, struct{}) (map[string]any, error) {
+		return map[string]any{"result": "pong"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return must(llmagent.New(llmagent.Config{
+		Name:  name,
+		Model: llm,
+		Tools: []tool.Tool{ping},
+	}))
+}
+
+func newLimitsTestRunner(t *testing.T, a agent.Agent) (*Runner, session.Service) {
+	t.Helper()
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{
+		AppName:        "app",
+		Agent:          a,
+		SessionService: sessionService,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, sessionService
+}
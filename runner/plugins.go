@@ -0,0 +1,120 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"fmt"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	agentinternal "google.golang.org/adk/internal/agent"
+	"google.golang.org/adk/internal/agent/runconfig"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/plugin"
+	"google.golang.org/adk/session"
+)
+
+// pluginCallbacks are the agent callbacks of the plugins, which the agents
+// run ahead of their own callbacks.
+type pluginCallbacks = agentinternal.PluginCallbacks[agent.BeforeAgentCallback, agent.AfterAgentCallback]
+
+// newPluginCallbacks returns the agent callbacks of the plugins.
+func newPluginCallbacks(plugins []plugin.Plugin) (*pluginCallbacks, error) {
+	var beforeAgent []agent.BeforeAgentCallback
+	var afterAgent []agent.AfterAgentCallback
+	names := make(map[string]bool)
+	for _, p := range plugins {
+		if p == nil {
+			return nil, fmt.Errorf("plugin is nil")
+		}
+		if names[p.Name()] {
+			return nil, fmt.Errorf("plugin %q is registered more than once", p.Name())
+		}
+		names[p.Name()] = true
+		beforeAgent = append(beforeAgent, p.BeforeAgent)
+		afterAgent = append(afterAgent, p.AfterAgent)
+	}
+	return &pluginCallbacks{BeforeAgent: beforeAgent, AfterAgent: afterAgent}, nil
+}
+
+// plugins returns the plugins of the run, which are the plugins of the runner
+// of the invocation calling the agent as a tool, if any.
+func plugins(ctx agent.InvocationContext) []plugin.Plugin {
+	return runconfig.FromContext(ctx).Plugins
+}
+
+// runOnUserMessagePlugins returns the user message replaced by the first
+// plugin returning a non-nil content, or nil.
+func (r *Runner) runOnUserMessagePlugins(ctx agent.InvocationContext, msg *genai.Content) (*genai.Content, error) {
+	for _, p := range plugins(ctx) {
+		content, err := p.OnUserMessage(ctx, msg)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %q failed on user message: %w", p.Name(), err)
+		}
+		if content != nil {
+			return content, nil
+		}
+	}
+	return nil, nil
+}
+
+// runBeforeRunPlugins returns the event with the content of the first plugin
+// returning a non-nil content, which ends the run before the agent runs, or
+// nil.
+func (r *Runner) runBeforeRunPlugins(ctx agent.InvocationContext) (*session.Event, error) {
+	for _, p := range plugins(ctx) {
+		content, err := p.BeforeRun(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %q failed before run: %w", p.Name(), err)
+		}
+		if content == nil {
+			continue
+		}
+		event := session.NewEvent(ctx.InvocationID())
+		event.Author = ctx.Agent().Name()
+		event.Branch = ctx.Branch()
+		event.LLMResponse = model.LLMResponse{Content: content}
+		return event, nil
+	}
+	return nil, nil
+}
+
+func (r *Runner) runAfterRunPlugins(ctx agent.InvocationContext) {
+	for _, p := range plugins(ctx) {
+		p.AfterRun(ctx)
+	}
+}
+
+// runOnEventPlugins returns the event replaced by the first plugin returning
+// a non-nil event, or nil.
+func (r *Runner) runOnEventPlugins(ctx agent.InvocationContext, event *session.Event) (*session.Event, error) {
+	for _, p := range plugins(ctx) {
+		newEvent, err := p.OnEvent(ctx, event)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %q failed on event: %w", p.Name(), err)
+		}
+		if newEvent != nil {
+			return newEvent, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *Runner) runOnErrorPlugins(ctx agent.InvocationContext, err error) {
+	for _, p := range plugins(ctx) {
+		p.OnError(ctx, err)
+	}
+}
@@ -0,0 +1,431 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/modeltest"
+	"google.golang.org/adk/plugin"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+func TestRunner_Plugins(t *testing.T) {
+	fullLog := []string{
+		"p1.OnUserMessage", "p2.OnUserMessage",
+		"p1.BeforeRun", "p2.BeforeRun",
+		"p1.BeforeAgent", "p2.BeforeAgent", "agent.BeforeAgent",
+		"p1.BeforeModel", "p2.BeforeModel", "agent.BeforeModel",
+		"p1.AfterModel", "p2.AfterModel", "agent.AfterModel",
+		"p1.OnEvent", "p2.OnEvent",
+		"p1.BeforeTool", "p2.BeforeTool", "agent.BeforeTool",
+		"tool.Run",
+		"p1.AfterTool", "p2.AfterTool", "agent.AfterTool",
+		"p1.OnEvent", "p2.OnEvent",
+		"p1.BeforeModel", "p2.BeforeModel", "agent.BeforeModel",
+		"p1.AfterModel", "p2.AfterModel", "agent.AfterModel",
+		"p1.OnEvent", "p2.OnEvent",
+		"p1.AfterAgent", "p2.AfterAgent", "agent.AfterAgent",
+		"p1.AfterRun", "p2.AfterRun",
+	}
+
+	tests := []struct {
+		name string
+		// stage is the stage short-circuited by the first plugin.
+		stage        string
+		wantEvents   []string
+		wantContents []string
+	}{
+		{
+			name:         "NoShortCircuit",
+			wantEvents:   []string{"agent: call ping, turn complete", "agent: response ping", "agent: done, turn complete"},
+			wantContents: []string{"user: Hello", "model: call ping", "user: response ping"},
+		},
+		{
+			name:         "OnUserMessage",
+			stage:        "OnUserMessage",
+			wantEvents:   []string{"agent: call ping, turn complete", "agent: response ping", "agent: done, turn complete"},
+			wantContents: []string{"user: p1 OnUserMessage", "model: call ping", "user: response ping"},
+		},
+		{
+			name:       "BeforeRun",
+			stage:      "BeforeRun",
+			wantEvents: []string{"agent: p1 BeforeRun"},
+		},
+		{
+			name:       "BeforeAgent",
+			stage:      "BeforeAgent",
+			wantEvents: []string{"agent: p1 BeforeAgent"},
+		},
+		{
+			name:       "BeforeModel",
+			stage:      "BeforeModel",
+			wantEvents: []string{"agent: p1 BeforeModel"},
+		},
+		{
+			name:         "AfterModel",
+			stage:        "AfterModel",
+			wantEvents:   []string{"agent: p1 AfterModel"},
+			wantContents: []string{"user: Hello"},
+		},
+		{
+			name:         "BeforeTool",
+			stage:        "BeforeTool",
+			wantEvents:   []string{"agent: call ping, turn complete", "agent: response ping", "agent: done, turn complete"},
+			wantContents: []string{"user: Hello", "model: call ping", "user: response ping"},
+		},
+		{
+			name:         "AfterTool",
+			stage:        "AfterTool",
+			wantEvents:   []string{"agent: call ping, turn complete", "agent: response ping", "agent: done, turn complete"},
+			wantContents: []string{"user: Hello", "model: call ping", "user: response ping"},
+		},
+		{
+			name:       "OnEvent",
+			stage:      "OnEvent",
+			wantEvents: []string{"agent: p1 OnEvent", "agent: p1 OnEvent", "agent: p1 OnEvent"},
+			// The session holds the replaced events.
+			wantContents: []string{"user: Hello", "model: p1 OnEvent", "model: p1 OnEvent"},
+		},
+		{
+			name:         "AfterAgent",
+			stage:        "AfterAgent",
+			wantEvents:   []string{"agent: call ping, turn complete", "agent: response ping", "agent: done, turn complete", "agent: p1 AfterAgent"},
+			wantContents: []string{"user: Hello", "model: call ping", "user: response ping"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var log []string
+			llm := modeltest.New("test-model", modeltest.FunctionCall("ping", map[string]any{}), modeltest.Text("done"))
+			r := newPluginTestRunner(t, llm, &log,
+				&recordingPlugin{name: "p1", log: &log, stage: tc.stage},
+				&recordingPlugin{name: "p2", log: &log})
+
+			var gotEvents []string
+			for ev, err := range r.Run(t.Context(), "user", "session", genai.NewContentFromText("Hello", genai.RoleUser), agent.RunConfig{}) {
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+				gotEvents = append(gotEvents, describeEvent(ev))
+			}
+			if diff := cmp.Diff(tc.wantEvents, gotEvents); diff != "" {
+				t.Errorf("Run() events mismatch (-want +got):\n%s", diff)
+			}
+
+			var gotContents []string
+			if req := llm.LastRequest(); req != nil {
+				gotContents = modeltest.Contents(req)
+			}
+			if diff := cmp.Diff(tc.wantContents, gotContents); diff != "" {
+				t.Errorf("last model request contents mismatch (-want +got):\n%s", diff)
+			}
+
+			if tc.stage == "" {
+				if diff := cmp.Diff(fullLog, log); diff != "" {
+					t.Errorf("hook calls mismatch (-want +got):\n%s", diff)
+				}
+				return
+			}
+			if !slices.Contains(log, "p1."+tc.stage) {
+				t.Errorf("hook calls %v don't contain p1.%s", log, tc.stage)
+			}
+			for _, skipped := range []string{"p2." + tc.stage, "agent." + tc.stage} {
+				if slices.Contains(log, skipped) {
+					t.Errorf("hook calls %v contain short-circuited %s", log, skipped)
+				}
+			}
+			if !slices.Contains(log, "p1.AfterRun") {
+				t.Errorf("hook calls %v don't contain p1.AfterRun", log)
+			}
+		})
+	}
+}
+
+func TestRunner_PluginsOnError(t *testing.T) {
+	var log []string
+	modelErr := errors.New("model failed")
+	llm := modeltest.New("test-model", modeltest.Error(modelErr))
+	r := newPluginTestRunner(t, llm, &log,
+		&recordingPlugin{name: "p1", log: &log},
+		&recordingPlugin{name: "p2", log: &log})
+
+	var gotErr error
+	for _, err := range r.Run(t.Context(), "user", "session", genai.NewContentFromText("Hello", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			gotErr = err
+		}
+	}
+	if !errors.Is(gotErr, modelErr) {
+		t.Errorf("Run() error = %v, want %v", gotErr, modelErr)
+	}
+	// The after agent callbacks run after the error.
+	want := []string{
+		"p1.OnError", "p2.OnError",
+		"p1.AfterAgent", "p2.AfterAgent", "agent.AfterAgent",
+		"p1.AfterRun", "p2.AfterRun",
+	}
+	if got := log[len(log)-len(want):]; !slices.Equal(got, want) {
+		t.Errorf("hook calls %v, want them to end with %v", log, want)
+	}
+}
+
+func TestRunner_PluginsNested(t *testing.T) {
+	var log []string
+	child := must(llmagent.New(llmagent.Config{
+		Name:        "child",
+		Description: "child agent",
+		Model:       modeltest.New("child-model", modeltest.Text("child response")),
+	}))
+	parent := must(llmagent.New(llmagent.Config{
+		Name: "parent",
+		Model: modeltest.New("parent-model",
+			modeltest.FunctionCall("child", map[string]any{"request": "Hello"}),
+			modeltest.Text("done")),
+		Tools: []tool.Tool{nestedAgentTool(t, child)},
+	}))
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{
+		AppName:        "app",
+		Agent:          parent,
+		SessionService: sessionService,
+		Plugins:        []plugin.Plugin{&recordingPlugin{name: "p1", log: &log}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, err := range r.Run(t.Context(), "user", "session", genai.NewContentFromText("Hello", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+	// The plugins of the parent hook into the run of the agent called as a
+	// tool.
+	want := []string{
+		"p1.OnUserMessage", "p1.BeforeRun", "p1.BeforeAgent",
+		"p1.BeforeModel", "p1.AfterModel", "p1.OnEvent",
+		"p1.BeforeTool",
+		"p1.OnUserMessage", "p1.BeforeRun", "p1.BeforeAgent",
+		"p1.BeforeModel", "p1.AfterModel", "p1.OnEvent",
+		"p1.AfterAgent", "p1.AfterRun",
+		"p1.AfterTool", "p1.OnEvent",
+		"p1.BeforeModel", "p1.AfterModel", "p1.OnEvent",
+		"p1.AfterAgent", "p1.AfterRun",
+	}
+	if diff := cmp.Diff(want, log); diff != "" {
+		t.Errorf("hook calls mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNew_Plugins(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		plugins []plugin.Plugin
+		wantErr string
+	}{
+		{
+			name:    "NilPlugin",
+			plugins: []plugin.Plugin{nil},
+			wantErr: "plugin is nil",
+		},
+		{
+			name:    "DuplicateName",
+			plugins: []plugin.Plugin{&recordingPlugin{name: "p1"}, &recordingPlugin{name: "p1"}},
+			wantErr: `plugin "p1" is registered more than once`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(Config{
+				Agent:          must(llmagent.New(llmagent.Config{Name: "agent"})),
+				SessionService: session.InMemoryService(),
+				Plugins:        tc.plugins,
+			})
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("New() error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// newPluginTestRunner returns a runner of an agent calling the ping tool,
+// whose callbacks and tool record their calls in log.
+func newPluginTestRunner(t *testing.T, llm model.LLM, log *[]string, plugins ...plugin.Plugin) *Runner {
+	t.Helper()
+	ping, err := functiontool.New(functiontool.Config{
+		Name:        "ping",
+		Description: "pings",
+	}, func(_ tool.Context, _ struct{}) (map[string]any, error) {
+		*log = append(*log, "tool.Run")
+		return map[string]any{"result": "pong"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := must(llmagent.New(llmagent.Config{
+		Name:  "agent",
+		Model: llm,
+		Tools: []tool.Tool{ping},
+		BeforeAgentCallbacks: []agent.BeforeAgentCallback{func(agent.CallbackContext) (*genai.Content, error) {
+			*log = append(*log, "agent.BeforeAgent")
+			return nil, nil
+		}},
+		AfterAgentCallbacks: []agent.AfterAgentCallback{func(agent.CallbackContext) (*genai.Content, error) {
+			*log = append(*log, "agent.AfterAgent")
+			return nil, nil
+		}},
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{func(agent.CallbackContext, *model.LLMRequest) (*model.LLMResponse, error) {
+			*log = append(*log, "agent.BeforeModel")
+			return nil, nil
+		}},
+		AfterModelCallbacks: []llmagent.AfterModelCallback{func(agent.CallbackContext, *model.LLMResponse, error) (*model.LLMResponse, error) {
+			*log = append(*log, "agent.AfterModel")
+			return nil, nil
+		}},
+		BeforeToolCallbacks: []llmagent.BeforeToolCallback{func(tool.Context, tool.Tool, map[string]any) (map[string]any, error) {
+			*log = append(*log, "agent.BeforeTool")
+			return nil, nil
+		}},
+		AfterToolCallbacks: []llmagent.AfterToolCallback{func(tool.Context, tool.Tool, map[string]any, map[string]any, error) (map[string]any, error) {
+			*log = append(*log, "agent.AfterTool")
+			return nil, nil
+		}},
+	}))
+
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{
+		AppName:        "app",
+		Agent:          a,
+		SessionService: sessionService,
+		Plugins:        plugins,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// recordingPlugin records the calls of its hooks in log. Its hooks of the
+// stage return a non-nil result, short-circuiting the stage.
+type recordingPlugin struct {
+	plugin.Base
+	name  string
+	log   *[]string
+	stage string
+}
+
+func (p *recordingPlugin) Name() string {
+	return p.name
+}
+
+// record records the call of the hook and reports whether it short-circuits
+// the stage.
+func (p *recordingPlugin) record(hook string) bool {
+	*p.log = append(*p.log, p.name+"."+hook)
+	return hook == p.stage
+}
+
+func (p *recordingPlugin) content(hook string) *genai.Content {
+	return genai.NewContentFromText(p.name+" "+hook, genai.RoleModel)
+}
+
+func (p *recordingPlugin) OnUserMessage(agent.InvocationContext, *genai.Content) (*genai.Content, error) {
+	if !p.record("OnUserMessage") {
+		return nil, nil
+	}
+	return genai.NewContentFromText(p.name+" OnUserMessage", genai.RoleUser), nil
+}
+
+func (p *recordingPlugin) BeforeRun(agent.InvocationContext) (*genai.Content, error) {
+	if !p.record("BeforeRun") {
+		return nil, nil
+	}
+	return p.content("BeforeRun"), nil
+}
+
+func (p *recordingPlugin) AfterRun(agent.InvocationContext) {
+	p.record("AfterRun")
+}
+
+func (p *recordingPlugin) OnEvent(_ agent.InvocationContext, event *session.Event) (*session.Event, error) {
+	if !p.record("OnEvent") {
+		return nil, nil
+	}
+	newEvent := *event
+	newEvent.LLMResponse = model.LLMResponse{Content: p.content("OnEvent")}
+	return &newEvent, nil
+}
+
+func (p *recordingPlugin) OnError(agent.InvocationContext, error) {
+	p.record("OnError")
+}
+
+func (p *recordingPlugin) BeforeAgent(agent.CallbackContext) (*genai.Content, error) {
+	if !p.record("BeforeAgent") {
+		return nil, nil
+	}
+	return p.content("BeforeAgent"), nil
+}
+
+func (p *recordingPlugin) AfterAgent(agent.CallbackContext) (*genai.Content, error) {
+	if !p.record("AfterAgent") {
+		return nil, nil
+	}
+	return p.content("AfterAgent"), nil
+}
+
+func (p *recordingPlugin) BeforeModel(agent.CallbackContext, *model.LLMRequest) (*model.LLMResponse, error) {
+	if !p.record("BeforeModel") {
+		return nil, nil
+	}
+	return &model.LLMResponse{Content: p.content("BeforeModel")}, nil
+}
+
+func (p *recordingPlugin) AfterModel(agent.CallbackContext, *model.LLMResponse, error) (*model.LLMResponse, error) {
+	if !p.record("AfterModel") {
+		return nil, nil
+	}
+	return &model.LLMResponse{Content: p.content("AfterModel")}, nil
+}
+
+func (p *recordingPlugin) BeforeTool(tool.Context, tool.Tool, map[string]any) (map[string]any, error) {
+	if !p.record("BeforeTool") {
+		return nil, nil
+	}
+	return map[string]any{"result": p.name + " BeforeTool"}, nil
+}
+
+func (p *recordingPlugin) AfterTool(tool.Context, tool.Tool, map[string]any, map[string]any, error) (map[string]any, error) {
+	if !p.record("AfterTool") {
+		return nil, nil
+	}
+	return map[string]any{"result": p.name + " AfterTool"}, nil
+}
@@ -17,14 +17,19 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"iter"
 	"log"
+	"slices"
 
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/compaction"
+	agentinternal "google.golang.org/adk/internal/agent"
 	"google.golang.org/adk/internal/agent/parentmap"
 	"google.golang.org/adk/internal/agent/runconfig"
 	artifactinternal "google.golang.org/adk/internal/artifact"
@@ -32,9 +37,13 @@ import (
 	"google.golang.org/adk/internal/llminternal"
 	imemory "google.golang.org/adk/internal/memory"
 	"google.golang.org/adk/internal/sessioninternal"
+	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/plugin"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool/toolconfirmation"
+	"google.golang.org/adk/usage"
 )
 
 // Config is used to create a [Runner].
@@ -48,6 +57,22 @@ type Config struct {
 	ArtifactService artifact.Service
 	// optional
 	MemoryService memory.Service
+	// Usage enables the token usage and cost accounting of the model calls.
+	// optional
+	Usage *usage.Config
+	// CredentialService stores the credentials the tools use to call APIs on
+	// behalf of the users. Defaults to [auth.InMemoryCredentialService].
+	// optional
+	CredentialService auth.CredentialService
+	// Plugins hook into the runs of all the agents, ahead of the callbacks of
+	// the agents. They run in the order they are listed.
+	// optional
+	Plugins []plugin.Plugin
+	// Compaction compacts the history of the sessions after the runs which
+	// reach its thresholds. The failures of the compaction don't fail the
+	// runs: they're logged and passed to the OnError hooks of the plugins.
+	// optional
+	Compaction *compaction.Config
 }
 
 // New creates a new [Runner].
@@ -60,18 +85,43 @@ func New(cfg Config) (*Runner, error) {
 		return nil, fmt.Errorf("session service is required")
 	}
 
+	if cfg.Usage != nil && cfg.Usage.Service == nil {
+		return nil, fmt.Errorf("usage service is required when usage accounting is enabled")
+	}
+
+	if cfg.Compaction != nil {
+		if err := cfg.Compaction.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	credentialService := cfg.CredentialService
+	if credentialService == nil {
+		credentialService = auth.InMemoryCredentialService()
+	}
+
 	parents, err := parentmap.New(cfg.Agent)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create agent tree: %w", err)
 	}
 
+	pluginCallbacks, err := newPluginCallbacks(cfg.Plugins)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Runner{
-		appName:         cfg.AppName,
-		rootAgent:       cfg.Agent,
-		sessionService:  cfg.SessionService,
-		artifactService: cfg.ArtifactService,
-		memoryService:   cfg.MemoryService,
-		parents:         parents,
+		appName:           cfg.AppName,
+		rootAgent:         cfg.Agent,
+		sessionService:    cfg.SessionService,
+		artifactService:   cfg.ArtifactService,
+		memoryService:     cfg.MemoryService,
+		usage:             cfg.Usage,
+		credentialService: credentialService,
+		plugins:           cfg.Plugins,
+		pluginCallbacks:   pluginCallbacks,
+		compaction:        cfg.Compaction,
+		parents:           parents,
 	}, nil
 }
 
@@ -79,11 +129,16 @@ func New(cfg Config) (*Runner, error) {
 // processing, event generation, and interaction with various services like
 // artifact storage, session management, and memory.
 type Runner struct {
-	appName         string
-	rootAgent       agent.Agent
-	sessionService  session.Service
-	artifactService artifact.Service
-	memoryService   memory.Service
+	appName           string
+	rootAgent         agent.Agent
+	sessionService    session.Service
+	artifactService   artifact.Service
+	memoryService     memory.Service
+	usage             *usage.Config
+	credentialService auth.CredentialService
+	plugins           []plugin.Plugin
+	pluginCallbacks   *pluginCallbacks
+	compaction        *compaction.Config
 
 	parents parentmap.Map
 }
@@ -91,7 +146,44 @@ type Runner struct {
 // Run runs the agent for the given user input, yielding events from agents.
 // For each user message it finds the proper agent within an agent tree to
 // continue the conversation within the session.
+//
+// When ctx is the context of a tool, e.g. to run an agent as a tool, the run
+// is part of the invocation calling the tool: it shares its limits, usage
+// accounting and budget, credentials and plugins instead of the runner's.
 func (r *Runner) Run(ctx context.Context, userID, sessionID string, msg *genai.Content, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
+	return r.run(ctx, userID, sessionID, msg, nil, cfg)
+}
+
+// RunLive runs the agent in the bidi streaming mode, yielding events from
+// agents. The user input (text, audio and video) is read from the queue as
+// it arrives, until the queue is closed. The agents must use a model
+// implementing [model.LiveLLM].
+//
+// Non-partial events, including the user content sent through the queue and
+// the finished audio transcriptions, are committed to the session.
+func (r *Runner) RunLive(ctx context.Context, userID, sessionID string, queue *agent.LiveRequestQueue, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
+	if queue == nil {
+		return func(yield func(*session.Event, error) bool) {
+			yield(nil, fmt.Errorf("live request queue is required"))
+		}
+	}
+	cfg.StreamingMode = agent.StreamingModeBidi
+	return r.run(ctx, userID, sessionID, nil, queue, cfg)
+}
+
+// Usage returns the usage of the model calls made by the agents of the app.
+// The AppName of the request is set to the app of the runner. It fails if
+// the usage accounting isn't enabled.
+func (r *Runner) Usage(ctx context.Context, req *usage.GetRequest) (*usage.GetResponse, error) {
+	if r.usage == nil {
+		return nil, fmt.Errorf("usage accounting is not enabled")
+	}
+	appReq := *req
+	appReq.AppName = r.appName
+	return r.usage.Service.Get(ctx, &appReq)
+}
+
+func (r *Runner) run(ctx context.Context, userID, sessionID string, msg *genai.Content, queue *agent.LiveRequestQueue, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
 	// TODO(hakim): we need to validate whether cfg is compatible with the Agent.
 	//   see adk-python/src/google/adk/runners.py Runner._new_invocation_context.
 	// TODO: setup tracer.
@@ -108,16 +200,46 @@ func (r *Runner) Run(ctx context.Context, userID, sessionID string, msg *genai.C
 
 		session := resp.Session
 
-		agentToRun, err := r.findAgentToRun(session)
+		agentToRun, err := r.findAgentToRun(session, msg)
 		if err != nil {
 			yield(nil, err)
 			return
 		}
 
+		// The runners of the agents called as tools share the limits and the
+		// usage budget of the invocation calling them, which ends when
+		// they're exceeded, its credentials and its plugins.
+		limits, usageCfg := runconfig.NewLimits(&cfg), r.usage
+		credentialService, scope := r.credentialService, &runconfig.Scope{AppName: session.AppName(), UserID: session.UserID(), SessionID: session.ID()}
+		plugins := r.plugins
+		parentCfg := runconfig.FromContext(ctx)
+		nested := parentCfg != nil
+		if nested {
+			limits, usageCfg = parentCfg.Limits, parentCfg.Usage
+			credentialService, scope = parentCfg.CredentialService, parentCfg.Scope
+			plugins = parentCfg.Plugins
+		}
+		if deadline, ok := limits.Deadline(); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, deadline)
+			defer cancel()
+		}
+
 		ctx = parentmap.ToContext(ctx, r.parents)
 		ctx = runconfig.ToContext(ctx, &runconfig.RunConfig{
-			StreamingMode: runconfig.StreamingMode(cfg.StreamingMode),
+			StreamingMode:     runconfig.StreamingMode(cfg.StreamingMode),
+			LiveRequestQueue:  queue,
+			Usage:             usageCfg,
+			CredentialService: credentialService,
+			Scope:             scope,
+			Plugins:           plugins,
+			Limits:            limits,
 		})
+		if !nested {
+			// The contexts of the nested runners carry the callbacks of the
+			// plugins of the parent already.
+			ctx = agentinternal.PluginCallbacksToContext(ctx, r.pluginCallbacks)
+		}
 
 		var artifacts agent.Artifacts
 		if r.artifactService != nil {
@@ -147,20 +269,71 @@ func (r *Runner) Run(ctx context.Context, userID, sessionID string, msg *genai.C
 			UserContent: msg,
 			RunConfig:   &cfg,
 		})
+		if !nested {
+			scope.InvocationID = ctx.InvocationID()
+		}
+		defer func() { r.runAfterRunPlugins(ctx) }()
+
+		if msg != nil {
+			newMsg, err := r.runOnUserMessagePlugins(ctx, msg)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if newMsg != nil {
+				msg = newMsg
+				ctx = icontext.WithUserContent(ctx, msg)
+			}
+		}
 
 		if err := r.appendMessageToSession(ctx, session, msg, cfg.SaveInputBlobsAsArtifacts); err != nil {
 			yield(nil, err)
 			return
 		}
 
+		event, err := r.runBeforeRunPlugins(ctx)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if event != nil {
+			if err := r.sessionService.AppendEvent(ctx, session, event); err != nil {
+				yield(nil, fmt.Errorf("failed to add event to session: %w", err))
+				return
+			}
+			yield(event, nil)
+			return
+		}
+
 		for event, err := range agentToRun.Run(ctx) {
 			if err != nil {
+				r.runOnErrorPlugins(ctx, err)
+				if limitErr, ok := limits.Exceeded(err); ok && !nested {
+					event, err := r.appendErrorEvent(ctx, session, string(limitErr.Limit), limitErr.Error())
+					yield(event, err)
+					return
+				}
+				var budgetErr *usage.BudgetExceededError
+				if errors.As(err, &budgetErr) && !nested {
+					event, err := r.appendErrorEvent(ctx, session, usage.ErrorCodeBudgetExceeded, budgetErr.Error())
+					yield(event, err)
+					return
+				}
 				if !yield(event, err) {
 					return
 				}
 				continue
 			}
 
+			newEvent, err := r.runOnEventPlugins(ctx, event)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if newEvent != nil {
+				event = newEvent
+			}
+
 			// only commit non-partial event to a session service
 			if !event.LLMResponse.Partial {
 				if err := r.sessionService.AppendEvent(ctx, session, event); err != nil {
@@ -173,7 +346,48 @@ func (r *Runner) Run(ctx context.Context, userID, sessionID string, msg *genai.C
 				return
 			}
 		}
+
+		// The run is complete: compaction is best effort, a failure leaves
+		// the history as it is until the next run.
+		if err := r.compact(ctx, session); err != nil {
+			r.runOnErrorPlugins(ctx, err)
+			log.Printf("Failed to compact session %s: %v", session.ID(), err)
+		}
+	}
+}
+
+// compact appends a compaction event to the session if its history reaches
+// the thresholds of the compaction.
+func (r *Runner) compact(ctx agent.InvocationContext, storedSession session.Session) error {
+	if r.compaction == nil {
+		return nil
+	}
+	event, err := compaction.Compact(ctx, r.compaction, ctx.InvocationID(), storedSession.Events())
+	if err != nil || event == nil {
+		return err
 	}
+	if err := r.sessionService.AppendEvent(ctx, storedSession, event); err != nil {
+		return fmt.Errorf("failed to add compaction event to session: %w", err)
+	}
+	return nil
+}
+
+// appendErrorEvent appends the event ending an invocation which exceeded a
+// limit of its run config or its usage budget.
+func (r *Runner) appendErrorEvent(ctx agent.InvocationContext, storedSession session.Session, code, message string) (*session.Event, error) {
+	event := session.NewEvent(ctx.InvocationID())
+	event.Author = ctx.Agent().Name()
+	event.Branch = ctx.Branch()
+	event.LLMResponse = model.LLMResponse{
+		ErrorCode:    code,
+		ErrorMessage: message,
+		TurnComplete: true,
+	}
+	// The event is stored even if the invocation timed out.
+	if err := r.sessionService.AppendEvent(context.WithoutCancel(ctx), storedSession, event); err != nil {
+		return nil, fmt.Errorf("failed to add event to session: %w", err)
+	}
+	return event, nil
 }
 
 func (r *Runner) appendMessageToSession(ctx agent.InvocationContext, storedSession session.Session, msg *genai.Content, saveInputBlobsAsArtifacts bool) error {
@@ -213,13 +427,26 @@ func (r *Runner) appendMessageToSession(ctx agent.InvocationContext, storedSessi
 
 // findAgentToRun returns the agent that should handle the next request based on
 // session history.
-func (r *Runner) findAgentToRun(session session.Session) (agent.Agent, error) {
+func (r *Runner) findAgentToRun(session session.Session, msg *genai.Content) (agent.Agent, error) {
 	events := session.Events()
+
+	// The function responses to long-running function calls are sent to the
+	// agent which made the calls, regardless of its type.
+	callEvent, err := findMatchingFunctionCall(events, msg)
+	if err != nil {
+		return nil, err
+	}
+	if callEvent != nil {
+		agentToRun := findAgent(r.rootAgent, callEvent.Author)
+		if agentToRun == nil {
+			return nil, fmt.Errorf("agent %q which made the long-running function call not found", callEvent.Author)
+		}
+		return agentToRun, nil
+	}
+
 	for i := events.Len() - 1; i >= 0; i-- {
 		event := events.At(i)
 
-		// TODO: findMatchingFunctionCall.
-
 		if event.Author == "user" {
 			continue
 		}
@@ -240,6 +467,60 @@ func (r *Runner) findAgentToRun(session session.Session) (agent.Agent, error) {
 	return r.rootAgent, nil
 }
 
+// findMatchingFunctionCall returns the event of the pending long-running
+// function calls the function responses of msg respond to, or nil if msg has
+// no function responses.
+//
+// reference: adk-python src/google/adk/runners.py _find_agent_to_run
+func findMatchingFunctionCall(events session.Events, msg *genai.Content) (*session.Event, error) {
+	var match *session.Event
+	for _, fr := range utils.FunctionResponses(msg) {
+		ev, err := findPendingFunctionCall(events, fr.ID)
+		if err != nil {
+			return nil, err
+		}
+		if match != nil && match.Author != ev.Author {
+			return nil, fmt.Errorf("function responses respond to the function calls of different agents: %q and %q", match.Author, ev.Author)
+		}
+		match = ev
+	}
+	return match, nil
+}
+
+// findPendingFunctionCall returns the event of the pending long-running
+// function call with the ID. The long-running calls of tools stay pending, as
+// the client can respond several times, e.g. with the progress of the
+// operation, but the requests of credentials and confirmations are only
+// responded to once.
+func findPendingFunctionCall(events session.Events, id string) (*session.Event, error) {
+	if id == "" {
+		return nil, errors.New("function response without the ID of its function call")
+	}
+	responded := false
+	for i := events.Len() - 1; i >= 0; i-- {
+		ev := events.At(i)
+		if ev.Author == "user" {
+			for _, fr := range utils.FunctionResponses(ev.Content) {
+				responded = responded || fr.ID == id
+			}
+			continue
+		}
+		for _, fc := range utils.FunctionCalls(ev.Content) {
+			if fc.ID != id {
+				continue
+			}
+			if !slices.Contains(ev.LongRunningToolIDs, id) {
+				return nil, fmt.Errorf("function call %q is not a long-running call", id)
+			}
+			if responded && (fc.Name == auth.FunctionCallName || fc.Name == toolconfirmation.FunctionCallName) {
+				return nil, fmt.Errorf("function call %q was already responded to", id)
+			}
+			return ev, nil
+		}
+	}
+	return nil, fmt.Errorf("function call %q not found in the session", id)
+}
+
 // checks if the agent and its parent chain allow transfer up the tree.
 func (r *Runner) isTransferableAcrossAgentTree(agentToRun agent.Agent) bool {
 	for curAgent := agentToRun; curAgent != nil; curAgent = r.parents[curAgent.Name()] {
@@ -19,15 +19,25 @@ import (
 	"context"
 	"fmt"
 	"iter"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/agent/workflowagents/parallelagent"
 	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/modeltest"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/adk/tool/toolconfirmation"
+	"google.golang.org/adk/usage"
 )
 
 func TestRunner_findAgentToRun(t *testing.T) {
@@ -41,6 +51,7 @@ func TestRunner_findAgentToRun(t *testing.T) {
 		name      string
 		rootAgent agent.Agent
 		session   session.Session
+		msg       *genai.Content
 		wantAgent agent.Agent
 		wantErr   bool
 	}{
@@ -80,6 +91,82 @@ func TestRunner_findAgentToRun(t *testing.T) {
 			rootAgent: agentTree.root,
 			wantAgent: agentTree.root,
 		},
+		{
+			name: "function response to long-running call of agent not allowing transfer",
+			session: createSession(t, t.Context(), appName, userID, sessionID, []*session.Event{
+				functionCallEvent("no_transfer_agent", "call1", true),
+				{
+					Author: "allows_transfer_agent",
+				},
+			}),
+			msg:       functionResponse("call1"),
+			rootAgent: agentTree.root,
+			wantAgent: agentTree.noTransferAgent,
+		},
+		{
+			name: "function response to unknown call",
+			session: createSession(t, t.Context(), appName, userID, sessionID, []*session.Event{
+				functionCallEvent("no_transfer_agent", "call1", true),
+			}),
+			msg:       functionResponse("call2"),
+			rootAgent: agentTree.root,
+			wantErr:   true,
+		},
+		{
+			name: "function response to call which is not long-running",
+			session: createSession(t, t.Context(), appName, userID, sessionID, []*session.Event{
+				functionCallEvent("no_transfer_agent", "call1", false),
+			}),
+			msg:       functionResponse("call1"),
+			rootAgent: agentTree.root,
+			wantErr:   true,
+		},
+		{
+			name: "progress of long-running call",
+			session: createSession(t, t.Context(), appName, userID, sessionID, []*session.Event{
+				functionCallEvent("no_transfer_agent", "call1", true),
+				{
+					Author:      "user",
+					LLMResponse: model.LLMResponse{Content: functionResponse("call1")},
+				},
+				{
+					Author: "allows_transfer_agent",
+				},
+			}),
+			msg:       functionResponse("call1"),
+			rootAgent: agentTree.root,
+			wantAgent: agentTree.noTransferAgent,
+		},
+		{
+			name: "confirmation request already responded to",
+			session: createSession(t, t.Context(), appName, userID, sessionID, []*session.Event{
+				{
+					Author: "no_transfer_agent",
+					LLMResponse: model.LLMResponse{Content: genai.NewContentFromParts([]*genai.Part{
+						{FunctionCall: &genai.FunctionCall{ID: "call1", Name: toolconfirmation.FunctionCallName}},
+					}, genai.RoleModel)},
+					LongRunningToolIDs: []string{"call1"},
+				},
+				{
+					Author:      "user",
+					LLMResponse: model.LLMResponse{Content: functionResponse("call1")},
+				},
+			}),
+			msg:       functionResponse("call1"),
+			rootAgent: agentTree.root,
+			wantErr:   true,
+		},
+		{
+			name: "function responses to calls of different agents",
+			session: createSession(t, t.Context(), appName, userID, sessionID, []*session.Event{
+				functionCallEvent("no_transfer_agent", "call1", true),
+				functionCallEvent("allows_transfer_agent", "call2", true),
+			}),
+			msg: &genai.Content{Role: genai.RoleUser, Parts: append(
+				functionResponse("call1").Parts, functionResponse("call2").Parts...)},
+			rootAgent: agentTree.root,
+			wantErr:   true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -87,7 +174,7 @@ func TestRunner_findAgentToRun(t *testing.T) {
 			r := &Runner{
 				rootAgent: tt.rootAgent,
 			}
-			gotAgent, err := r.findAgentToRun(tt.session)
+			gotAgent, err := r.findAgentToRun(tt.session, tt.msg)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Runner.findAgentToRun() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -314,6 +401,469 @@ func TestRunner_SaveInputBlobsAsArtifacts(t *testing.T) {
 	}
 }
 
+func TestRunner_RunLive(t *testing.T) {
+	ctx := t.Context()
+	appName, userID, sessionID := "testApp", "testUser", "testSession"
+
+	type Args struct {
+		City string `json:"city"`
+	}
+	weather, err := functiontool.New(functiontool.Config{
+		Name:        "get_weather",
+		Description: "returns the weather in a city",
+	}, func(_ tool.Context, args Args) (map[string]any, error) {
+		return map[string]any{"weather": "sunny in " + args.City}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The model answers the user content, the function response and the
+	// audio of the user, in order.
+	llm := modeltest.NewLive("live-model",
+		modeltest.Turn{Responses: []*model.LLMResponse{
+			{Content: genai.NewContentFromText("Let me check.", genai.RoleModel), Partial: true},
+			{Content: genai.NewContentFromText("Let me check.", genai.RoleModel)},
+			{Content: genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Paris"}, genai.RoleModel)},
+		}},
+		modeltest.Turn{Responses: []*model.LLMResponse{
+			{Content: genai.NewContentFromText("It's sunny.", genai.RoleModel)},
+			{TurnComplete: true},
+		}},
+		modeltest.Turn{Responses: []*model.LLMResponse{
+			{InputTranscription: &genai.Transcription{Text: "Stop"}, Partial: true},
+			{InputTranscription: &genai.Transcription{Text: "Stop", Finished: true}},
+			{Interrupted: true},
+		}},
+	)
+	a := must(llmagent.New(llmagent.Config{
+		Name:  "live_agent",
+		Model: llm,
+		Tools: []tool.Tool{weather},
+	}))
+
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: sessionID}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{AppName: appName, Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queue := agent.NewLiveRequestQueue()
+	if err := queue.SendContent(genai.NewContentFromText("Weather in Paris?", genai.RoleUser)); err != nil {
+		t.Fatal(err)
+	}
+	cfg := agent.RunConfig{ResponseModalities: []genai.Modality{genai.ModalityText}}
+	var partials int
+	for ev, err := range r.RunLive(ctx, userID, sessionID, queue, cfg) {
+		if err != nil {
+			t.Fatalf("RunLive() error = %v", err)
+		}
+		if ev.Partial {
+			partials++
+		}
+		switch {
+		case ev.TurnComplete:
+			// The user speaks after the model answered...
+			if err := queue.SendRealtime(&genai.Blob{MIMEType: "audio/pcm", Data: []byte{0, 1}}); err != nil {
+				t.Fatal(err)
+			}
+		case ev.Interrupted:
+			// ...and interrupts it, which ends the session.
+			queue.Close()
+		}
+	}
+	if partials != 2 {
+		t.Errorf("RunLive() yielded %d partial events, want 2", partials)
+	}
+
+	if err := llm.Done(); err != nil {
+		t.Error(err)
+	}
+	connectReq := llm.Requests()[0]
+	if got := connectReq.LiveConnectConfig.ResponseModalities; !slices.Equal(got, cfg.ResponseModalities) {
+		t.Errorf("Connect() response modalities = %v, want %v", got, cfg.ResponseModalities)
+	}
+	if diff := cmp.Diff([]string{"get_weather"}, modeltest.ToolNames(connectReq)); diff != "" {
+		t.Errorf("Connect() tools mismatch (-want +got):\n%s", diff)
+	}
+
+	resp, err := sessionService.Get(ctx, &session.GetRequest{AppName: appName, UserID: userID, SessionID: sessionID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for ev := range resp.Session.Events().All() {
+		got = append(got, describeEvent(ev))
+	}
+	want := []string{
+		"user: Weather in Paris?",
+		"live_agent: Let me check.",
+		"live_agent: call get_weather",
+		"live_agent: response get_weather",
+		"live_agent: It's sunny.",
+		"live_agent: turn complete",
+		"user: Stop",
+		"live_agent: interrupted",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("session events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_RunLiveUsage(t *testing.T) {
+	ctx := t.Context()
+	appName, userID, sessionID := "testApp", "testUser", "testSession"
+
+	// The first answer exceeds the budget, which ends the live session.
+	llm := modeltest.NewLive("live-model", modeltest.Turn{Responses: []*model.LLMResponse{
+		{Content: genai.NewContentFromText("Hello!", genai.RoleModel)},
+		{TurnComplete: true},
+	}}.WithUsage(240, 60))
+	a := must(llmagent.New(llmagent.Config{Name: "live_agent", Model: llm}))
+
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: sessionID}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{
+		AppName:        appName,
+		Agent:          a,
+		SessionService: sessionService,
+		Usage: &usage.Config{
+			Service: usage.InMemoryService(),
+			Budget:  usage.Budget{Invocation: usage.Limit{MaxTokens: 250}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queue := agent.NewLiveRequestQueue()
+	defer queue.Close()
+	if err := queue.SendContent(genai.NewContentFromText("Hi", genai.RoleUser)); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for ev, err := range r.RunLive(ctx, userID, sessionID, queue, agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("RunLive() error = %v", err)
+		}
+		if ev.TurnComplete {
+			// The budget is checked before the user could speak again.
+			queue.Close()
+		}
+		got = append(got, describeEvent(ev))
+	}
+	want := []string{
+		"user: Hi",
+		"live_agent: Hello!",
+		"live_agent: turn complete",
+		"live_agent: error USAGE_BUDGET_EXCEEDED: invocation usage of 300 tokens and cost 0 exceeded the budget, turn complete",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("RunLive() events mismatch (-want +got):\n%s", diff)
+	}
+
+	resp, err := r.Usage(ctx, &usage.GetRequest{UserID: userID, SessionID: sessionID})
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	wantUsage := usage.Usage{PromptTokens: 240, CandidatesTokens: 60, TotalTokens: 300, Calls: 1}
+	if diff := cmp.Diff(wantUsage, resp.ByAgent["live_agent"]); diff != "" {
+		t.Errorf("Usage() agent mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_RunLiveLimits(t *testing.T) {
+	ctx := t.Context()
+	appName, userID, sessionID := "testApp", "testUser", "testSession"
+
+	// The agent transfers to another live agent, whose connection exceeds the
+	// limit of LLM calls.
+	a := must(llmagent.New(llmagent.Config{
+		Name: "live_agent",
+		Model: modeltest.NewLive("live-model", modeltest.Turn{Responses: []*model.LLMResponse{
+			{Content: genai.NewContentFromFunctionCall("transfer_to_agent", map[string]any{"agent_name": "other_agent"}, genai.RoleModel)},
+		}}, modeltest.Turn{}),
+		SubAgents: []agent.Agent{must(llmagent.New(llmagent.Config{
+			Name:        "other_agent",
+			Description: "another agent",
+			Model:       modeltest.NewLive("other-model"),
+		}))},
+	}))
+
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: sessionID}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{AppName: appName, Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queue := agent.NewLiveRequestQueue()
+	defer queue.Close()
+	if err := queue.SendContent(genai.NewContentFromText("Hi", genai.RoleUser)); err != nil {
+		t.Fatal(err)
+	}
+	var last *session.Event
+	for ev, err := range r.RunLive(ctx, userID, sessionID, queue, agent.RunConfig{MaxLLMCalls: 1}) {
+		if err != nil {
+			t.Fatalf("RunLive() error = %v", err)
+		}
+		if ev.Actions.TransferToAgent != "" {
+			// The other agent doesn't wait for the user.
+			queue.Close()
+		}
+		last = ev
+	}
+	if last == nil {
+		t.Fatal("RunLive() returned no events")
+	}
+	if last.ErrorCode != string(agent.LimitLLMCalls) {
+		t.Errorf("RunLive() last event = %q, want a %s error", describeEvent(last), agent.LimitLLMCalls)
+	}
+}
+
+func TestRunner_Usage(t *testing.T) {
+	ctx := t.Context()
+	appName, userID, sessionID := "testApp", "testUser", "testSession"
+
+	ping, err := functiontool.New(functiontool.Config{
+		Name:        "ping",
+		Description: "pings",
+	}, func(_ tool.Context, _ struct{}) (map[string]any, error) {
+		return map[string]any{"result": "pong"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The model would call the tool again: the budget stops it after three
+	// calls of 100 tokens.
+	pingCall := modeltest.FunctionCall("ping", map[string]any{}).WithUsage(80, 20)
+	llm := modeltest.New("ping-model", pingCall, pingCall, pingCall)
+	a := must(llmagent.New(llmagent.Config{
+		Name:  "looping_agent",
+		Model: llm,
+		Tools: []tool.Tool{ping},
+	}))
+
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: sessionID}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{
+		AppName:        appName,
+		Agent:          a,
+		SessionService: sessionService,
+		Usage: &usage.Config{
+			Service: usage.InMemoryService(),
+			Prices:  usage.PriceTable{"ping-model": {Input: 1, Output: 10}},
+			Budget:  usage.Budget{Invocation: usage.Limit{MaxTokens: 250}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls int
+	var last *session.Event
+	for ev, err := range r.Run(ctx, userID, sessionID, genai.NewContentFromText("Go", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if ev.Content != nil && ev.Content.Parts[0].FunctionCall != nil {
+			calls++
+		}
+		last = ev
+	}
+	if calls != 3 {
+		t.Errorf("Run() made %d model calls, want 3", calls)
+	}
+	if err := llm.Done(); err != nil {
+		t.Error(err)
+	}
+	if last == nil {
+		t.Fatal("Run() returned no events")
+	}
+	wantLast := "looping_agent: error USAGE_BUDGET_EXCEEDED: invocation usage of 300 tokens and cost 0.00084 exceeded the budget, turn complete"
+	if got := describeEvent(last); got != wantLast {
+		t.Errorf("Run() last event = %q, want %q", got, wantLast)
+	}
+
+	got, err := r.Usage(ctx, &usage.GetRequest{UserID: userID, SessionID: sessionID})
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	want := usage.Usage{PromptTokens: 240, CandidatesTokens: 60, TotalTokens: 300, Calls: 3, Cost: 0.00084}
+	if diff := cmp.Diff(want, got.Total, cmpopts.EquateApprox(0, 1e-12)); diff != "" {
+		t.Errorf("Usage() total mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want, got.ByAgent["looping_agent"], cmpopts.EquateApprox(0, 1e-12)); diff != "" {
+		t.Errorf("Usage() agent mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_NestedUsage(t *testing.T) {
+	ctx := t.Context()
+	appName, userID, sessionID := "testApp", "testUser", "testSession"
+
+	child := must(llmagent.New(llmagent.Config{
+		Name:        "child",
+		Description: "child agent",
+		Model:       modeltest.New("child-model", modeltest.Text("child response").WithUsage(240, 60)),
+	}))
+	// The parent isn't called again: the usage of the child exceeds the
+	// budget of the invocation.
+	parent := must(llmagent.New(llmagent.Config{
+		Name:  "parent",
+		Model: modeltest.New("parent-model", modeltest.FunctionCall("child", map[string]any{"request": "Go"}).WithUsage(80, 20)),
+		Tools: []tool.Tool{nestedAgentTool(t, child)},
+	}))
+
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: sessionID}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{
+		AppName:        appName,
+		Agent:          parent,
+		SessionService: sessionService,
+		Usage: &usage.Config{
+			Service: usage.InMemoryService(),
+			Budget:  usage.Budget{Invocation: usage.Limit{MaxTokens: 250}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for ev, err := range r.Run(ctx, userID, sessionID, genai.NewContentFromText("Go", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		got = append(got, describeEvent(ev))
+	}
+	want := []string{
+		"parent: call child, turn complete",
+		"parent: response child",
+		"parent: error USAGE_BUDGET_EXCEEDED: invocation usage of 400 tokens and cost 0 exceeded the budget, turn complete",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Run() events mismatch (-want +got):\n%s", diff)
+	}
+
+	// The usage of the child is recorded in the session of the parent.
+	resp, err := r.Usage(ctx, &usage.GetRequest{UserID: userID, SessionID: sessionID})
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	wantChild := usage.Usage{PromptTokens: 240, CandidatesTokens: 60, TotalTokens: 300, Calls: 1}
+	if diff := cmp.Diff(wantChild, resp.ByAgent["child"]); diff != "" {
+		t.Errorf("Usage() child mismatch (-want +got):\n%s", diff)
+	}
+	if resp.Total.TotalTokens != 400 {
+		t.Errorf("Usage() total tokens = %d, want 400", resp.Total.TotalTokens)
+	}
+}
+
+func TestRunner_InvocationID(t *testing.T) {
+	ctx := t.Context()
+	appName, userID, sessionID := "testApp", "testUser", "testSession"
+
+	// The LLM agent runs in the branch of the parallel agent, which both
+	// create their own invocation context.
+	a := must(parallelagent.New(parallelagent.Config{
+		AgentConfig: agent.Config{
+			Name: "parallel",
+			SubAgents: []agent.Agent{must(llmagent.New(llmagent.Config{
+				Name:  "llm_agent",
+				Model: modeltest.New("test-model", modeltest.Text("Hello.")),
+			}))},
+		},
+	}))
+
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: sessionID}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{AppName: appName, Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range r.Run(ctx, userID, sessionID, genai.NewContentFromText("Hi", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+
+	resp, err := sessionService.Get(ctx, &session.GetRequest{AppName: appName, UserID: userID, SessionID: sessionID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := slices.Collect(resp.Session.Events().All())
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want the user event and the reply", len(events))
+	}
+	// The events of all the agents of the invocation share the ID of the
+	// user event, so that the history can be grouped by invocation.
+	for _, ev := range events[1:] {
+		if ev.InvocationID != events[0].InvocationID {
+			t.Errorf("event of %q has InvocationID %q, want %q", ev.Author, ev.InvocationID, events[0].InvocationID)
+		}
+	}
+}
+
+func describeEvent(ev *session.Event) string {
+	var desc []string
+	if ev.Content != nil {
+		for _, p := range ev.Content.Parts {
+			switch {
+			case p.FunctionCall != nil:
+				desc = append(desc, "call "+p.FunctionCall.Name)
+			case p.FunctionResponse != nil:
+				desc = append(desc, "response "+p.FunctionResponse.Name)
+			default:
+				desc = append(desc, p.Text)
+			}
+		}
+	}
+	if ev.ErrorCode != "" {
+		desc = append(desc, fmt.Sprintf("error %s: %s", ev.ErrorCode, ev.ErrorMessage))
+	}
+	if ev.TurnComplete {
+		desc = append(desc, "turn complete")
+	}
+	if ev.Interrupted {
+		desc = append(desc, "interrupted")
+	}
+	return ev.Author + ": " + strings.Join(desc, ", ")
+}
+
+// functionCallEvent returns an event of the agent calling a function.
+func functionCallEvent(author, callID string, longRunning bool) *session.Event {
+	ev := &session.Event{
+		Author: author,
+		LLMResponse: model.LLMResponse{Content: genai.NewContentFromParts([]*genai.Part{
+			{FunctionCall: &genai.FunctionCall{ID: callID, Name: "fn"}},
+		}, genai.RoleModel)},
+	}
+	if longRunning {
+		ev.LongRunningToolIDs = []string{callID}
+	}
+	return ev
+}
+
+// functionResponse returns the content of the user responding to the function call.
+func functionResponse(callID string) *genai.Content {
+	return genai.NewContentFromParts([]*genai.Part{
+		{FunctionResponse: &genai.FunctionResponse{ID: callID, Name: "fn", Response: map[string]any{"result": "done"}}},
+	}, genai.RoleUser)
+}
+
 // creates agentTree for tests and returns references to the agents
 func agentTree(t *testing.T) agentTreeStruct {
 	t.Helper()
@@ -341,6 +891,43 @@ type agentTreeStruct struct {
 	root, noTransferAgent, allowsTransferAgent agent.Agent
 }
 
+// nestedAgentTool returns a tool running the agent with its own runner, like
+// the agent tool, which this package can't import.
+func nestedAgentTool(t *testing.T, a agent.Agent) tool.Tool {
+	t.Helper()
+	type Args struct {
+		Request string `json:"request"`
+	}
+	nested, err := functiontool.New(functiontool.Config{
+		Name:        a.Name(),
+		Description: a.Description(),
+	}, func(ctx tool.Context, args Args) (map[string]any, error) {
+		sessionService := session.InMemoryService()
+		r, err := New(Config{AppName: a.Name(), Agent: a, SessionService: sessionService})
+		if err != nil {
+			return nil, err
+		}
+		resp, err := sessionService.Create(ctx, &session.CreateRequest{AppName: a.Name(), UserID: ctx.UserID()})
+		if err != nil {
+			return nil, err
+		}
+		var texts []string
+		for ev, err := range r.Run(ctx, ctx.UserID(), resp.Session.ID(), genai.NewContentFromText(args.Request, genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				return nil, err
+			}
+			if ev.Content != nil && ev.Content.Parts[0].Text != "" {
+				texts = append(texts, ev.Content.Parts[0].Text)
+			}
+		}
+		return map[string]any{"result": strings.Join(texts, "\n")}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nested
+}
+
 func must[T agent.Agent](a T, err error) T {
 	if err != nil {
 		panic(err)
@@ -23,6 +23,7 @@ import (
 	"github.com/a2aproject/a2a-go/a2asrv/eventqueue"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/runner"
 	"google.golang.org/adk/session"
 )
@@ -75,7 +76,14 @@ type Executor struct {
 }
 
 // NewExecutor creates an initialized [Executor] instance.
+//
+// The runners of all the executions share the credential service of the
+// RunnerConfig, which defaults to an in-memory service created here, so that
+// the credentials provided in a request are used by the next ones.
 func NewExecutor(config ExecutorConfig) *Executor {
+	if config.RunnerConfig.CredentialService == nil {
+		config.RunnerConfig.CredentialService = auth.InMemoryCredentialService()
+	}
 	return &Executor{config: config}
 }
 
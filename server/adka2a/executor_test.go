@@ -28,9 +28,14 @@ import (
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/modeltest"
 	"google.golang.org/adk/runner"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/adk/tool/toolconfirmation"
 )
 
 type testQueue struct {
@@ -275,6 +280,56 @@ func TestExecutor_Cancel(t *testing.T) {
 	}
 }
 
+func TestExecutor_ToolConfirmation(t *testing.T) {
+	type Args struct {
+		Amount int `json:"amount"`
+	}
+	transferTool, err := functiontool.New(functiontool.Config{Name: "transfer", RequireConfirmation: true}, func(ctx tool.Context, args Args) (map[string]any, error) {
+		return map[string]any{"transferred": args.Amount}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent, err := llmagent.New(llmagent.Config{
+		Name:  "agent",
+		Model: modeltest.New("test-model", modeltest.FunctionCall("transfer", map[string]any{"amount": 200})),
+		Tools: []tool.Tool{transferTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task := &a2a.Task{ID: a2a.NewTaskID(), ContextID: a2a.NewContextID()}
+	msg := a2a.NewMessageForTask(a2a.MessageRoleUser, task, a2a.TextPart{Text: "Transfer the money."})
+	runnerConfig := runner.Config{AppName: agent.Name(), Agent: agent, SessionService: session.InMemoryService()}
+	executor := NewExecutor(ExecutorConfig{RunnerConfig: runnerConfig})
+	queue := &testQueue{Queue: newInMemoryQueue(t)}
+	reqCtx := &a2asrv.RequestContext{TaskID: task.ID, ContextID: task.ContextID, Message: msg, StoredTask: task}
+
+	if err := executor.Execute(t.Context(), reqCtx, queue); err != nil {
+		t.Fatalf("executor.Execute() error = %v", err)
+	}
+
+	// The confirmation request pauses the task until the client responds.
+	var confirmationRequested bool
+	for _, ev := range queue.events {
+		if ev, ok := ev.(*a2a.TaskArtifactUpdateEvent); ok {
+			for _, p := range ev.Artifact.Parts {
+				if dp, ok := p.(a2a.DataPart); ok && dp.Data["name"] == toolconfirmation.FunctionCallName {
+					confirmationRequested = true
+				}
+			}
+		}
+	}
+	if !confirmationRequested {
+		t.Errorf("executor.Execute() events = %v, want a %s call", queue.events, toolconfirmation.FunctionCallName)
+	}
+	last, ok := queue.events[len(queue.events)-1].(*a2a.TaskStatusUpdateEvent)
+	if !ok || last.Status.State != a2a.TaskStateInputRequired || !last.Final {
+		t.Errorf("executor.Execute() last event = %v, want a final %s status update", queue.events[len(queue.events)-1], a2a.TaskStateInputRequired)
+	}
+}
+
 func TestExecutor_SessionReuse(t *testing.T) {
 	ctx := t.Context()
 	agent, err := newEventReplayAgent([]*session.Event{}, nil)
@@ -23,9 +23,12 @@ import (
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/plugin"
 	"google.golang.org/adk/runner"
 	"google.golang.org/adk/server/adkrest/internal/models"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/usage"
 )
 
 // RuntimeAPIController is the controller for the Runtime API.
@@ -34,11 +37,49 @@ type RuntimeAPIController struct {
 	sessionService  session.Service
 	artifactService artifact.Service
 	agentLoader     agent.Loader
+	usage           *usage.Config
+	plugins         []plugin.Plugin
+	// credentialService is shared by the runners of all the requests, so
+	// that the credentials provided in a request are used by the next ones.
+	credentialService auth.CredentialService
+}
+
+// RuntimeAPIOption configures the optional features of a
+// [RuntimeAPIController].
+type RuntimeAPIOption func(*RuntimeAPIController)
+
+// WithUsage enables the token usage accounting of the runs.
+func WithUsage(cfg *usage.Config) RuntimeAPIOption {
+	return func(c *RuntimeAPIController) {
+		c.usage = cfg
+	}
+}
+
+// WithPlugins sets the plugins of the runners.
+func WithPlugins(plugins ...plugin.Plugin) RuntimeAPIOption {
+	return func(c *RuntimeAPIController) {
+		c.plugins = plugins
+	}
+}
+
+// WithCredentialService sets the service storing the credentials of the
+// users. It defaults to an in-memory service shared by the requests.
+func WithCredentialService(s auth.CredentialService) RuntimeAPIOption {
+	return func(c *RuntimeAPIController) {
+		c.credentialService = s
+	}
 }
 
 // NewRuntimeAPIController creates the controller for the Runtime API.
-func NewRuntimeAPIController(sessionService session.Service, agentLoader agent.Loader, artifactService artifact.Service, sseTimeout time.Duration) *RuntimeAPIController {
-	return &RuntimeAPIController{sessionService: sessionService, agentLoader: agentLoader, artifactService: artifactService, sseTimeout: sseTimeout}
+func NewRuntimeAPIController(sessionService session.Service, agentLoader agent.Loader, artifactService artifact.Service, sseTimeout time.Duration, opts ...RuntimeAPIOption) *RuntimeAPIController {
+	c := &RuntimeAPIController{sessionService: sessionService, agentLoader: agentLoader, artifactService: artifactService, sseTimeout: sseTimeout}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.credentialService == nil {
+		c.credentialService = auth.InMemoryCredentialService()
+	}
+	return c
 }
 
 // RunAgent executes a non-streaming agent run for a given session and message.
@@ -175,10 +216,13 @@ func (c *RuntimeAPIController) getRunner(req models.RunAgentRequest) (*runner.Ru
 	}
 
 	r, err := runner.New(runner.Config{
-		AppName:         req.AppName,
-		Agent:           curAgent,
-		SessionService:  c.sessionService,
-		ArtifactService: c.artifactService,
+		AppName:           req.AppName,
+		Agent:             curAgent,
+		SessionService:    c.sessionService,
+		ArtifactService:   c.artifactService,
+		Usage:             c.usage,
+		CredentialService: c.credentialService,
+		Plugins:           c.plugins,
 	},
 	)
 	if err != nil {
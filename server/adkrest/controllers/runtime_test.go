@@ -0,0 +1,142 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/model/modeltest"
+	"google.golang.org/adk/server/adkrest/controllers"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+func TestRuntimeAPIController_CredentialsAcrossRequests(t *testing.T) {
+	authConfig := &auth.Config{
+		Scheme: &auth.Scheme{Type: auth.SchemeTypeAPIKey, In: "header", Name: "X-Key"},
+	}
+	profileTool, err := functiontool.New(functiontool.Config{
+		Name:        "get_profile",
+		Description: "returns the profile of the user",
+	}, func(ctx tool.Context, args struct{}) (map[string]any, error) {
+		cred, err := tool.Credential(ctx, authConfig)
+		if err != nil {
+			return nil, err
+		}
+		if cred == nil {
+			if err := tool.RequestCredential(ctx, authConfig); err != nil {
+				return nil, err
+			}
+			return map[string]any{"status": "pending"}, nil
+		}
+		return map[string]any{"profile": "profile of " + cred.APIKey}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	llm := modeltest.New("test-model",
+		modeltest.FunctionCall("get_profile", nil),
+		modeltest.Text("You are Alice."),
+		modeltest.FunctionCall("get_profile", nil),
+		modeltest.Text("Still Alice."),
+	)
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "agent",
+		Model: llm,
+		Tools: []tool.Tool{profileTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := t.Context()
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "agent", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	// Every request creates its own runner.
+	apiController := controllers.NewRuntimeAPIController(sessionService, agent.NewSingleLoader(a), nil, 0)
+	run := func(msg *genai.Content) {
+		t.Helper()
+		body, err := json.Marshal(map[string]any{"appName": "agent", "userId": "user", "sessionId": "session", "newMessage": msg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/run", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		if err := apiController.RunHandler(rr, req); err != nil {
+			t.Fatalf("RunHandler() error = %v", err)
+		}
+	}
+
+	// The first request pauses for the credential, which the second one
+	// provides.
+	run(genai.NewContentFromText("Who am I?", genai.RoleUser))
+	resp, err := sessionService.Get(ctx, &session.GetRequest{AppName: "agent", UserID: "user", SessionID: "session"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := resp.Session.Events()
+	request := events.At(events.Len() - 1).Content.Parts[0].FunctionCall
+	if request == nil || request.Name != "adk_request_credential" {
+		t.Fatalf("last event = %+v, want a credential request", events.At(events.Len()-1).Content)
+	}
+	requested, ok := request.Args["authConfig"].(*auth.Config)
+	if !ok {
+		data, err := json.Marshal(request.Args["authConfig"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		requested = &auth.Config{}
+		if err := json.Unmarshal(data, requested); err != nil {
+			t.Fatal(err)
+		}
+	}
+	requested.ExchangedCredential = &auth.Credential{Type: auth.CredentialTypeAPIKey, APIKey: "alice-key"}
+	data, err := json.Marshal(requested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response map[string]any
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatal(err)
+	}
+	run(&genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{
+		ID:       request.ID,
+		Name:     "adk_request_credential",
+		Response: response,
+	}}}})
+
+	// The third request uses the stored credential.
+	run(genai.NewContentFromText("Who am I again?", genai.RoleUser))
+	if err := llm.Done(); err != nil {
+		t.Fatal(err)
+	}
+	contents := llm.LastRequest().Contents
+	got := contents[len(contents)-1].Parts[0].FunctionResponse.Response
+	if got["profile"] != "profile of alice-key" {
+		t.Errorf("function response = %v, want the profile of alice-key", got)
+	}
+}
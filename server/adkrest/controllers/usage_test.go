@@ -0,0 +1,99 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/mux"
+
+	"google.golang.org/adk/server/adkrest/controllers"
+	"google.golang.org/adk/usage"
+)
+
+func TestGetUsage(t *testing.T) {
+	service := usage.InMemoryService()
+	for _, r := range []*usage.Record{
+		{AppName: "testApp", UserID: "testUser", SessionID: "s1", Agent: "root", Model: "m", Usage: usage.Usage{TotalTokens: 10, Calls: 1}},
+		{AppName: "testApp", UserID: "testUser", SessionID: "s2", Agent: "root", Model: "m", Usage: usage.Usage{TotalTokens: 5, Calls: 1}},
+	} {
+		if err := service.Add(t.Context(), r); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	tc := []struct {
+		name       string
+		vars       map[string]string
+		wantTotal  usage.Usage
+		wantErr    string
+		wantStatus int
+	}{
+		{
+			name:       "session",
+			vars:       map[string]string{"app_name": "testApp", "user_id": "testUser", "session_id": "s1"},
+			wantTotal:  usage.Usage{TotalTokens: 10, Calls: 1},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "user",
+			vars:       map[string]string{"app_name": "testApp", "user_id": "testUser"},
+			wantTotal:  usage.Usage{TotalTokens: 15, Calls: 2},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "user ID is missing",
+			vars:       map[string]string{"app_name": "testApp"},
+			wantErr:    "user_id parameter is required",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			apiController := controllers.NewUsageAPIController(service)
+			req, err := http.NewRequest(http.MethodGet, "/usage", nil)
+			if err != nil {
+				t.Fatalf("new request: %v", err)
+			}
+			req = mux.SetURLVars(req, tt.vars)
+			rr := httptest.NewRecorder()
+
+			apiController.GetUsageHandler(rr, req)
+
+			if status := rr.Code; status != tt.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tt.wantStatus)
+			}
+			if tt.wantErr != "" {
+				if respErr := strings.Trim(rr.Body.String(), "\n"); respErr != tt.wantErr {
+					t.Errorf("GetUsage() error = %q, want %q", respErr, tt.wantErr)
+				}
+				return
+			}
+			var got usage.GetResponse
+			if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantTotal, got.Total); diff != "" {
+				t.Errorf("GetUsage() total mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -36,14 +36,19 @@ func NewHandler(config *launcher.Config, sseWriteTimeout time.Duration) http.Han
 	router := mux.NewRouter().StrictSlash(true)
 	// TODO: Allow taking a prefix to allow customizing the path
 	// where the ADK REST API will be served.
-	setupRouter(router,
+	subrouters := []routers.Router{
 		routers.NewSessionsAPIRouter(controllers.NewSessionsAPIController(config.SessionService)),
-		routers.NewRuntimeAPIRouter(controllers.NewRuntimeAPIController(config.SessionService, config.AgentLoader, config.ArtifactService, sseWriteTimeout)),
+		routers.NewRuntimeAPIRouter(controllers.NewRuntimeAPIController(config.SessionService, config.AgentLoader, config.ArtifactService, sseWriteTimeout,
+			controllers.WithUsage(config.Usage), controllers.WithPlugins(config.Plugins...), controllers.WithCredentialService(config.CredentialService))),
 		routers.NewAppsAPIRouter(controllers.NewAppsAPIController(config.AgentLoader)),
 		routers.NewDebugAPIRouter(controllers.NewDebugAPIController(config.SessionService, config.AgentLoader, adkExporter)),
 		routers.NewArtifactsAPIRouter(controllers.NewArtifactsAPIController(config.ArtifactService)),
 		&routers.EvalAPIRouter{},
-	)
+	}
+	if config.Usage != nil {
+		subrouters = append(subrouters, routers.NewUsageAPIRouter(controllers.NewUsageAPIController(config.Usage.Service)))
+	}
+	setupRouter(router, subrouters...)
 	return router
 }
 
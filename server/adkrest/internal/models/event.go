@@ -19,14 +19,19 @@ import (
 
 	"google.golang.org/genai"
 
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool/toolconfirmation"
 )
 
 // EventActions represent a data model for session.EventActions
 type EventActions struct {
-	StateDelta    map[string]any   `json:"stateDelta"`
-	ArtifactDelta map[string]int64 `json:"artifactDelta"`
+	StateDelta                 map[string]any                            `json:"stateDelta"`
+	ArtifactDelta              map[string]int64                          `json:"artifactDelta"`
+	RequestedAuthConfigs       map[string]*auth.Config                   `json:"requestedAuthConfigs,omitempty"`
+	RequestedToolConfirmations map[string]*toolconfirmation.Confirmation `json:"requestedToolConfirmations,omitempty"`
+	Compaction                 *session.EventCompaction                  `json:"compaction,omitempty"`
 }
 
 // Event represents a single event in a session.
@@ -66,8 +71,11 @@ func ToSessionEvent(event Event) *session.Event {
 			ErrorMessage:      event.ErrorMessage,
 		},
 		Actions: session.EventActions{
-			StateDelta:    event.Actions.StateDelta,
-			ArtifactDelta: event.Actions.ArtifactDelta,
+			StateDelta:                 event.Actions.StateDelta,
+			ArtifactDelta:              event.Actions.ArtifactDelta,
+			RequestedAuthConfigs:       event.Actions.RequestedAuthConfigs,
+			RequestedToolConfirmations: event.Actions.RequestedToolConfirmations,
+			Compaction:                 event.Actions.Compaction,
 		},
 	}
 }
@@ -89,8 +97,11 @@ func FromSessionEvent(event session.Event) Event {
 		ErrorCode:          event.LLMResponse.ErrorCode,
 		ErrorMessage:       event.LLMResponse.ErrorMessage,
 		Actions: EventActions{
-			StateDelta:    event.Actions.StateDelta,
-			ArtifactDelta: event.Actions.ArtifactDelta,
+			StateDelta:                 event.Actions.StateDelta,
+			ArtifactDelta:              event.Actions.ArtifactDelta,
+			RequestedAuthConfigs:       event.Actions.RequestedAuthConfigs,
+			RequestedToolConfirmations: event.Actions.RequestedToolConfirmations,
+			Compaction:                 event.Actions.Compaction,
 		},
 	}
 }
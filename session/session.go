@@ -20,8 +20,11 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"google.golang.org/genai"
 
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool/toolconfirmation"
 )
 
 // Session represents a series of interactions between a user and agents.
@@ -154,6 +157,26 @@ type EventActions struct {
 	TransferToAgent string
 	// The agent is escalating to a higher level agent.
 	Escalate bool
+	// RequestedAuthConfigs are the auth configs of the credentials requested
+	// by the tools, by function call ID. Only valid for function response
+	// events.
+	RequestedAuthConfigs map[string]*auth.Config
+	// RequestedToolConfirmations are the confirmations of the tool calls
+	// requested from the user, by function call ID. Only valid for function
+	// response events.
+	RequestedToolConfirmations map[string]*toolconfirmation.Confirmation
+	// Compaction replaces the events of a time range of the session by their
+	// summary in the model requests. Only valid for compaction events.
+	Compaction *EventCompaction
+}
+
+// EventCompaction is the summary of the events of the session between
+// StartTime and EndTime, inclusive.
+type EventCompaction struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	// Content is the summary of the events.
+	Content *genai.Content `json:"content"`
 }
 
 // Prefixes for defining session's state scopes
@@ -15,6 +15,7 @@
 package agenttool_test
 
 import (
+	"encoding/json"
 	"log"
 	"testing"
 
@@ -23,15 +24,18 @@ import (
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/auth"
 	icontext "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/internal/sessioninternal"
 	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/model/gemini"
+	"google.golang.org/adk/model/modeltest"
 	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/agenttool"
+	"google.golang.org/adk/tool/functiontool"
 )
 
 func TestAgentTool_Declaration(t *testing.T) {
@@ -309,6 +313,40 @@ func TestAgentTool_Run_SkipSummarization(t *testing.T) {
 	}
 }
 
+func TestAgentTool_Run_SharedLimits(t *testing.T) {
+	child := createAgentWithModel(t, nil, nil, modeltest.New("child-model", modeltest.Text("child response")))
+	parent, err := llmagent.New(llmagent.Config{
+		Name: "parent",
+		Model: modeltest.New("parent-model",
+			modeltest.FunctionCall(child.Name(), map[string]any{"request": "magic"}),
+			modeltest.Text("parent response")),
+		Tools: []tool.Tool{agenttool.New(child, nil)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The LLM call of the child counts towards the limit of the invocation,
+	// which ends before the parent calls its model again.
+	runner := testutil.NewTestAgentRunner(t, parent)
+	var events []*session.Event
+	for event, err := range runner.RunContentWithConfig(t, "session",
+		genai.NewContentFromText("Hello", genai.RoleUser), agent.RunConfig{MaxLLMCalls: 2}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		events = append(events, event)
+	}
+	lastEvent := events[len(events)-1]
+	if lastEvent.ErrorCode != string(agent.LimitLLMCalls) {
+		t.Errorf("last event error code = %q, want %q", lastEvent.ErrorCode, agent.LimitLLMCalls)
+	}
+	response := events[len(events)-2].Content.Parts[0].FunctionResponse
+	if diff := cmp.Diff(map[string]any{"result": "child response"}, response.Response); diff != "" {
+		t.Errorf("agent tool response mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func createAgent(t *testing.T, inputSchema, outputSchema *genai.Schema) agent.Agent {
 	t.Helper()
 
@@ -369,3 +407,101 @@ func createToolContext(t *testing.T, testAgent agent.Agent) tool.Context {
 
 	return toolinternal.NewToolContext(ctx, "", &session.EventActions{})
 }
+
+func TestAgentTool_Run_SharedCredentials(t *testing.T) {
+	authConfig := &auth.Config{
+		Scheme: &auth.Scheme{Type: auth.SchemeTypeAPIKey, In: "header", Name: "X-Key"},
+	}
+	profileTool, err := functiontool.New(functiontool.Config{
+		Name:        "get_profile",
+		Description: "returns the profile of the user",
+	}, func(ctx tool.Context, args struct{}) (map[string]any, error) {
+		cred, err := tool.Credential(ctx, authConfig)
+		if err != nil {
+			return nil, err
+		}
+		if cred == nil {
+			if err := tool.RequestCredential(ctx, authConfig); err != nil {
+				return nil, err
+			}
+			return map[string]any{"status": "pending"}, nil
+		}
+		return map[string]any{"profile": "profile of " + cred.APIKey}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	childModel := modeltest.New("child-model",
+		modeltest.FunctionCall("get_profile", nil),
+		modeltest.Text("child response"))
+	child, err := llmagent.New(llmagent.Config{
+		Name:        "child",
+		Description: "child agent",
+		Model:       childModel,
+		Tools:       []tool.Tool{profileTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, err := llmagent.New(llmagent.Config{
+		Name: "parent",
+		Model: modeltest.New("parent-model",
+			modeltest.FunctionCall("get_profile", nil),
+			modeltest.Text("You are Alice."),
+			modeltest.FunctionCall(child.Name(), map[string]any{"request": "Who am I?"}),
+			modeltest.Text("parent response")),
+		Tools: []tool.Tool{profileTool, agenttool.New(child, nil)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, parent)
+
+	// The parent gets the credential from the client.
+	events, err := testutil.CollectEvents(runner.Run(t, "session", "Who am I?"))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	request := events[len(events)-1].Content.Parts[0].FunctionCall
+	var requested auth.Config
+	data, err := json.Marshal(request.Args["authConfig"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &requested); err != nil {
+		t.Fatal(err)
+	}
+	requested.ExchangedCredential = &auth.Credential{Type: auth.CredentialTypeAPIKey, APIKey: "alice-key"}
+	data, err = json.Marshal(requested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response map[string]any
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatal(err)
+	}
+	answer := &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{
+		ID:       request.ID,
+		Name:     "adk_request_credential",
+		Response: response,
+	}}}}
+	if _, err := testutil.CollectEvents(runner.RunContent(t, "session", answer)); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// The child called as a tool uses the credential of the parent.
+	texts, err := testutil.CollectTextParts(runner.Run(t, "session", "Ask the child"))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if diff := cmp.Diff([]string{"parent response"}, texts); diff != "" {
+		t.Errorf("Run() texts mismatch (-want +got):\n%s", diff)
+	}
+	if err := childModel.Done(); err != nil {
+		t.Fatal(err)
+	}
+	contents := childModel.LastRequest().Contents
+	if got := contents[len(contents)-1].Parts[0].FunctionResponse.Response["profile"]; got != "profile of alice-key" {
+		t.Errorf("child function response profile = %v, want %q", got, "profile of alice-key")
+	}
+}
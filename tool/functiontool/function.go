@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"reflect"
 	"runtime/debug"
+	"time"
 
 	"github.com/google/jsonschema-go/jsonschema"
 	"google.golang.org/genai"
@@ -46,6 +47,16 @@ type Config struct {
 	OutputSchema *jsonschema.Schema
 	// IsLongRunning makes a FunctionTool a long-running operation.
 	IsLongRunning bool
+	// Timeout, if set, limits the duration of the tool calls, overriding the
+	// tool timeout of the agent. The context of the tool is canceled at the
+	// deadline and the model gets a timeout error. The function must return
+	// once ctx.Done() is closed: its changes after the deadline are
+	// discarded.
+	Timeout time.Duration
+	// RequireConfirmation makes the calls of the tool wait for the
+	// confirmation of the user before running. See package
+	// [google.golang.org/adk/tool/toolconfirmation].
+	RequireConfirmation bool
 }
 
 // Func represents a Go function that can be wrapped in a tool.
@@ -115,6 +126,16 @@ func (f *functionTool[TArgs, TResults]) IsLongRunning() bool {
 	return f.cfg.IsLongRunning
 }
 
+// Timeout implements toolinternal.TimeoutTool.
+func (f *functionTool[TArgs, TResults]) Timeout() time.Duration {
+	return f.cfg.Timeout
+}
+
+// RequireConfirmation implements toolinternal.ConfirmationTool.
+func (f *functionTool[TArgs, TResults]) RequireConfirmation() bool {
+	return f.cfg.RequireConfirmation
+}
+
 // ProcessRequest packs the function tool's declaration into the LLM request.
 func (f *functionTool[TArgs, TResults]) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
 	return toolutils.PackTool(req, f)
@@ -18,11 +18,15 @@ package mcptoolset
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sync"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/internal/agent/runconfig"
 	"google.golang.org/adk/internal/version"
 	"google.golang.org/adk/tool"
 )
@@ -54,10 +58,16 @@ func New(cfg Config) (tool.Toolset, error) {
 	if client == nil {
 		client = mcp.NewClient(&mcp.Implementation{Name: "adk-mcp-client", Version: version.Version}, nil)
 	}
+	transport := cfg.Transport
+	if cfg.AuthConfig != nil {
+		transport = withAuthTransport(transport)
+	}
 	return &set{
 		client:     client,
-		transport:  cfg.Transport,
+		transport:  transport,
 		toolFilter: cfg.ToolFilter,
+		authConfig: cfg.AuthConfig,
+		timeout:    cfg.Timeout,
 	}, nil
 }
 
@@ -71,12 +81,53 @@ type Config struct {
 	// If ToolFilter is nil, then all tools are returned.
 	// tool.StringPredicate can be convenient if there's a known fixed list of tool names.
 	ToolFilter tool.Predicate
+	// AuthConfig, if set, authenticates the tool calls on behalf of the
+	// user, who is asked for the credential if needed. The credential is set
+	// on the HTTP requests of *mcp.StreamableClientTransport and
+	// *mcp.SSEClientTransport. Other transports must send their requests
+	// with an [auth.Transport].
+	//
+	// The session setup and the listing of the tools use the credential of
+	// the user too, if it's already stored, but never ask for it. The
+	// session is shared by the users: it's set up with the credential of the
+	// user whose request opens it.
+	AuthConfig *auth.Config
+	// Timeout, if set, limits the duration of the tool calls, overriding the
+	// tool timeout of the agent.
+	Timeout time.Duration
+}
+
+// withAuthTransport returns the transport with its HTTP client setting the
+// credentials of the tool calls on the requests.
+func withAuthTransport(t mcp.Transport) mcp.Transport {
+	switch t := t.(type) {
+	case *mcp.StreamableClientTransport:
+		c := *t
+		c.HTTPClient = withAuth(c.HTTPClient)
+		return &c
+	case *mcp.SSEClientTransport:
+		c := *t
+		c.HTTPClient = withAuth(c.HTTPClient)
+		return &c
+	}
+	return t
+}
+
+func withAuth(client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c := *client
+	c.Transport = &auth.Transport{Base: client.Transport}
+	return &c
 }
 
 type set struct {
 	client     *mcp.Client
 	transport  mcp.Transport
 	toolFilter tool.Predicate
+	authConfig *auth.Config
+	timeout    time.Duration
 
 	mu      sync.Mutex
 	session *mcp.ClientSession
@@ -96,7 +147,11 @@ func (*set) IsLongRunning() bool {
 
 // Tools fetch MCP tools from the server, convert to adk tool.Tool and filter by name.
 func (s *set) Tools(ctx agent.ReadonlyContext) ([]tool.Tool, error) {
-	session, err := s.getSession(ctx)
+	listCtx, err := s.authContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	session, err := s.getSession(listCtx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get MCP session: %w", err)
 	}
@@ -105,7 +160,7 @@ func (s *set) Tools(ctx agent.ReadonlyContext) ([]tool.Tool, error) {
 
 	cursor := ""
 	for {
-		resp, err := session.ListTools(ctx, &mcp.ListToolsParams{
+		resp, err := session.ListTools(listCtx, &mcp.ListToolsParams{
 			Cursor: cursor,
 		})
 		if err != nil {
@@ -113,7 +168,7 @@ func (s *set) Tools(ctx agent.ReadonlyContext) ([]tool.Tool, error) {
 		}
 
 		for _, mcpTool := range resp.Tools {
-			t, err := convertTool(mcpTool, s.getSession)
+			t, err := convertTool(mcpTool, s.getSession, s.authConfig, s.timeout)
 			if err != nil {
 				return nil, fmt.Errorf("failed to convert MCP tool %q to adk tool: %w", mcpTool.Name, err)
 			}
@@ -134,6 +189,28 @@ func (s *set) Tools(ctx agent.ReadonlyContext) ([]tool.Tool, error) {
 	return adkTools, nil
 }
 
+// authContext returns ctx carrying the stored credential of the user, if
+// any, for the servers which also authenticate the session setup and the
+// listing of the tools. Only the tool calls can ask for a missing credential.
+func (s *set) authContext(ctx agent.ReadonlyContext) (context.Context, error) {
+	if s.authConfig == nil {
+		return ctx, nil
+	}
+	runCfg := runconfig.FromContext(ctx)
+	if runCfg == nil || runCfg.CredentialService == nil {
+		return ctx, nil
+	}
+	scope := runCfg.ReadonlyScopeOf(ctx)
+	cred, err := auth.GetCredential(ctx, runCfg.CredentialService, scope.AppName, scope.UserID, s.authConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credential: %w", err)
+	}
+	if cred == nil {
+		return ctx, nil
+	}
+	return auth.NewContext(ctx, s.authConfig.Scheme, cred), nil
+}
+
 func (s *set) getSession(ctx context.Context) (*mcp.ClientSession, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
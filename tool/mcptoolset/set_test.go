@@ -20,6 +20,7 @@ import (
 	"iter"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -31,9 +32,12 @@ import (
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/auth"
+	"google.golang.org/adk/internal/agent/runconfig"
 	icontext "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/internal/httprr"
 	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/model/gemini"
 	"google.golang.org/adk/runner"
@@ -307,3 +311,147 @@ func TestToolFilter(t *testing.T) {
 		t.Errorf("tools mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestAuth(t *testing.T) {
+	ctx := t.Context()
+	server := mcp.NewServer(&mcp.Implementation{Name: "profile_server", Version: "v1.0.0"}, nil)
+	type Args struct{}
+	mcp.AddTool(server, &mcp.Tool{Name: "whoami", Description: "returns the authorization of the caller"},
+		func(ctx context.Context, req *mcp.CallToolRequest, args Args) (*mcp.CallToolResult, any, error) {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{&mcp.TextContent{Text: req.Extra.Header.Get("Authorization")}},
+			}, nil, nil
+		})
+	httpServer := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	defer httpServer.Close()
+	// The client keeps a standalone SSE stream open, close it before shutting down the server.
+	defer httpServer.CloseClientConnections()
+
+	authConfig := &auth.Config{Scheme: &auth.Scheme{Type: auth.SchemeTypeHTTP, HTTPScheme: "bearer"}}
+	ts, err := mcptoolset.New(mcptoolset.Config{
+		Transport:  &mcp.StreamableClientTransport{Endpoint: httpServer.URL},
+		AuthConfig: authConfig,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create MCP tool set: %v", err)
+	}
+
+	credentialService := auth.InMemoryCredentialService()
+	sessionService := session.InMemoryService()
+	resp, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invCtx := icontext.NewInvocationContext(
+		runconfig.ToContext(ctx, &runconfig.RunConfig{CredentialService: credentialService}),
+		icontext.InvocationContextParams{Session: resp.Session},
+	)
+	tools, err := ts.Tools(icontext.NewReadonlyContext(invCtx))
+	if err != nil {
+		t.Fatalf("Failed to get tools: %v", err)
+	}
+	whoami := tools[0].(toolinternal.FunctionTool)
+
+	// Without credential, the tool requests it.
+	actions := &session.EventActions{}
+	got, err := whoami.Run(toolinternal.NewToolContext(invCtx, "call1", actions), map[string]any{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if diff := cmp.Diff(map[string]any{"output": "Pending user authorization."}, got); diff != "" {
+		t.Errorf("Run() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]*auth.Config{"call1": authConfig}, actions.RequestedAuthConfigs); diff != "" {
+		t.Errorf("RequestedAuthConfigs mismatch (-want +got):\n%s", diff)
+	}
+
+	// With the credential provided by the user, the call is authenticated.
+	err = credentialService.Save(ctx, &auth.SaveRequest{
+		AppName:    "app",
+		UserID:     "user",
+		Key:        authConfig.Key(),
+		Credential: &auth.Credential{Type: auth.CredentialTypeHTTP, HTTP: &auth.HTTPCredential{Scheme: "bearer", Token: "token"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = whoami.Run(toolinternal.NewToolContext(invCtx, "call2", &session.EventActions{}), map[string]any{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if diff := cmp.Diff(map[string]any{"output": "Bearer token"}, got); diff != "" {
+		t.Errorf("Run() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAuth_SessionAndListing(t *testing.T) {
+	ctx := t.Context()
+	server := mcp.NewServer(&mcp.Implementation{Name: "profile_server", Version: "v1.0.0"}, nil)
+	type Args struct{}
+	mcp.AddTool(server, &mcp.Tool{Name: "whoami", Description: "returns the authorization of the caller"},
+		func(ctx context.Context, req *mcp.CallToolRequest, args Args) (*mcp.CallToolResult, any, error) {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{&mcp.TextContent{Text: req.Extra.Header.Get("Authorization")}},
+			}, nil, nil
+		})
+	// The server authenticates all the messages of the client, including the
+	// session setup and the listing of the tools.
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil)
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer httpServer.Close()
+	defer httpServer.CloseClientConnections()
+
+	authConfig := &auth.Config{Scheme: &auth.Scheme{Type: auth.SchemeTypeHTTP, HTTPScheme: "bearer"}}
+	ts, err := mcptoolset.New(mcptoolset.Config{
+		Transport:  &mcp.StreamableClientTransport{Endpoint: httpServer.URL},
+		AuthConfig: authConfig,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create MCP tool set: %v", err)
+	}
+
+	credentialService := auth.InMemoryCredentialService()
+	sessionService := session.InMemoryService()
+	resp, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invCtx := icontext.NewInvocationContext(
+		runconfig.ToContext(ctx, &runconfig.RunConfig{CredentialService: credentialService}),
+		icontext.InvocationContextParams{Session: resp.Session},
+	)
+
+	// Without credential, the server refuses the session.
+	if _, err := ts.Tools(icontext.NewReadonlyContext(invCtx)); err == nil {
+		t.Fatal("Tools() without credential succeeded, want error")
+	}
+
+	// With the credential stored, the session is set up and the tools are
+	// listed.
+	err = credentialService.Save(ctx, &auth.SaveRequest{
+		AppName:    "app",
+		UserID:     "user",
+		Key:        authConfig.Key(),
+		Credential: &auth.Credential{Type: auth.CredentialTypeHTTP, HTTP: &auth.HTTPCredential{Scheme: "bearer", Token: "token"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools, err := ts.Tools(icontext.NewReadonlyContext(invCtx))
+	if err != nil {
+		t.Fatalf("Tools() error = %v", err)
+	}
+	got, err := tools[0].(toolinternal.FunctionTool).Run(toolinternal.NewToolContext(invCtx, "call1", &session.EventActions{}), map[string]any{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if diff := cmp.Diff(map[string]any{"output": "Bearer token"}, got); diff != "" {
+		t.Errorf("Run() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -19,10 +19,12 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"google.golang.org/genai"
 
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/internal/toolinternal/toolutils"
 	"google.golang.org/adk/model"
@@ -31,7 +33,7 @@ import (
 
 type getSessionFunc func(ctx context.Context) (*mcp.ClientSession, error)
 
-func convertTool(t *mcp.Tool, getSessionFunc getSessionFunc) (tool.Tool, error) {
+func convertTool(t *mcp.Tool, getSessionFunc getSessionFunc, authConfig *auth.Config, timeout time.Duration) (tool.Tool, error) {
 	mcp := &mcpTool{
 		name:        t.Name,
 		description: t.Description,
@@ -40,6 +42,8 @@ func convertTool(t *mcp.Tool, getSessionFunc getSessionFunc) (tool.Tool, error)
 			Description: t.Description,
 		},
 		getSessionFunc: getSessionFunc,
+		authConfig:     authConfig,
+		timeout:        timeout,
 	}
 
 	// Since t.InputSchema and t.OutputSchema are pointers (*jsonschema.Schema) and the destination ResponseJsonSchema
@@ -62,6 +66,8 @@ type mcpTool struct {
 	funcDeclaration *genai.FunctionDeclaration
 
 	getSessionFunc getSessionFunc
+	authConfig     *auth.Config
+	timeout        time.Duration
 }
 
 // Name implements the tool.Tool.
@@ -79,6 +85,11 @@ func (t *mcpTool) IsLongRunning() bool {
 	return false
 }
 
+// Timeout implements the toolinternal.TimeoutTool.
+func (t *mcpTool) Timeout() time.Duration {
+	return t.timeout
+}
+
 func (t *mcpTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
 	return toolutils.PackTool(req, t)
 }
@@ -88,13 +99,28 @@ func (t *mcpTool) Declaration() *genai.FunctionDeclaration {
 }
 
 func (t *mcpTool) Run(ctx tool.Context, args any) (map[string]any, error) {
-	session, err := t.getSessionFunc(ctx)
+	var callCtx context.Context = ctx
+	if t.authConfig != nil {
+		cred, err := tool.Credential(ctx, t.authConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get credential: %w", err)
+		}
+		if cred == nil {
+			if err := tool.RequestCredential(ctx, t.authConfig); err != nil {
+				return nil, fmt.Errorf("failed to request credential: %w", err)
+			}
+			return map[string]any{"output": "Pending user authorization."}, nil
+		}
+		callCtx = auth.NewContext(ctx, t.authConfig.Scheme, cred)
+	}
+
+	// The session is set up with the credential if the call opens it.
+	session, err := t.getSessionFunc(callCtx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
 
-	// TODO: add auth
-	res, err := session.CallTool(ctx, &mcp.CallToolParams{
+	res, err := session.CallTool(callCtx, &mcp.CallToolParams{
 		Name:      t.name,
 		Arguments: args,
 	})
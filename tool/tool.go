@@ -19,8 +19,10 @@ package tool
 
 import (
 	"context"
+	"errors"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/session"
 )
@@ -53,6 +55,52 @@ type Context interface {
 	SearchMemory(context.Context, string) (*memory.SearchResponse, error)
 }
 
+// CredentialContext is implemented by the contexts of the tools which can
+// call APIs on behalf of the user, such as the contexts of the tools called
+// by a runner. See [Credential] and [RequestCredential].
+type CredentialContext interface {
+	Context
+	// Credential returns the credential of the user to call the API
+	// protected as described by the auth config, or nil if the user must
+	// provide or authorize it with RequestCredential. See [auth.GetCredential].
+	Credential(cfg *auth.Config) (*auth.Credential, error)
+	// RequestCredential requests the credential of the auth config from the
+	// client. The agent run pauses after the function call, and the tool is
+	// called again once the client provides the credential.
+	RequestCredential(cfg *auth.Config)
+}
+
+var errNoCredentials = errors.New("tool context doesn't support credentials")
+
+// Credential returns the credential of the user to call the API protected as
+// described by the auth config, or nil if the user must provide or authorize
+// it with [RequestCredential]. It fails if ctx isn't a [CredentialContext].
+func Credential(ctx Context, cfg *auth.Config) (*auth.Credential, error) {
+	c, ok := ctx.(CredentialContext)
+	if !ok {
+		return nil, errNoCredentials
+	}
+	return c.Credential(cfg)
+}
+
+// RequestCredential requests the credential of the auth config from the
+// client. The agent run pauses after the function call, and the tool is
+// called again once the client provides the credential. It fails if ctx isn't
+// a [CredentialContext].
+func RequestCredential(ctx Context, cfg *auth.Config) error {
+	c, ok := ctx.(CredentialContext)
+	if !ok {
+		return errNoCredentials
+	}
+	c.RequestCredential(cfg)
+	return nil
+}
+
+// ConfirmationPolicy reports whether the call of the tool with the arguments
+// must be confirmed by the user before the tool runs. See package
+// [google.golang.org/adk/tool/toolconfirmation].
+type ConfirmationPolicy func(ctx agent.ReadonlyContext, tool Tool, args map[string]any) bool
+
 // Toolset is an interface for a collection of tools. It allows grouping
 // related tools together and providing them to an agent.
 type Toolset interface {
@@ -17,6 +17,7 @@ package tool_test
 import (
 	"testing"
 
+	"google.golang.org/adk/auth"
 	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/agenttool"
@@ -103,3 +104,18 @@ func TestTypes(t *testing.T) {
 		})
 	}
 }
+
+// customContext is a tool context implemented outside of the module.
+type customContext struct {
+	tool.Context
+}
+
+func TestCredential_UnsupportedContext(t *testing.T) {
+	cfg := &auth.Config{Scheme: &auth.Scheme{Type: auth.SchemeTypeAPIKey, In: "header", Name: "X-Key"}}
+	if _, err := tool.Credential(customContext{}, cfg); err == nil {
+		t.Error("Credential() succeeded, want error")
+	}
+	if err := tool.RequestCredential(customContext{}, cfg); err == nil {
+		t.Error("RequestCredential() succeeded, want error")
+	}
+}
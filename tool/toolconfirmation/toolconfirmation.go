@@ -0,0 +1,52 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package toolconfirmation defines the confirmation of tool calls by the
+// user, which lets a human approve, reject or edit the calls of sensitive
+// tools before they run.
+//
+// When a call requires confirmation, the tool doesn't run and the agent
+// yields an event with a long-running function call named
+// [FunctionCallName], whose arguments are a [Request]. The run pauses until
+// the client responds to this function call with a [Confirmation] as the
+// function response, which resumes the original call.
+package toolconfirmation
+
+import "google.golang.org/genai"
+
+// FunctionCallName is the name of the function calls requesting the
+// confirmation of the tool calls from the client.
+const FunctionCallName = "adk_request_confirmation"
+
+// Confirmation is the confirmation of a tool call.
+type Confirmation struct {
+	// Hint explains to the user what to confirm.
+	Hint string `json:"hint,omitempty"`
+	// Confirmed reports whether the user approved the call. A rejected call
+	// doesn't run and the model is told so.
+	Confirmed bool `json:"confirmed"`
+	// Args are the arguments of the call to confirm. If set by the client in
+	// the response, the tool is called with them instead, e.g. to let the
+	// user edit the arguments proposed by the model.
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// Request are the arguments of the function calls requesting confirmation.
+type Request struct {
+	// OriginalFunctionCall is the call to confirm.
+	OriginalFunctionCall *genai.FunctionCall `json:"originalFunctionCall"`
+	// ToolConfirmation is the confirmation to complete and send back as the
+	// function response.
+	ToolConfirmation *Confirmation `json:"toolConfirmation"`
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// InMemoryService returns a new in-memory implementation of the usage service. Thread-safe.
+func InMemoryService() Service {
+	return &inMemoryService{
+		store: make(map[key][]Record),
+	}
+}
+
+type key struct {
+	appName, userID string
+}
+
+// inMemoryService is an in-memory implementation of Service.
+type inMemoryService struct {
+	mu    sync.RWMutex
+	store map[key][]Record
+}
+
+func (s *inMemoryService) Add(ctx context.Context, r *Record) error {
+	if r == nil {
+		return fmt.Errorf("record is nil")
+	}
+	if r.AppName == "" || r.UserID == "" {
+		return fmt.Errorf("app_name and user_id are required, got app_name: %q, user_id: %q", r.AppName, r.UserID)
+	}
+
+	k := key{appName: r.AppName, userID: r.UserID}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store[k] = append(s.store[k], *r)
+	return nil
+}
+
+func (s *inMemoryService) Get(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	if req.AppName == "" || req.UserID == "" {
+		return nil, fmt.Errorf("app_name and user_id are required, got app_name: %q, user_id: %q", req.AppName, req.UserID)
+	}
+
+	res := &GetResponse{
+		ByAgent: map[string]Usage{},
+		ByModel: map[string]Usage{},
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, r := range s.store[key{appName: req.AppName, userID: req.UserID}] {
+		if req.SessionID != "" && r.SessionID != req.SessionID {
+			continue
+		}
+		if req.InvocationID != "" && r.InvocationID != req.InvocationID {
+			continue
+		}
+		res.Total.Add(r.Usage)
+		add(res.ByAgent, r.Agent, r.Usage)
+		add(res.ByModel, r.Model, r.Usage)
+	}
+	return res, nil
+}
+
+func add(m map[string]Usage, name string, u Usage) {
+	v := m[name]
+	v.Add(u)
+	m[name] = v
+}
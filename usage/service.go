@@ -0,0 +1,48 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+import (
+	"context"
+)
+
+// Service is a definition of the usage service.
+//
+// The service stores the usage records of model calls and aggregates them.
+type Service interface {
+	// Add stores a usage record.
+	Add(ctx context.Context, r *Record) error
+	// Get returns the aggregated usage of the records matching the request.
+	Get(ctx context.Context, req *GetRequest) (*GetResponse, error)
+}
+
+// GetRequest represents a request to aggregate usage. AppName and UserID
+// are required. An empty SessionID or InvocationID matches all the sessions
+// or invocations.
+type GetRequest struct {
+	AppName      string
+	UserID       string
+	SessionID    string
+	InvocationID string
+}
+
+// GetResponse represents the aggregated usage.
+type GetResponse struct {
+	Total Usage `json:"total"`
+	// ByAgent is the usage per agent name.
+	ByAgent map[string]Usage `json:"byAgent"`
+	// ByModel is the usage per model name.
+	ByModel map[string]Usage `json:"byModel"`
+}
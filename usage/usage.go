@@ -0,0 +1,192 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package usage provides token usage and cost accounting for agents.
+//
+// The runner records the usage metadata of every model call made by the
+// agents, prices it with a [PriceTable] and stores it in a [Service]. The
+// recorded usage can be aggregated per invocation, session and user, and
+// optional [Budget] limits end the invocation once they are exceeded.
+package usage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// Config configures the usage accounting of a runner.
+type Config struct {
+	// Service stores the usage records. Required.
+	Service Service
+	// Prices is used to compute the cost of the model calls. Models without
+	// a price have no cost.
+	Prices PriceTable
+	// Budget limits the usage. The zero value means no limits.
+	Budget Budget
+}
+
+// Usage is the token usage and cost of one or more model calls.
+type Usage struct {
+	// PromptTokens is the number of tokens in the prompts, including the
+	// cached ones.
+	PromptTokens int64 `json:"promptTokens"`
+	// CandidatesTokens is the number of tokens in the generated responses.
+	CandidatesTokens int64 `json:"candidatesTokens"`
+	// CachedTokens is the number of prompt tokens read from the cache.
+	CachedTokens int64 `json:"cachedTokens"`
+	// ThoughtsTokens is the number of tokens used for thinking.
+	ThoughtsTokens int64 `json:"thoughtsTokens"`
+	// ToolUsePromptTokens is the number of tokens in the tool use prompts.
+	ToolUsePromptTokens int64 `json:"toolUsePromptTokens"`
+	// TotalTokens is the total number of tokens reported by the model.
+	TotalTokens int64 `json:"totalTokens"`
+	// Calls is the number of model calls.
+	Calls int64 `json:"calls"`
+	// Cost is the cost of the model calls, in the currency of the
+	// [PriceTable].
+	Cost float64 `json:"cost"`
+}
+
+// FromMetadata returns the usage of a single model call.
+func FromMetadata(md *genai.GenerateContentResponseUsageMetadata) Usage {
+	if md == nil {
+		return Usage{Calls: 1}
+	}
+	return Usage{
+		PromptTokens:        int64(md.PromptTokenCount),
+		CandidatesTokens:    int64(md.CandidatesTokenCount),
+		CachedTokens:        int64(md.CachedContentTokenCount),
+		ThoughtsTokens:      int64(md.ThoughtsTokenCount),
+		ToolUsePromptTokens: int64(md.ToolUsePromptTokenCount),
+		TotalTokens:         int64(md.TotalTokenCount),
+		Calls:               1,
+	}
+}
+
+// Add adds o to u.
+func (u *Usage) Add(o Usage) {
+	u.PromptTokens += o.PromptTokens
+	u.CandidatesTokens += o.CandidatesTokens
+	u.CachedTokens += o.CachedTokens
+	u.ThoughtsTokens += o.ThoughtsTokens
+	u.ToolUsePromptTokens += o.ToolUsePromptTokens
+	u.TotalTokens += o.TotalTokens
+	u.Calls += o.Calls
+	u.Cost += o.Cost
+}
+
+// Price is the price of a model, per million tokens.
+type Price struct {
+	// Input is the price of the prompt tokens.
+	Input float64
+	// CachedInput is the price of the prompt tokens read from the cache.
+	// If zero, Input is used.
+	CachedInput float64
+	// Output is the price of the generated tokens, thoughts included.
+	Output float64
+}
+
+// Cost returns the cost of the usage.
+func (p Price) Cost(u Usage) float64 {
+	cachedPrice := p.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = p.Input
+	}
+	input := u.PromptTokens - u.CachedTokens + u.ToolUsePromptTokens
+	output := u.CandidatesTokens + u.ThoughtsTokens
+	return (float64(input)*p.Input + float64(u.CachedTokens)*cachedPrice + float64(output)*p.Output) / 1e6
+}
+
+// PriceTable maps model names to their prices.
+//
+// A model name matches an entry with the same name, or else the entry with
+// the longest name that is a prefix of it, so that "gemini-2.5-flash" prices
+// "gemini-2.5-flash-001" as well.
+type PriceTable map[string]Price
+
+// Lookup returns the price of the model.
+func (t PriceTable) Lookup(modelName string) (Price, bool) {
+	if p, ok := t[modelName]; ok {
+		return p, true
+	}
+	var price Price
+	var match string
+	for name, p := range t {
+		if len(name) > len(match) && strings.HasPrefix(modelName, name) {
+			price, match = p, name
+		}
+	}
+	return price, match != ""
+}
+
+// Budget limits the usage of an invocation, session and user. When a limit
+// is exceeded, the model is no longer called: the invocation ends with an
+// event whose error code is [ErrorCodeBudgetExceeded].
+type Budget struct {
+	Invocation Limit
+	Session    Limit
+	// User limits the usage of a user across all the sessions of the app.
+	User Limit
+}
+
+// Limit is a usage limit. Zero fields mean no limit.
+type Limit struct {
+	MaxTokens int64
+	MaxCost   float64
+}
+
+// Exceeded reports whether the usage exceeds the limit.
+func (l Limit) Exceeded(u Usage) bool {
+	return (l.MaxTokens > 0 && u.TotalTokens > l.MaxTokens) || (l.MaxCost > 0 && u.Cost > l.MaxCost)
+}
+
+// IsZero reports whether the limit has no limits set.
+func (l Limit) IsZero() bool {
+	return l.MaxTokens <= 0 && l.MaxCost <= 0
+}
+
+// ErrorCodeBudgetExceeded is the error code of the event ending an
+// invocation which exceeded its [Budget].
+const ErrorCodeBudgetExceeded = "USAGE_BUDGET_EXCEEDED"
+
+// BudgetExceededError is the error of an invocation which exceeded a limit
+// of its [Budget].
+type BudgetExceededError struct {
+	// Scope is the scope of the limit: "invocation", "session" or "user".
+	Scope string
+	Limit Limit
+	// Usage is the usage in the scope of the limit.
+	Usage Usage
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s usage of %d tokens and cost %.6g exceeded the budget", e.Scope, e.Usage.TotalTokens, e.Usage.Cost)
+}
+
+// Record is the usage of a model call.
+type Record struct {
+	AppName      string
+	UserID       string
+	SessionID    string
+	InvocationID string
+	// Agent is the name of the agent that made the call.
+	Agent string
+	// Model is the name of the model.
+	Model string
+	Usage Usage
+	Time  time.Time
+}
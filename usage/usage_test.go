@@ -0,0 +1,189 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/usage"
+)
+
+func TestPriceTable_Lookup(t *testing.T) {
+	prices := usage.PriceTable{
+		"gemini":                {Input: 1},
+		"gemini-2.5-flash":      {Input: 2},
+		"gemini-2.5-flash-lite": {Input: 3},
+	}
+	tests := []struct {
+		model  string
+		want   usage.Price
+		wantOK bool
+	}{
+		{model: "gemini-2.5-flash", want: usage.Price{Input: 2}, wantOK: true},
+		{model: "gemini-2.5-flash-001", want: usage.Price{Input: 2}, wantOK: true},
+		{model: "gemini-2.5-flash-lite-001", want: usage.Price{Input: 3}, wantOK: true},
+		{model: "gemini-2.0-pro", want: usage.Price{Input: 1}, wantOK: true},
+		{model: "gpt-4o", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, ok := prices.Lookup(tt.model)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Lookup(%q) = %v, %v, want %v, %v", tt.model, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPrice_Cost(t *testing.T) {
+	u := usage.FromMetadata(&genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:        1_000_000,
+		CachedContentTokenCount: 400_000,
+		CandidatesTokenCount:    100_000,
+		ThoughtsTokenCount:      100_000,
+		TotalTokenCount:         1_200_000,
+	})
+	tests := []struct {
+		name  string
+		price usage.Price
+		want  float64
+	}{
+		{name: "cached price", price: usage.Price{Input: 1, CachedInput: 0.25, Output: 10}, want: 0.6 + 0.1 + 2},
+		{name: "no cached price", price: usage.Price{Input: 1, Output: 10}, want: 1 + 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.price.Cost(u); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Cost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimit_Exceeded(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit usage.Limit
+		usage usage.Usage
+		want  bool
+	}{
+		{name: "no limit", usage: usage.Usage{TotalTokens: 100, Cost: 1}, want: false},
+		{name: "tokens at limit", limit: usage.Limit{MaxTokens: 100}, usage: usage.Usage{TotalTokens: 100}, want: false},
+		{name: "tokens over limit", limit: usage.Limit{MaxTokens: 100}, usage: usage.Usage{TotalTokens: 101}, want: true},
+		{name: "cost over limit", limit: usage.Limit{MaxTokens: 100, MaxCost: 0.5}, usage: usage.Usage{TotalTokens: 10, Cost: 0.6}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.limit.Exceeded(tt.usage); got != tt.want {
+				t.Errorf("Exceeded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInMemoryService(t *testing.T) {
+	ctx := t.Context()
+	s := usage.InMemoryService()
+
+	records := []*usage.Record{
+		{AppName: "app", UserID: "u1", SessionID: "s1", InvocationID: "i1", Agent: "root", Model: "m1", Usage: usage.Usage{PromptTokens: 10, TotalTokens: 15, Calls: 1, Cost: 1}},
+		{AppName: "app", UserID: "u1", SessionID: "s1", InvocationID: "i1", Agent: "helper", Model: "m2", Usage: usage.Usage{PromptTokens: 20, TotalTokens: 25, Calls: 1, Cost: 2}},
+		{AppName: "app", UserID: "u1", SessionID: "s1", InvocationID: "i2", Agent: "root", Model: "m1", Usage: usage.Usage{PromptTokens: 30, TotalTokens: 35, Calls: 1}},
+		{AppName: "app", UserID: "u1", SessionID: "s2", InvocationID: "i3", Agent: "root", Model: "m1", Usage: usage.Usage{PromptTokens: 40, TotalTokens: 45, Calls: 1}},
+		{AppName: "app", UserID: "u2", SessionID: "s3", InvocationID: "i4", Agent: "root", Model: "m1", Usage: usage.Usage{PromptTokens: 50, TotalTokens: 55, Calls: 1}},
+		{AppName: "other", UserID: "u1", SessionID: "s4", InvocationID: "i5", Agent: "root", Model: "m1", Usage: usage.Usage{PromptTokens: 60, TotalTokens: 65, Calls: 1}},
+	}
+	for _, r := range records {
+		if err := s.Add(ctx, r); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		req  *usage.GetRequest
+		want *usage.GetResponse
+	}{
+		{
+			name: "invocation",
+			req:  &usage.GetRequest{AppName: "app", UserID: "u1", SessionID: "s1", InvocationID: "i1"},
+			want: &usage.GetResponse{
+				Total: usage.Usage{PromptTokens: 30, TotalTokens: 40, Calls: 2, Cost: 3},
+				ByAgent: map[string]usage.Usage{
+					"root":   {PromptTokens: 10, TotalTokens: 15, Calls: 1, Cost: 1},
+					"helper": {PromptTokens: 20, TotalTokens: 25, Calls: 1, Cost: 2},
+				},
+				ByModel: map[string]usage.Usage{
+					"m1": {PromptTokens: 10, TotalTokens: 15, Calls: 1, Cost: 1},
+					"m2": {PromptTokens: 20, TotalTokens: 25, Calls: 1, Cost: 2},
+				},
+			},
+		},
+		{
+			name: "session",
+			req:  &usage.GetRequest{AppName: "app", UserID: "u1", SessionID: "s1"},
+			want: &usage.GetResponse{
+				Total: usage.Usage{PromptTokens: 60, TotalTokens: 75, Calls: 3, Cost: 3},
+				ByAgent: map[string]usage.Usage{
+					"root":   {PromptTokens: 40, TotalTokens: 50, Calls: 2, Cost: 1},
+					"helper": {PromptTokens: 20, TotalTokens: 25, Calls: 1, Cost: 2},
+				},
+				ByModel: map[string]usage.Usage{
+					"m1": {PromptTokens: 40, TotalTokens: 50, Calls: 2, Cost: 1},
+					"m2": {PromptTokens: 20, TotalTokens: 25, Calls: 1, Cost: 2},
+				},
+			},
+		},
+		{
+			name: "user",
+			req:  &usage.GetRequest{AppName: "app", UserID: "u1"},
+			want: &usage.GetResponse{
+				Total: usage.Usage{PromptTokens: 100, TotalTokens: 120, Calls: 4, Cost: 3},
+				ByAgent: map[string]usage.Usage{
+					"root":   {PromptTokens: 80, TotalTokens: 95, Calls: 3, Cost: 1},
+					"helper": {PromptTokens: 20, TotalTokens: 25, Calls: 1, Cost: 2},
+				},
+				ByModel: map[string]usage.Usage{
+					"m1": {PromptTokens: 80, TotalTokens: 95, Calls: 3, Cost: 1},
+					"m2": {PromptTokens: 20, TotalTokens: 25, Calls: 1, Cost: 2},
+				},
+			},
+		},
+		{
+			name: "no records",
+			req:  &usage.GetRequest{AppName: "app", UserID: "u3"},
+			want: &usage.GetResponse{ByAgent: map[string]usage.Usage{}, ByModel: map[string]usage.Usage{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Get(ctx, tt.req)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Get() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := s.Get(ctx, &usage.GetRequest{AppName: "app"}); err == nil {
+		t.Error("Get() without user_id succeeded, want error")
+	}
+}